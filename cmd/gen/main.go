@@ -0,0 +1,139 @@
+// This program generates rokka/operations_objects.go and rokka/stackoptions_objects.go.
+//
+// The schemas are read from the files passed using -operations and -stackoptions, "-" reads a schema from stdin.
+// Without any schema file, the operations are requested from the API. The generated files contain the version and
+// hash of the schema instead of a timestamp, generating the same schema therefore always results in the same code.
+//
+// With -check nothing is written, instead the program fails if the existing files differ from the generated code.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+// schema is the JSON schema of operations or stack options as returned by the API.
+type schema struct {
+	// Version identifies the schema, e.g. the date it has been retrieved. It's optional.
+	Version string
+	// Hash is the SHA-256 hash of the canonical JSON representation of the schema.
+	Hash string
+	Data []byte
+}
+
+// newSchema returns the schema of the JSON data. The data is converted into its canonical representation to make
+// the hash independent of formatting and the order of keys.
+func newSchema(data []byte, version string) (schema, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return schema{}, fmt.Errorf("invalid schema: %s", err)
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return schema{}, err
+	}
+	sum := sha256.Sum256(canonical)
+	return schema{Version: version, Hash: hex.EncodeToString(sum[:]), Data: canonical}, nil
+}
+
+// readSchema reads the schema from a file or from stdin if the name is "-".
+func readSchema(name string, stdin io.Reader, version string) (schema, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return schema{}, err
+	}
+	return newSchema(data, version)
+}
+
+// liveOperationsSchema requests the operations schema from the API.
+func liveOperationsSchema(cfg *rokka.Config, version string) (schema, error) {
+	res, err := rokka.NewClient(cfg).GetOperations()
+	if err != nil {
+		return schema{}, err
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return schema{}, err
+	}
+	return newSchema(data, version)
+}
+
+// output writes the generated code to the file, or compares it to the content of the file if check is true.
+func output(fileName string, src []byte, check bool) error {
+	if !check {
+		return ioutil.WriteFile(fileName, src, 0644)
+	}
+	existing, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(existing, src) {
+		return fmt.Errorf("%s is not up to date, run go generate", fileName)
+	}
+	return nil
+}
+
+func main() {
+	operationsSchema := flag.String("operations", "", "file containing the operations schema, - for stdin (default: request the schema from the API)")
+	operationsOutput := flag.String("operations-output", "operations_objects.go", "file to write the operations to")
+	stackOptionsSchema := flag.String("stackoptions", "", "file containing the stack options schema, - for stdin")
+	stackOptionsOutput := flag.String("stackoptions-output", "stackoptions_objects.go", "file to write the stack options to")
+	version := flag.String("version", "", "version of the schemas written to the generated files")
+	check := flag.Bool("check", false, "don't write anything, fail if the existing files differ from the generated code")
+	flag.Parse()
+
+	if *operationsSchema == "-" && *stackOptionsSchema == "-" {
+		log.Fatal("only one schema can be read from stdin")
+	}
+
+	var s schema
+	var err error
+	if *operationsSchema != "" {
+		s, err = readSchema(*operationsSchema, os.Stdin, *version)
+	} else {
+		s, err = liveOperationsSchema(&rokka.Config{}, *version)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generateOperations(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := output(*operationsOutput, src, *check); err != nil {
+		log.Fatal(err)
+	}
+
+	if *stackOptionsSchema == "" {
+		return
+	}
+	s, err = readSchema(*stackOptionsSchema, os.Stdin, *version)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err = generateStackOptions(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := output(*stackOptionsOutput, src, *check); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,15 +1,14 @@
-// This program generates rokka/operations_structs.go
 package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"go/format"
-	"log"
-	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
-	"time"
 
 	"github.com/rokka-io/rokka-go/cmd/rokka/cli"
 	"github.com/rokka-io/rokka-go/rokka"
@@ -18,6 +17,13 @@ import (
 type operationProperty struct {
 	Name string
 	Type string
+	// Values lists the allowed values of a string property.
+	Values []string
+	// Minimum and Maximum are the limits of a numeric property formatted as Go constants, empty if there's no limit.
+	Minimum string
+	Maximum string
+	// Pattern is a regular expression a string property needs to match.
+	Pattern string
 }
 
 type operationProperties []operationProperty
@@ -31,10 +37,44 @@ type operation struct {
 	Properties operationProperties
 	Required   []string
 	OneOf      []string
+	// Deprecated is set if the description of the operation starts with "Deprecated!". DeprecationNote is the rest of
+	// the description, e.g. what to use instead.
+	Deprecated      bool
+	DeprecationNote string
 }
 
 type operations []operation
 
+// HasChecks returns true if the value of the property is restricted.
+func (p operationProperty) HasChecks() bool {
+	return len(p.Values) > 0 || p.Pattern != "" || p.Minimum != "" || p.Maximum != ""
+}
+
+// HasChecks returns true if the operation has any option which needs to be validated.
+func (o operation) HasChecks() bool {
+	if len(o.Required) > 0 || len(o.OneOf) > 0 {
+		return true
+	}
+	for _, p := range o.Properties {
+		if p.HasChecks() {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPatterns returns true if any property of the operations needs to match a pattern.
+func (o operations) HasPatterns() bool {
+	for _, op := range o {
+		for _, p := range op.Properties {
+			if p.Pattern != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (o operations) Len() int           { return len(o) }
 func (o operations) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
 func (o operations) Less(i, j int) bool { return o[i].Name < o[j].Name }
@@ -46,24 +86,15 @@ var typeMap = map[string]string{
 	"number":  "float64",
 }
 
-func main() {
-	cfg := rokka.Config{}
-	generate(&cfg, "operations_objects.go")
-}
-
-func generate(cfg *rokka.Config, fileName string) {
-	c := rokka.NewClient(cfg)
-
-	res, err := c.GetOperations()
-	if err != nil {
-		log.Fatal(err)
-	}
+// deprecatedPrefix is the start of the description of deprecated operations.
+const deprecatedPrefix = "Deprecated!"
 
-	f, err := os.Create(fileName)
-	if err != nil {
-		log.Fatal(err)
+// generateOperations returns the source of operations_objects.go for the schema returned by GET /operations.
+func generateOperations(s schema) ([]byte, error) {
+	res := make(rokka.OperationsResponse)
+	if err := json.Unmarshal(s.Data, &res); err != nil {
+		return nil, err
 	}
-	defer f.Close()
 
 	ops := make(operations, 0)
 	for name, value := range res {
@@ -72,8 +103,23 @@ func generate(cfg *rokka.Config, fileName string) {
 			for propName, propValue := range propertiesMap {
 				propValueMap := propValue.(map[string]interface{})
 				p := operationProperty{
-					propName,
-					typeMap[propValueMap["type"].(string)],
+					Name: propName,
+					Type: typeMap[propValueMap["type"].(string)],
+				}
+				for _, key := range []string{"values", "enum"} {
+					if list, ok := propValueMap[key].([]interface{}); ok && p.Type == "string" {
+						p.Values = cli.ToStringSlice(list)
+					}
+				}
+				if v, ok := propValueMap["minimum"].(float64); ok {
+					p.Minimum = strconv.FormatFloat(v, 'f', -1, 64)
+				}
+				if v, ok := propValueMap["maximum"].(float64); ok {
+					p.Maximum = strconv.FormatFloat(v, 'f', -1, 64)
+				}
+				if v, ok := propValueMap["pattern"].(string); ok && p.Type == "string" {
+					// patterns of JSON schemas match anywhere in the value, the whole value needs to match though.
+					p.Pattern = "^(?:" + v + ")$"
 				}
 				properties = append(properties, p)
 			}
@@ -87,51 +133,71 @@ func generate(cfg *rokka.Config, fileName string) {
 			oneOf = cli.ToStringSlice(list.([]interface{}))
 		}
 		o := operation{
-			name,
-			properties,
-			required,
-			oneOf,
+			Name:       name,
+			Properties: properties,
+			Required:   required,
+			OneOf:      oneOf,
+		}
+		if desc, ok := value["description"].(string); ok && strings.HasPrefix(desc, deprecatedPrefix) {
+			o.Deprecated = true
+			o.DeprecationNote = strings.TrimSpace(strings.TrimPrefix(desc, deprecatedPrefix))
 		}
 		ops = append(ops, o)
 	}
 
 	sort.Sort(ops)
 
-	var b bytes.Buffer
-	err = packageTemplate.Execute(&b, struct {
-		Timestamp  time.Time
+	return execute(packageTemplate, struct {
+		Schema     schema
 		Operations operations
 	}{
-		Timestamp:  time.Now(),
+		Schema:     s,
 		Operations: ops,
 	})
-	if err != nil {
-		log.Fatal(err)
+}
+
+// execute executes the template and formats the resulting source.
+func execute(t *template.Template, data interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return nil, err
 	}
 	src, err := format.Source(b.Bytes())
 	if err != nil {
-		log.Printf("warning: internal error: invalid Go generated: %s", err)
-		log.Printf("warning: compile the package to analyze the error")
-		os.Exit(1)
+		return nil, fmt.Errorf("internal error: invalid Go generated: %s", err)
 	}
-	f.Write(src)
+	return src, nil
 }
 
 var funcMap = template.FuncMap{
 	"title":          strings.Title,
 	"titleCamelCase": cli.TitleCamelCase,
+	"float64Value":   float64Value,
+}
+
+// float64Value returns the expression to get the value of a property as float64.
+func float64Value(p operationProperty) string {
+	v := "*o." + cli.TitleCamelCase(p.Name)
+	if p.Type == "float64" {
+		return v
+	}
+	return "float64(" + v + ")"
 }
 
 var packageTemplate = template.Must(template.New("").Funcs(funcMap).Parse(`
 package rokka
 
 // Code generated by go generate; DO NOT EDIT.
-// This file was generated at {{ .Timestamp }}
+// This file was generated from the operations schema{{ if .Schema.Version }} version {{ .Schema.Version }}{{ end }} (sha256 {{ .Schema.Hash }}).
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	{{- if .Operations.HasPatterns }}
+	"regexp"
+	{{- end }}
 	"strings"
 )
 
@@ -139,8 +205,8 @@ import (
 type Operation interface {
 	// Name returns the operation's name known by the API.
 	Name() string
-	// Validate checks if required properties are set.
-	// Otherwise it returns false with an error indicating the missing property.
+	// Validate checks if required properties are set and the values of the options are allowed.
+	// Otherwise it returns false with an *OperationValidationError listing every invalid option.
 	Validate() (bool, error)
 	// toURLPath generates a part of the URL used for dynamic rendering of a stack.
 	toURLPath() string
@@ -155,37 +221,61 @@ type rawStack struct {
 // the correct operation types for JSON.
 type Operations []Operation
 
-// UnmarshalJSON implements json.Unmarshaler.
+// UnmarshalJSON implements json.Unmarshaler. Unknown operations as well as operations containing options which
+// can't be decoded are kept as *RawOperation. Options using legacy representations, e.g. numbers as string, are
+// converted to the type of their field.
 func (o *Operations) UnmarshalJSON(data []byte) error {
-	ops := make([]rawStack, 0)
-	if err := json.Unmarshal(data, &ops); err != nil {
+	ops, _, err := decodeOperations(data)
+	if err != nil {
 		return err
 	}
-	for _, v := range ops {
-		op, err := NewOperationByName(v.Name)
-		if err != nil {
-			return err
-		}
-		*o = append(*o, op.(Operation))
-		if err := json.Unmarshal(v.Options, op); err != nil {
-			// BUG(mweibel): We continue here when such an error is reached because rokka sometimes (legacy reasons)
-			//               has options on an operation which are not of the correct type. Should we write something to stdout? also not nice though..
-			continue
-		}
-	}
+	*o = append(*o, ops...)
 	return nil
 }
 
-// MarshalJSON implements json.Marshaler
+// MarshalJSON implements json.Marshaler. The result is canonical: every operation is an object containing
+// the name followed by the options, whose keys are sorted. Marshalling equal operations therefore always results
+// in identical bytes.
 func (o Operations) MarshalJSON() ([]byte, error) {
-	ops := make([]map[string]interface{}, len(o))
+	var b bytes.Buffer
+	b.WriteByte('[')
 	for i, v := range o {
-		ops[i] = make(map[string]interface{})
-		ops[i]["name"] = v.Name()
-		ops[i]["options"] = v
+		if v == nil {
+			return nil, fmt.Errorf("rokka: operation %d is nil", i)
+		}
+		name, err := json.Marshal(v.Name())
+		if err != nil {
+			return nil, err
+		}
+		options, err := canonicalOptions(v)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(` + "`" + `{"name":` + "`" + `)
+		b.Write(name)
+		b.WriteString(` + "`" + `,"options":` + "`" + `)
+		b.Write(options)
+		b.WriteByte('}')
 	}
+	b.WriteByte(']')
 
-	return json.Marshal(ops)
+	return b.Bytes(), nil
+}
+
+// canonicalOptions marshals the options of an operation with sorted keys. Numbers are kept as they are.
+func canonicalOptions(op Operation) ([]byte, error) {
+	raw, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return nil, err
+	}
+	return json.Marshal(options)
 }
 
 var errOperationNotImplemented = errors.New("Operation not implemented")
@@ -201,6 +291,16 @@ func NewOperationByName(name string) (Operation, error) {
 	return nil, errOperationNotImplemented
 }
 
+// DeprecatedOperations returns the operations deprecated by rokka by name with the note of the operations schema,
+// e.g. what to use instead.
+func DeprecatedOperations() map[string]string {
+	return map[string]string{
+		{{- range .Operations }}{{ if .Deprecated }}
+			"{{ .Name }}": {{ printf "%q" .DeprecationNote }},
+		{{- end }}{{ end }}
+	}
+}
+
 {{- range $i, $op := .Operations }}
 	// {{ title .Name }}Operation is an auto-generated Operation as specified by the rokka API.
 	{{- if (or .OneOf .Required) }}
@@ -217,25 +317,60 @@ func NewOperationByName(name string) (Operation, error) {
 	// Name implements rokka.Operation.Name
 	func (o {{ title .Name }}Operation) Name() string { return "{{ .Name }}" }
 
+	{{- $op := . }}
+	{{- range .Properties }}
+		{{- if .Values }}
+
+			// Values of {{ title $op.Name }}Operation.{{ titleCamelCase .Name }}.
+			const (
+			{{- $prop := . }}
+			{{- range .Values }}
+				{{ title $op.Name }}{{ titleCamelCase $prop.Name }}{{ titleCamelCase . }} = "{{ . }}"
+			{{- end }}
+			)
+
+			// {{ title $op.Name }}{{ titleCamelCase .Name }}Values lists the allowed values of {{ title $op.Name }}Operation.{{ titleCamelCase .Name }}.
+			var {{ title $op.Name }}{{ titleCamelCase .Name }}Values = []string{ {{- range $i, $v := .Values }}{{ if $i }}, {{ end }}{{ printf "%q" $v }}{{ end -}} }
+		{{- end }}
+		{{- if .Pattern }}
+
+			var {{ $op.Name }}{{ titleCamelCase .Name }}Pattern = regexp.MustCompile({{ printf "%q" .Pattern }})
+		{{- end }}
+	{{- end }}
+
 	// Validate implements rokka.Operation.Validate.
 	func (o {{ title .Name }}Operation) Validate() (bool, error) {
+		{{- if not .HasChecks }}
+			return true, nil
+		{{- else }}
+		v := newOperationValidator(o.Name())
 		{{- range .Required }}
-			if o.{{ titleCamelCase . }} == nil {
-				return false, errors.New("option \"{{ titleCamelCase . }}\" is required")
-			}
+			v.required("{{ titleCamelCase . }}", o.{{ titleCamelCase . }} != nil)
 		{{- end}}
 		{{- if .OneOf }}
-			valid := false
-			{{- range .OneOf }}
-				if o.{{ titleCamelCase . }} != nil {
-					valid = true
-				}
-			{{- end}}
-			if !valid {
-				return false, errors.New("one of \"{{ .OneOf }}\" is required")
-			}
+			v.oneOf([]string{ {{- range $i, $v := .OneOf }}{{ if $i }}, {{ end }}{{ printf "%q" $v }}{{ end -}} }
+			{{- range .OneOf }}, o.{{ titleCamelCase . }} != nil{{ end }})
 		{{- end}}
-		return true, nil
+		{{- range .Properties }}
+			{{- if .HasChecks }}
+				if o.{{ titleCamelCase .Name }} != nil {
+				{{- if .Values }}
+					v.enum("{{ titleCamelCase .Name }}", *o.{{ titleCamelCase .Name }}, {{ title $op.Name }}{{ titleCamelCase .Name }}Values)
+				{{- end }}
+				{{- if .Pattern }}
+					v.pattern("{{ titleCamelCase .Name }}", *o.{{ titleCamelCase .Name }}, {{ $op.Name }}{{ titleCamelCase .Name }}Pattern)
+				{{- end }}
+				{{- if .Minimum }}
+					v.minimum("{{ titleCamelCase .Name }}", {{ float64Value . }}, {{ .Minimum }})
+				{{- end }}
+				{{- if .Maximum }}
+					v.maximum("{{ titleCamelCase .Name }}", {{ float64Value . }}, {{ .Maximum }})
+				{{- end }}
+				}
+			{{- end }}
+		{{- end }}
+		return v.result()
+		{{- end }}
 	}
 
 	// toURLPath implements rokka.Operation.toURLPath.
@@ -251,5 +386,34 @@ func NewOperationByName(name string) (Operation, error) {
 		}
 		return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 	}
+
+	// {{ title .Name }}Builder builds {{ title .Name }}Operation values without the need of pointers, see {{ title .Name }}.
+	// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+	type {{ title .Name }}Builder struct {
+		op {{ title .Name }}Operation
+	}
+
+	// {{ title .Name }} returns a new {{ title .Name }}Builder to build {{ title .Name }}Operation values.
+	func {{ title .Name }}() {{ title .Name }}Builder { return {{ title .Name }}Builder{} }
+
+	{{- range .Properties }}
+
+		// {{ titleCamelCase .Name }} sets {{ title $op.Name }}Operation.{{ titleCamelCase .Name }}.
+		func (b {{ title $op.Name }}Builder) {{ titleCamelCase .Name }}(v {{ .Type }}) {{ title $op.Name }}Builder {
+			b.op.{{ titleCamelCase .Name }} = &v
+			return b
+		}
+	{{- end }}
+
+	// Build validates the operation and returns it.
+	func (b {{ title .Name }}Builder) Build() ({{ title .Name }}Operation, error) {
+		_, err := b.op.Validate()
+		return b.op, err
+	}
+
+	// BuildOperation implements rokka.OperationBuilder.
+	func (b {{ title .Name }}Builder) BuildOperation() (Operation, error) {
+		return b.Build()
+	}
 {{- end }}
 `))
@@ -2,61 +2,38 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"strings"
 	"testing"
-
-	"github.com/rokka-io/rokka-go/rokka"
-	"github.com/rokka-io/rokka-go/test"
 )
 
-func TestRunCodeGenerator(t *testing.T) {
-	r := test.NewResponse(http.StatusOK, "./testdata/GetOperations.json")
-	ts := test.NewMockAPI(t, test.Routes{"GET /operations": r})
-	defer ts.Close()
-
-	snapshotFileName := "./testdata/operations_object.go.snapshot"
-	_, updateSnapshot := os.LookupEnv("UPDATE_SNAPSHOT")
-
-	if _, err := os.Stat(snapshotFileName); os.IsNotExist(err) {
-		if !updateSnapshot {
-			t.Errorf("Snapshot file %s does not exist. Run `UPDATE_SNAPSHOT=1 go test ./... -run '^TestRunCodeGenerator$'` to create it.", snapshotFileName)
-			return
-		}
-		_, err := os.Create(snapshotFileName)
-		if err != nil {
-			panic(err)
+// assertSnapshot compares the generated code to the snapshot file line by line. With the environment variable
+// UPDATE_SNAPSHOT set, the snapshot is overwritten instead.
+func assertSnapshot(t *testing.T, snapshotFileName string, src []byte) {
+	if _, updateSnapshot := os.LookupEnv("UPDATE_SNAPSHOT"); updateSnapshot {
+		if err := ioutil.WriteFile(snapshotFileName, src, 0644); err != nil {
+			t.Fatal(err)
 		}
+		fmt.Println("Updated snapshot", snapshotFileName)
+		return
 	}
 
-	snapshot, err := os.Open(snapshotFileName)
-	if err != nil {
-		panic(err)
+	snapshot, err := ioutil.ReadFile(snapshotFileName)
+	if os.IsNotExist(err) {
+		t.Fatalf("Snapshot file %s does not exist. Run `UPDATE_SNAPSHOT=1 go test ./cmd/gen` to create it.", snapshotFileName)
 	}
-	defer snapshot.Close()
-
-	f, err := ioutil.TempFile(os.TempDir(), "operations_object.go")
 	if err != nil {
-		panic(err)
-	}
-	defer os.Remove(f.Name())
-
-	generate(&rokka.Config{APIAddress: ts.URL}, f.Name())
-
-	if updateSnapshot {
-		os.Rename(f.Name(), snapshotFileName)
-		fmt.Println("Updated snapshot")
-		return
+		t.Fatal(err)
 	}
 
-	snapshotScanner := bufio.NewScanner(snapshot)
-	fScanner := bufio.NewScanner(f)
+	snapshotScanner := bufio.NewScanner(bytes.NewReader(snapshot))
+	fScanner := bufio.NewScanner(bytes.NewReader(src))
 
 	success := true
-	line := 1
+	line := 0
 	for snapshotScanner.Scan() {
 		line++
 		snapshotLine := snapshotScanner.Text()
@@ -66,7 +43,7 @@ func TestRunCodeGenerator(t *testing.T) {
 			continue
 		}
 		fLine := fScanner.Text()
-		if snapshotLine != fLine && !strings.HasPrefix(snapshotLine, "// This file was generated at") {
+		if snapshotLine != fLine {
 			t.Logf("(%d) - %s", line, snapshotLine)
 			t.Logf("(%d) + %s", line, fLine)
 			success = false
@@ -80,6 +57,84 @@ func TestRunCodeGenerator(t *testing.T) {
 	}
 
 	if !success {
-		t.Errorf("Snapshot isn't equal to generated code. Please check the diff written. To update the snapshot, execute `UPDATE_SNAPSHOT=1 go test ./... -run '^TestRunCodeGenerator$'`.")
+		t.Errorf("Snapshot %s isn't equal to generated code. Please check the diff written. To update the snapshot, execute `UPDATE_SNAPSHOT=1 go test ./cmd/gen`.", snapshotFileName)
+	}
+}
+
+func TestRunCodeGenerator(t *testing.T) {
+	s, err := readSchema("./testdata/GetOperations.json", nil, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generateOperations(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSnapshot(t, "./testdata/operations_object.go.snapshot", src)
+}
+
+func TestGenerateOperations_Deprecated(t *testing.T) {
+	s, err := newSchema([]byte(`{
+		"noop": {"properties": {}, "description": "Deprecated! Use an empty stack operations collection"},
+		"grayscale": {"properties": {}, "description": ""}
+	}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generateOperations(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `return map[string]string{
+		"noop": "Use an empty stack operations collection",
+	}`
+	if !strings.Contains(string(src), expected) {
+		t.Errorf("Expected generated code to contain '%s', got:\n%s", expected, src)
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	a, err := newSchema([]byte(`{"resize": {"properties": {"width": {"type": "integer", "minimum": 1}}}}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := readSchema("-", bytes.NewBufferString(`{"resize":{"properties":{"width":{"minimum":1,"type":"integer"}}}}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash != b.Hash {
+		t.Errorf("Expected the hash to be independent of formatting, got %s and %s", a.Hash, b.Hash)
+	}
+
+	if _, err := newSchema([]byte(`{"resize":`), ""); err == nil {
+		t.Error("Expected invalid schema to return an error")
+	}
+}
+
+// TestGeneratedCodeIsUpToDate is the same as running `go generate ./rokka` with -check. The version needs to match
+// the one passed in rokka/operations.go.
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	s, err := readSchema("./schema/operations.json", nil, "reconstructed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generateOperations(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := output("../../rokka/operations_objects.go", src, true); err != nil {
+		t.Error(err)
+	}
+
+	s, err = readSchema("./schema/stackoptions.json", nil, "reconstructed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err = generateStackOptions(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := output("../../rokka/stackoptions_objects.go", src, true); err != nil {
+		t.Error(err)
 	}
 }
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/rokka-io/rokka-go/cmd/rokka/cli"
+)
+
+type stackOption struct {
+	// Name is the name of the option used by the API, e.g. `jpg.quality`.
+	Name        string
+	Type        string
+	Description string
+	// Values lists the allowed values of a string option.
+	Values []string
+	// Minimum and Maximum are the limits of a numeric option formatted as Go constants, empty if there's no limit.
+	Minimum string
+	Maximum string
+	// MinLength is the minimum length of a string option, empty if there's no limit.
+	MinLength string
+	// Default is the value used by rokka if the option isn't set formatted as Go constant, empty if unknown.
+	Default string
+}
+
+// HasChecks returns true if the value of the option is restricted.
+func (o stackOption) HasChecks() bool {
+	return len(o.Values) > 0 || o.Minimum != "" || o.Maximum != "" || o.MinLength != ""
+}
+
+// Float64Value returns the expression to get the value of a numeric option as float64.
+func (o stackOption) Float64Value() string {
+	if o.Type == "float64" {
+		return "*s." + o.FieldName()
+	}
+	return "float64(*s." + o.FieldName() + ")"
+}
+
+// FieldName returns the name of the field of the option, e.g. `JpgQuality` for `jpg.quality`.
+func (o stackOption) FieldName() string {
+	return stackOptionFieldName(o.Name)
+}
+
+var stackOptionNameSeparator = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+func stackOptionFieldName(name string) string {
+	parts := stackOptionNameSeparator.Split(name, -1)
+	for i, p := range parts {
+		parts[i] = cli.TitleCamelCase(p)
+	}
+	return strings.Join(parts, "")
+}
+
+type stackOptions []stackOption
+
+func (o stackOptions) Len() int           { return len(o) }
+func (o stackOptions) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o stackOptions) Less(i, j int) bool { return o[i].Name < o[j].Name }
+
+// schemaType returns the Go type of the JSON schema type, which is either a string or a list of types possibly
+// including null.
+func schemaType(t interface{}) string {
+	switch t := t.(type) {
+	case string:
+		return typeMap[t]
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return typeMap[s]
+			}
+		}
+	}
+	return ""
+}
+
+// goConstant formats a value of the schema as Go constant of the given type.
+func goConstant(v interface{}, typ string) string {
+	switch v := v.(type) {
+	case string:
+		if typ == "string" {
+			return strconv.Quote(v)
+		}
+	case bool:
+		if typ == "bool" {
+			return strconv.FormatBool(v)
+		}
+	case float64:
+		if typ == "int" && v == float64(int(v)) {
+			return strconv.Itoa(int(v))
+		}
+		if typ == "float64" {
+			s := strconv.FormatFloat(v, 'f', -1, 64)
+			if !strings.Contains(s, ".") {
+				s += ".0"
+			}
+			return s
+		}
+	}
+	return ""
+}
+
+// generateStackOptions returns the source of stackoptions_objects.go for the schema returned by GET /stackoptions.
+func generateStackOptions(s schema) ([]byte, error) {
+	res := struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}{}
+	if err := json.Unmarshal(s.Data, &res); err != nil {
+		return nil, err
+	}
+
+	options := make(stackOptions, 0, len(res.Properties))
+	for name, def := range res.Properties {
+		o := stackOption{
+			Name: name,
+			Type: schemaType(def["type"]),
+		}
+		if o.Type == "" {
+			return nil, fmt.Errorf("stack option %s: unsupported type %v", name, def["type"])
+		}
+		if v, ok := def["description"].(string); ok {
+			o.Description = v
+		}
+		for _, key := range []string{"values", "enum"} {
+			if list, ok := def[key].([]interface{}); ok && o.Type == "string" {
+				o.Values = cli.ToStringSlice(list)
+			}
+		}
+		if v, ok := def["minimum"].(float64); ok {
+			o.Minimum = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		if v, ok := def["maximum"].(float64); ok {
+			o.Maximum = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		if v, ok := def["minLength"].(float64); ok && o.Type == "string" {
+			o.MinLength = strconv.Itoa(int(v))
+		}
+		o.Default = goConstant(def["default"], o.Type)
+		options = append(options, o)
+	}
+	sort.Sort(options)
+
+	return execute(stackOptionsTemplate, struct {
+		Schema  schema
+		Options stackOptions
+	}{
+		Schema:  s,
+		Options: options,
+	})
+}
+
+var stackOptionsTemplate = template.Must(template.New("").Funcs(funcMap).Parse(`
+package rokka
+
+// Code generated by go generate; DO NOT EDIT.
+// This file was generated from the stack options schema{{ if .Schema.Version }} version {{ .Schema.Version }}{{ end }} (sha256 {{ .Schema.Hash }}).
+
+// Names of the stack options known by this version of the client.
+const (
+{{- range .Options }}
+	StackOption{{ .FieldName }} = "{{ .Name }}"
+{{- end }}
+)
+
+{{- range .Options }}
+	{{- $option := . }}
+	{{- if .Values }}
+
+		// Values of the stack option {{ .Name }}.
+		const (
+		{{- range .Values }}
+			StackOption{{ $option.FieldName }}{{ titleCamelCase . }} = "{{ . }}"
+		{{- end }}
+		)
+
+		// StackOption{{ .FieldName }}Values lists the allowed values of the stack option {{ .Name }}.
+		var StackOption{{ .FieldName }}Values = []string{ {{- range $i, $v := .Values }}{{ if $i }}, {{ end }}{{ printf "%q" $v }}{{ end -}} }
+	{{- end }}
+{{- end }}
+
+// StackOptionsSpec contains the stack options known by this version of the client as typed fields.
+// Options which aren't set are nil, rokka uses the default value for them.
+//
+// See: https://rokka.io/documentation/references/stacks.html#stack-options
+type StackOptionsSpec struct {
+{{- range .Options }}
+	// {{ .FieldName }} is the stack option {{ .Name }}.
+	{{- if .Description }} {{ .Description }}{{ end }}
+	{{- if .Default }} Defaults to {{ .Default }}.{{ end }}
+	{{ .FieldName }} *{{ .Type }} ` + "`" + `json:"{{ .Name }},omitempty"` + "`" + `
+{{- end }}
+
+	// Other contains the options unknown to this version of the client and the ones with values which couldn't be
+	// converted to the type of their field.
+	Other StackOptions ` + "`" + `json:"-"` + "`" + `
+}
+
+// NewStackOptionsSpec returns the typed form of the stack options. Numbers and booleans passed as string are
+// converted to the type of their field. Options which can't be converted are reported in the error and kept in
+// Other, just like unknown options. Converting the result back using StackOptions therefore doesn't lose anything.
+func NewStackOptionsSpec(options StackOptions) (StackOptionsSpec, error) {
+	s := StackOptionsSpec{}
+	c := newStackOptionsConverter()
+	for k, v := range options {
+		switch k {
+		{{- range .Options }}
+			case StackOption{{ .FieldName }}:
+				s.{{ .FieldName }} = c.to{{ title .Type }}(k, v)
+		{{- end }}
+		default:
+			c.keep(k, v)
+		}
+	}
+	s.Other = c.other
+	return s, c.err()
+}
+
+// StackOptions returns the options which are set in the map form used by stacks, including Other.
+func (s StackOptionsSpec) StackOptions() StackOptions {
+	o := make(StackOptions, len(s.Other))
+	for k, v := range s.Other {
+		o[k] = v
+	}
+	{{- range .Options }}
+		if s.{{ .FieldName }} != nil {
+			o[StackOption{{ .FieldName }}] = *s.{{ .FieldName }}
+		}
+	{{- end }}
+	return o
+}
+
+// Validate checks the values of the options against the allowed values and limits of the schema and returns a
+// *StackOptionsValidationError listing every invalid option. Other isn't checked.
+func (s StackOptionsSpec) Validate() error {
+	v := newOperationValidator("")
+	{{- range .Options }}
+		{{- if .HasChecks }}
+			if s.{{ .FieldName }} != nil {
+			{{- if .Values }}
+				v.enum(StackOption{{ .FieldName }}, *s.{{ .FieldName }}, StackOption{{ .FieldName }}Values)
+			{{- end }}
+			{{- if .MinLength }}
+				v.minLength(StackOption{{ .FieldName }}, *s.{{ .FieldName }}, {{ .MinLength }})
+			{{- end }}
+			{{- if .Minimum }}
+				v.minimum(StackOption{{ .FieldName }}, {{ .Float64Value }}, {{ .Minimum }})
+			{{- end }}
+			{{- if .Maximum }}
+				v.maximum(StackOption{{ .FieldName }}, {{ .Float64Value }}, {{ .Maximum }})
+			{{- end }}
+			}
+		{{- end }}
+	{{- end }}
+	return stackOptionsValidationResult(v)
+}
+`))
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestGenerateStackOptions(t *testing.T) {
+	s, err := readSchema("./testdata/GetStackOptions.json", nil, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generateStackOptions(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSnapshot(t, "./testdata/stackoptions_objects.go.snapshot", src)
+}
+
+func TestStackOptionFieldName(t *testing.T) {
+	tests := map[string]string{
+		"dpr":                         "Dpr",
+		"jpg.quality":                 "JpgQuality",
+		"png.compression_level":       "PngCompressionLevel",
+		"jpg.transparency.autoformat": "JpgTransparencyAutoformat",
+	}
+	for name, expected := range tests {
+		if f := stackOptionFieldName(name); f != expected {
+			t.Errorf("Expected field name of %s to be %s, got %s", name, expected, f)
+		}
+	}
+}
@@ -12,25 +12,15 @@ type SourceImagesReader struct {
 
 // Read uses the search API to paginate through all images.
 func (sir *SourceImagesReader) Read(client *rokka.Client, images chan string, bar *pb.ProgressBar) error {
-	cursor := ""
-	for {
-		opt := rokka.ListSourceImagesOptions{
-			Offset: cursor,
-		}
-		res, err := client.ListSourceImages(sir.Organization, opt)
-		if err != nil {
-			return err
-		}
-		bar.Total = int64(res.Total)
+	it := client.IterateSourceImages(sir.Organization, rokka.ListSourceImagesOptions{})
+	it.Prefetch = true
+	defer it.Close()
 
-		for _, element := range res.Items {
-			images <- element.Hash
-		}
-		if res.Cursor == "" || cursor == res.Cursor || len(res.Items) == 0 {
-			return nil
-		}
-		cursor = res.Cursor
+	for it.Next() {
+		bar.Total = int64(it.Total())
+		images <- it.SourceImage().Hash
 	}
+	return it.Err()
 }
 
 // Count fetches one image in order to get the Total amount of images available.
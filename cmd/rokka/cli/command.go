@@ -48,16 +48,7 @@ func run(fn func(c *rokka.Client, args []string) (interface{}, error), tpl strin
 
 		res, err := fn(rokkaClient, args)
 		if err != nil {
-			switch err := err.(type) {
-			case *rokka.AnnotatedUnmarshalTypeError:
-				logErrorAndExit(fmt.Errorf("%s\n\nRelated JSON:\n----------\n%s\n----------", err, err.Content))
-			case rokka.StatusCodeError:
-				if err.APIError != nil {
-					logErrorAndExit(fmt.Errorf("%s (Status code %d)", err.APIError.Error.Message, err.Code))
-				}
-				logErrorAndExit(err)
-			}
-			logErrorAndExit(err)
+			logErrorAndExit(describeError(err))
 		}
 
 		t, err := template.New("").Funcs(funcMap).Parse(tmpl)
@@ -74,6 +65,79 @@ func run(fn func(c *rokka.Client, args []string) (interface{}, error), tpl strin
 	}
 }
 
+// runEach returns a func to execute the given func which emits the results one by one. Each emitted value is
+// formatted with the given template and written out immediately, which allows to stream long lists.
+// The optional header is written once before the first value unless a custom or raw template is used.
+func runEach(fn func(c *rokka.Client, args []string, emit func(interface{}) error) error, header, tpl string) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		tmpl := tpl
+		if len(responseTemplate) != 0 {
+			tmpl = responseTemplate
+			header = ""
+		} else if raw {
+			tmpl = rawTemplate
+			header = ""
+		}
+
+		t, err := template.New("").Funcs(funcMap).Parse(tmpl)
+		if err != nil {
+			logErrorAndExit(fmt.Errorf("error parsing response template: %s", err))
+		}
+
+		w := tabwriter.NewWriter(logger.StdOut, 0, 0, 2, ' ', 0)
+		fmt.Fprint(w, header)
+
+		err = fn(rokkaClient, args, func(v interface{}) error {
+			if err := t.Execute(w, v); err != nil {
+				return fmt.Errorf("cli: Error formatting response: %s", err)
+			}
+			return w.Flush()
+		})
+		w.Flush()
+		if err != nil {
+			logErrorAndExit(describeError(err))
+		}
+	}
+}
+
+// errorHints contains a hint for the user for each kind of error returned by the rokka client.
+var errorHints = []struct {
+	kind error
+	hint string
+}{
+	{rokka.ErrUnauthorized, "The API key is missing or invalid. Use `rokka login` to store an API key or pass it using --apiKey."},
+	{rokka.ErrForbidden, "The API key is not allowed to access this resource. Check the organization name and the roles of the membership."},
+	{rokka.ErrNotFound, "The resource does not exist. Check the spelling of the organization, stack name or hash."},
+	{rokka.ErrConflict, "The resource already exists or has been modified concurrently. Some commands support --overwrite."},
+	{rokka.ErrValidation, "The request has been rejected as invalid. Use --verbose to inspect the request sent to rokka."},
+	{rokka.ErrRateLimited, "Too many requests have been sent. Wait a moment before trying again or lower the --concurrency."},
+	{rokka.ErrServer, "rokka could not process the request. Please try again later."},
+}
+
+// describeError converts errors returned by the rokka client into a more readable error including a hint if available.
+func describeError(err error) error {
+	var unmarshalErr *rokka.AnnotatedUnmarshalTypeError
+	if errors.As(err, &unmarshalErr) {
+		return fmt.Errorf("%s\n\nRelated JSON:\n----------\n%s\n----------", unmarshalErr, unmarshalErr.Content)
+	}
+
+	msg := err.Error()
+	var sErr rokka.StatusCodeError
+	if errors.As(err, &sErr) && sErr.APIError != nil {
+		msg = fmt.Sprintf("%s (Status code %d)", sErr.APIError.Error.Message, sErr.Code)
+		if _, ok := err.(rokka.ErrMaxRetriesReached); ok {
+			msg = "max retries reached. Last error: " + msg
+		}
+	}
+
+	for _, h := range errorHints {
+		if errors.Is(err, h.kind) {
+			return fmt.Errorf("%s\n\nHint: %s", msg, h.hint)
+		}
+	}
+	return errors.New(msg)
+}
+
 // previewURL generates a preview URL for a source image.
 //
 // Will panic if `rokkaClient` is nil.
@@ -100,3 +100,28 @@ func TestRun_ErrorOutput(t *testing.T) {
 	cFn := run(fn, "{{.}}")
 	cFn(&cmd, []string{})
 }
+
+func TestDescribeError(t *testing.T) {
+	notFound := rokka.StatusCodeError{Code: 404, APIError: &rokka.APIError{}}
+	notFound.APIError.Error.Code = 404
+	notFound.APIError.Error.Message = "Stack not found"
+
+	table := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"plain error", errors.New("some error"), "some error"},
+		{"status code error", notFound, "Stack not found (Status code 404)\n\nHint: The resource does not exist. Check the spelling of the organization, stack name or hash."},
+		{"max retries reached", rokka.ErrMaxRetriesReached{LastError: rokka.StatusCodeError{Code: 503}}, "rokka: max retries reached. Last error: rokka: Status Code 503\n\nHint: rokka could not process the request. Please try again later."},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			actual := describeError(v.err).Error()
+			if actual != v.expected {
+				t.Errorf("Expected '%s', got: '%s'", v.expected, actual)
+			}
+		})
+	}
+}
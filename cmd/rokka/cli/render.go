@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/local"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderOperations string
+	renderOutput     string
+	renderLocal      bool
+	renderSourceDir  string
+	renderStacksDir  string
+)
+
+// renderResult contains the metadata of a rendered image written to a file.
+type renderResult struct {
+	rokka.RenderResponse
+	Name         string
+	BytesWritten int64
+}
+
+// renderToStdout returns whether the rendered image is written to stdout instead of a file.
+func renderToStdout() bool {
+	return renderOutput == "" || renderOutput == "-"
+}
+
+func renderImage(c *rokka.Client, args []string) (interface{}, error) {
+	ops, err := rokka.ParseOperations(renderOperations)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := "dynamic"
+	if len(args) > 3 {
+		stack = args[3]
+	}
+
+	// the file is created exclusively, so an existing file is never overwritten. It's removed again if rendering
+	// fails.
+	var w io.Writer = logger.StdOut
+	discard := func() {}
+	if !renderToStdout() {
+		file, err := os.OpenFile(renderOutput, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			return nil, errExists
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		w = file
+		discard = func() {
+			file.Close()
+			os.Remove(renderOutput)
+		}
+	}
+
+	var res rokka.RenderResponse
+	if renderLocal {
+		res, err = renderImageLocally(c, args[0], args[1], args[2], stack, ops)
+	} else {
+		res, err = c.Render(args[0], args[1], args[2], stack, ops)
+	}
+	if err != nil {
+		discard()
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	n, err := io.Copy(w, res.Body)
+	if err != nil {
+		discard()
+		return nil, err
+	}
+	return renderResult{RenderResponse: res, Name: renderOutput, BytesWritten: n}, nil
+}
+
+// localStack returns the operations and options of a stack, either read from the stack files in --stacks-dir or
+// requested from the API.
+func localStack(c *rokka.Client, org, name string) ([]rokka.Operation, rokka.StackOptions, error) {
+	if renderStacksDir == "" {
+		stack, err := findStack(c, org, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return stack.StackOperations, stack.StackOptions, nil
+	}
+	stacks, err := readStackDir(renderStacksDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, ok := stacks[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("stack %s not found in %s", name, renderStacksDir)
+	}
+	return req.Operations, req.Options, nil
+}
+
+// renderImageLocally renders the source image of --source-dir using the local renderer. Operations which can't be
+// emulated are logged as warnings.
+func renderImageLocally(c *rokka.Client, org, hash, format, stack string, ops []rokka.Operation) (rokka.RenderResponse, error) {
+	res := rokka.RenderResponse{ContentType: local.ContentType(format)}
+	if res.ContentType == "" {
+		return res, fmt.Errorf("format %s can't be rendered locally, use jpg, png or gif", format)
+	}
+
+	operations := make([]rokka.Operation, 0)
+	options := make(rokka.StackOptions)
+	if stack != "dynamic" {
+		stackOps, stackOptions, err := localStack(c, org, stack)
+		if err != nil {
+			return res, err
+		}
+		operations = append(operations, stackOps...)
+		options = stackOptions
+	}
+	operations = append(operations, ops...)
+
+	img, err := local.OpenSourceImage(renderSourceDir, hash)
+	if err != nil {
+		return res, err
+	}
+	rendered, err := local.Render(img, operations, local.OptionsFromStack(options))
+	if err != nil {
+		return res, err
+	}
+	for _, issue := range rendered.Issues {
+		logger.Errorf("Warning: %s\n", issue)
+	}
+
+	var buf bytes.Buffer
+	if err := local.Encode(&buf, rendered.Image, format, options); err != nil {
+		return res, err
+	}
+	res.Body = ioutil.NopCloser(&buf)
+	res.ContentLength = int64(buf.Len())
+	res.Width = rendered.Image.Bounds().Dx()
+	res.Height = rendered.Image.Bounds().Dy()
+	return res, nil
+}
+
+const renderTemplate = `Success writing {{.BytesWritten}} bytes to {{.Name}}.
+{{if .URL}}URL:	{{.URL}}
+{{end}}Content type:	{{.ContentType}}
+{{if .Width}}Dimensions:	{{.Width}}x{{.Height}}
+{{end}}{{if .CacheStatus}}Cache status:	{{.CacheStatus}}
+{{end}}`
+
+var renderCmd = &cobra.Command{
+	Use:   "render [org] [hash] [format] [stack]",
+	Short: "Render an image using a stack and dynamic operations",
+	Long: `Render requests the image rendered by the stack, or the dynamic stack if no stack is given.
+The operations are added on top of the stack in the same form as used in render URLs.
+
+The image is written to stdout unless a file is passed using --output.
+
+With --local, the image is rendered without rokka using the source images in --source-dir, named by their hash.
+Only resize, crop, rotate, grayscale, sepia, blur, alpha and noop are emulated, other operations are skipped with a
+warning. Stacks are read from the files in --stacks-dir as written by "stacks export" or requested from the API.`,
+	Example: `  # render a thumbnail into a file
+  rokka render test-organization c1b110 jpg --operations resize-width-200--grayscale --output thumbnail.jpg
+
+  # pipe an image rendered by a stack to another command
+  rokka render test-organization c1b110 png product | display
+
+  # render an image of the current directory offline
+  rokka render test-organization c1b110 png --local --operations resize-width-200 --output thumbnail.png`,
+	Args:                  cobra.RangeArgs(3, 4),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !renderToStdout() {
+			run(renderImage, renderTemplate)(cmd, args)
+			return
+		}
+		// the image itself is the output, there's nothing to format.
+		if _, err := renderImage(rokkaClient, args); err != nil {
+			logErrorAndExit(describeError(err))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+
+	renderCmd.Flags().StringVar(&renderOperations, "operations", "", "Operations added on top of the stack, e.g. resize-width-200--grayscale")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "File to write the image to (default stdout)")
+	renderCmd.Flags().BoolVar(&renderLocal, "local", false, "Render the image locally instead of using rokka")
+	renderCmd.Flags().StringVar(&renderSourceDir, "source-dir", ".", "Directory containing the source images named by their hash, used with --local")
+	renderCmd.Flags().StringVar(&renderStacksDir, "stacks-dir", "", "Directory containing stack files, used with --local")
+}
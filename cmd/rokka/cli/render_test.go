@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestRenderImage(t *testing.T) {
+	fixture := "../../../rokka/fixtures/image.png"
+	expected, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		panic(err)
+	}
+
+	r := test.NewResponse(http.StatusOK, fixture)
+	r.Headers["Content-Type"] = "image/png"
+	ts := test.NewMockAPI(t, test.Routes{
+		"GET /test-org/dynamic/resize-width-200--grayscale/c1b110.png": r,
+		"GET /test-org/product/noop/c1b110.png":                        r,
+	})
+	defer ts.Close()
+	c := rokka.NewClient(&rokka.Config{ImageHost: ts.URL + "/{{organization}}"})
+
+	dir, err := ioutil.TempDir(os.TempDir(), "render")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	renderOperations = "resize-width-200--grayscale"
+	renderOutput = filepath.Join(dir, "image.png")
+	defer func() {
+		renderOperations = ""
+		renderOutput = ""
+	}()
+
+	res, err := renderImage(c, []string{"test-org", "c1b110", "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.(renderResult).BytesWritten != int64(len(expected)) || res.(renderResult).ContentType != "image/png" {
+		t.Errorf("Unexpected result '%+v'", res)
+	}
+	written, err := ioutil.ReadFile(renderOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, expected) {
+		t.Error("Expected the file to contain the rendered image")
+	}
+
+	if _, err := renderImage(c, []string{"test-org", "c1b110", "png"}); err != errExists {
+		t.Errorf("Expected error '%v', got '%v'", errExists, err)
+	}
+
+	// the route doesn't exist, the file created for the output is removed again.
+	renderOutput = filepath.Join(dir, "failed.png")
+	if _, err := renderImage(c, []string{"test-org", "c1b110", "jpg"}); err == nil {
+		t.Error("Expected rendering to fail")
+	}
+	if _, err := os.Stat(renderOutput); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be written if rendering failed, got '%v'", err)
+	}
+
+	stdOut, err := ioutil.TempFile(dir, "stdout")
+	if err != nil {
+		panic(err)
+	}
+	logger = newCLILog(false)
+	logger.StdOut = stdOut
+
+	renderOperations = ""
+	renderOutput = ""
+	if _, err := renderImage(c, []string{"test-org", "c1b110", "png", "product"}); err != nil {
+		t.Fatal(err)
+	}
+	stdOut.Seek(0, 0)
+	written, err = ioutil.ReadAll(stdOut)
+	if err != nil {
+		panic(err)
+	}
+	if !bytes.Equal(written, expected) {
+		t.Error("Expected stdout to contain the rendered image")
+	}
+}
+
+func TestRenderImage_Local(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "render")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := ioutil.ReadFile("../../../rokka/fixtures/image.png")
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "c1b110.png"), src, 0644); err != nil {
+		panic(err)
+	}
+	stack := "operations:\n- name: resize\n  options:\n    width: 50\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "product.yaml"), []byte(stack), 0644); err != nil {
+		panic(err)
+	}
+
+	stdErr, err := ioutil.TempFile(dir, "stderr")
+	if err != nil {
+		panic(err)
+	}
+	logger = newCLILog(false)
+	logger.StdErr = stdErr
+
+	renderLocal = true
+	renderSourceDir = dir
+	renderStacksDir = dir
+	renderOperations = "crop-width-20-height-10--trim"
+	renderOutput = filepath.Join(dir, "image.png")
+	defer func() {
+		renderLocal = false
+		renderSourceDir = "."
+		renderStacksDir = ""
+		renderOperations = ""
+		renderOutput = ""
+	}()
+
+	res, err := renderImage(rokka.NewClient(&rokka.Config{}), []string{"test-org", "c1b110", "png", "product"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := res.(renderResult)
+	if r.ContentType != "image/png" || r.Width != 20 || r.Height != 10 || r.BytesWritten == 0 {
+		t.Errorf("Unexpected result '%+v'", r)
+	}
+
+	stdErr.Seek(0, 0)
+	warnings, err := ioutil.ReadAll(stdErr)
+	if err != nil {
+		panic(err)
+	}
+	if expected := "Warning: operation 2 (trim): not supported by the local renderer, skipped\n"; string(warnings) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, warnings)
+	}
+
+	os.Remove(renderOutput)
+	if _, err := renderImage(rokka.NewClient(&rokka.Config{}), []string{"test-org", "c1b110", "webp"}); err == nil {
+		t.Error("Expected an error for a format which can't be rendered locally")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"time"
 
 	"github.com/rokka-io/rokka-go/rokka"
 	"github.com/spf13/cobra"
@@ -23,6 +24,8 @@ var (
 	responseTemplate string
 	configFile       string
 	imageHost        string
+	maxRetries       int
+	retryBudget      time.Duration
 
 	logger      *cliLog
 	rokkaClient *rokka.Client
@@ -41,10 +44,11 @@ var rootCmd = &cobra.Command{
 		hc := newHTTPClient(logger)
 
 		rokkaClient = rokka.NewClient(&rokka.Config{
-			APIKey:     apiKey,
-			APIAddress: apiAddress,
-			HTTPClient: hc,
-			ImageHost:  imageHost,
+			APIKey:             apiKey,
+			APIAddress:         apiAddress,
+			HTTPClient:         hc,
+			RetryingHTTPClient: rokka.NewRetryingHTTPClientWithPolicy(hc, newRetryPolicy(logger)),
+			ImageHost:          imageHost,
 		}).AutoRetry()
 	},
 }
@@ -77,6 +81,28 @@ func init() {
 	flags.BoolVarP(&verbose, "verbose", "v", false, "Enable verbose mode")
 	flags.StringVar(&responseTemplate, "template", "", "Template to be applied to the response (See: https://golang.org/pkg/text/template/)")
 	flags.StringVar(&imageHost, "imageHost", defaultImageHost, "Image host used for preview URLs")
+	flags.IntVar(&maxRetries, "max-retries", rokka.DefaultRetryPolicy().MaxRetries, "Maximum number of attempts for failing requests")
+	flags.DurationVar(&retryBudget, "retry-budget", 0, "Maximum time spent on a request including retries, e.g. 30s (0 means no limit)")
+}
+
+// newRetryPolicy creates the retry policy based on the flags. In verbose mode every retry is logged.
+func newRetryPolicy(log *cliLog) rokka.RetryPolicy {
+	p := rokka.DefaultRetryPolicy()
+	p.MaxRetries = maxRetries
+	p.Budget = retryBudget
+	p.OnRetry = func(info rokka.RetryInfo) {
+		if !log.Verbose {
+			return
+		}
+		var reason string
+		if info.Err != nil {
+			reason = info.Err.Error()
+		} else {
+			reason = info.Response.Status
+		}
+		log.Errorf("Attempt %d of %s %s failed (%s), retrying in %s\n", info.Attempt, info.Request.Method, info.Request.URL, reason, info.Delay)
+	}
+	return p
 }
 
 func getPath() (string, error) {
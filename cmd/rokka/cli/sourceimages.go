@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/rokka-io/rokka-go/rokka"
 	"github.com/spf13/cobra"
@@ -17,12 +18,103 @@ var (
 	dynamicMetadataOptions  rokka.DynamicMetadataOptions
 	userMetadataName        string
 	binaryHash              bool
+	listAllSourceImages     bool
+
+	// sourceImagesSearchFlags contains the search expressions of the list command keyed by the search field.
+	sourceImagesSearchFlags = make(map[string]*string)
+	sourceImagesSearchUser  []string
+	sourceImagesSearchSort  string
 )
 
+// sourceImagesSearchFields are the fields which can be searched using flags of the list command. The key is the flag name.
+var sourceImagesSearchFields = []struct {
+	flag  string
+	field string
+	usage string
+}{
+	{"hash", "hash", "Hash, * acts as wildcard"},
+	{"binaryHash", "binaryhash", "Binary hash, * acts as wildcard"},
+	{"size", "size", "Size in bytes, either exact or a range (e.g. 1000..5000, 1000.., ..5000)"},
+	{"format", "format", "Format"},
+	{"width", "width", "Width, either exact or a range (e.g. 100..500, 100.., ..500)"},
+	{"height", "height", "Height, either exact or a range (e.g. 100..500, 100.., ..500)"},
+	{"created", "created", "Range of creation dates (e.g. 2018-01-01..2018-02-01, 2018-01-01T10:00:00Z..)"},
+}
+
 var errExists = errors.New("file already exists")
 
+// sourceImagesSearchQuery builds the search query out of the flags of the list command.
+func sourceImagesSearchQuery() (*rokka.SearchQuery, error) {
+	q := rokka.NewSearchQuery()
+	for _, f := range sourceImagesSearchFields {
+		expr := *sourceImagesSearchFlags[f.flag]
+		if expr == "" {
+			continue
+		}
+		v, err := rokka.ParseSearchValue(f.field, expr)
+		if err != nil {
+			return nil, err
+		}
+		q.Filter(f.field, v)
+	}
+
+	for _, u := range sourceImagesSearchUser {
+		parts := strings.SplitN(u, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid user metadata filter '%s', expected name=expression", u)
+		}
+		field := "user:" + parts[0]
+		v, err := rokka.ParseSearchValue(field, parts[1])
+		if err != nil {
+			return nil, err
+		}
+		q.Filter(field, v)
+	}
+
+	if sourceImagesSearchSort != "" {
+		sorts, err := rokka.ParseSearchSort(sourceImagesSearchSort)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sorts {
+			q.Sort(s.Field, s.Direction)
+		}
+	}
+	return q, q.Validate()
+}
+
 func listSourceImages(c *rokka.Client, args []string) (interface{}, error) {
-	return c.ListSourceImages(args[0], sourceImagesListOptions)
+	q, err := sourceImagesSearchQuery()
+	if err != nil {
+		return nil, err
+	}
+	options := sourceImagesListOptions
+	options.Query = q
+
+	return c.ListSourceImages(args[0], options)
+}
+
+func listAllSourceImagesEach(c *rokka.Client, args []string, emit func(interface{}) error) error {
+	q, err := sourceImagesSearchQuery()
+	if err != nil {
+		return err
+	}
+	options := sourceImagesListOptions
+	options.Query = q
+	if options.Offset == "0" {
+		options.Offset = ""
+	}
+
+	it := c.IterateSourceImages(args[0], options)
+	it.Prefetch = true
+	defer it.Close()
+
+	for it.Next() {
+		if err := emit(it.SourceImage()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
 }
 
 func getSourceImage(c *rokka.Client, args []string) (interface{}, error) {
@@ -141,9 +233,19 @@ var sourceImagesListCmd = &cobra.Command{
 	Args:                  cobra.ExactArgs(1),
 	Aliases:               []string{"l"},
 	DisableFlagsInUseLine: true,
-	Run:                   run(listSourceImages, "Name\tHash\tDetails\tPreview URL\n{{range .Items}}{{.Name}}\t{{.Hash}}\t{{.MimeType}}, {{.Width}}x{{.Height}}\t{{previewurl .Organization .Hash .Format}}\n{{end}}\nTotal: {{.Total}}\n"),
+	Long: `List/Search source images.
+In case the --all flag is specified, every page is requested and the source images are written out as soon as they are received.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if listAllSourceImages {
+			runEach(listAllSourceImagesEach, "Name\tHash\tDetails\tPreview URL\n", sourceImagesListItemTemplate)(cmd, args)
+			return
+		}
+		run(listSourceImages, "Name\tHash\tDetails\tPreview URL\n{{range .Items}}"+sourceImagesListItemTemplate+"{{end}}\nTotal: {{.Total}}\n")(cmd, args)
+	},
 }
 
+const sourceImagesListItemTemplate = "{{.Name}}\t{{.Hash}}\t{{.MimeType}}, {{.Width}}x{{.Height}}\t{{previewurl .Organization .Hash .Format}}\n"
+
 const sourceImageTemplate = "Hash:\t{{.Hash}} ({{.ShortHash}})\nName:\t{{.Name}}\nDetails:\t{{.MimeType}}, {{.Width}}x{{.Height}}, {{.Size}}Bytes\nCreated at:\t{{datetime .Created}}\nBinary hash:\t{{.BinaryHash}}\nPreview URL:\t{{previewurl .Organization .Hash .Format}}{{if .UserMetadata}}\nUser metadata:{{range $key, $value := .UserMetadata}}\n  {{$key}}:\t{{$value}}{{end}}{{end}}{{if .DynamicMetadata}}\nDynamic metadata:{{range $key, $value := .DynamicMetadata}}\n  {{$key}}:\t{{$value}}{{end}}{{end}}\n"
 
 var sourceImagesGetCmd = &cobra.Command{
@@ -283,14 +385,12 @@ func init() {
 	silcFlags := sourceImagesListCmd.Flags()
 	silcFlags.IntVarP(&sourceImagesListOptions.Limit, "limit", "l", 20, "Limit")
 	silcFlags.StringVarP(&sourceImagesListOptions.Offset, "offset", "o", "0", "Offset")
-	silcFlags.StringVar(&sourceImagesListOptions.Hash, "hash", "", "Hash")
-	silcFlags.StringVar(&sourceImagesListOptions.BinaryHash, "binaryHash", "", "Binary hash")
-	silcFlags.StringVar(&sourceImagesListOptions.Size, "size", "", "Size in kilobytes")
-	silcFlags.StringVar(&sourceImagesListOptions.Format, "format", "", "Format")
-	silcFlags.StringVar(&sourceImagesListOptions.Width, "width", "", "Width")
-	silcFlags.StringVar(&sourceImagesListOptions.Height, "height", "", "Height")
-	silcFlags.StringVar(&sourceImagesListOptions.Created, "created", "", "Created")
-	silcFlags.StringVar(&sourceImagesListOptions.Sort, "sort", "", "Sort")
+	for _, f := range sourceImagesSearchFields {
+		sourceImagesSearchFlags[f.flag] = silcFlags.String(f.flag, "", f.usage)
+	}
+	silcFlags.StringArrayVar(&sourceImagesSearchUser, "user", nil, "User metadata filter as name=expression, prefix the name with the type for numbers and dates (e.g. int:likes=10..)")
+	silcFlags.StringVar(&sourceImagesSearchSort, "sort", "", "Comma separated fields to sort by, each optionally followed by asc or desc (e.g. \"created desc,width\")")
+	silcFlags.BoolVar(&listAllSourceImages, "all", false, "Stream all pages instead of only the first one")
 
 	sourceImagesDeleteCmd.Flags().BoolVar(&binaryHash, "binaryHash", false, "Supplied hash is a binary hash")
 
@@ -94,6 +94,14 @@ func shouldRetry(r *bufio.Reader) bool {
 	return true
 }
 
+func confirm(r *bufio.Reader) bool {
+	skip, c, err := readString(r)
+	if err != nil || skip {
+		return false
+	}
+	return c == "y"
+}
+
 func processOptionInput(r *bufio.Reader, props map[string]interface{}, required []string, propName string, s reflect.Value) error {
 	fmt.Println()
 	options := props[propName].(map[string]interface{})
@@ -168,7 +176,10 @@ func setOperationField(s reflect.Value, fieldName, val string) error {
 	case "*string":
 		f.Set(reflect.ValueOf(&val))
 	case "*bool":
-		vBool := val == "true"
+		vBool, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
 		f.Set(reflect.ValueOf(&vBool))
 	case "*float64":
 		vFloat, err := strconv.ParseFloat(val, 64)
@@ -231,6 +242,121 @@ func cliCreateStack(c *rokka.Client, name, org string, req *rokka.CreateStackReq
 		}
 	}
 
+	fmt.Print("Add stack options? [y|n] ")
+	if !confirm(reader) {
+		return nil
+	}
+	definitions, err := c.GetStackOptions()
+	if err != nil {
+		return err
+	}
+	options, err := processStackOptionsInput(reader, definitions)
+	if err != nil {
+		return err
+	}
+	if len(options) > 0 {
+		req.Options = options
+	}
+
+	return nil
+}
+
+// processStackOptionsInput requests a value for every stack option. Options without input aren't set.
+func processStackOptionsInput(r *bufio.Reader, definitions rokka.StackOptionsResponse) (rokka.StackOptions, error) {
+	names := make([]string, 0, len(definitions.Properties))
+	for name := range definitions.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := make(rokka.StackOptions)
+	for _, name := range names {
+		if err := processStackOptionInput(r, definitions, name, options); err != nil {
+			return nil, err
+		}
+	}
+	return options, nil
+}
+
+// processStackOptionInput requests the value of a stack option and validates it the same way as
+// rokka.StackOptionsSpec.Validate does.
+func processStackOptionInput(r *bufio.Reader, definitions rokka.StackOptionsResponse, name string, options rokka.StackOptions) error {
+	def := definitions.Properties[name]
+	fmt.Println()
+	fmt.Printf("%s (%v)\n", name, def.Type)
+	if len(def.Values) > 0 {
+		fmt.Printf("  values: %s\n", strings.Join(def.Values, ", "))
+	}
+	if def.Default != nil {
+		fmt.Printf("  default: %v\n", def.Default)
+	}
+	if def.Minimum != nil {
+		fmt.Printf("  minimum: %d\n", *def.Minimum)
+	}
+	if def.Maximum != nil {
+		fmt.Printf("  maximum: %d\n", *def.Maximum)
+	}
+	if def.MinLength != nil {
+		fmt.Printf("  minLength: %d\n", *def.MinLength)
+	}
+	fmt.Print("Enter value: ")
+
+	skip, c, err := readString(r)
+	if err != nil {
+		return err
+	}
+	if skip {
+		return nil
+	}
+
+	if err := validateStackOptionInput(definitions, name, c, options); err != nil {
+		fmt.Printf("Input not valid: %s. Retry? [y|n]", err)
+		if !shouldRetry(r) {
+			return nil
+		}
+		return processStackOptionInput(r, definitions, name, options)
+	}
+	return nil
+}
+
+// validateStackOptionInput parses the input according to the type of the option and adds it to the options if
+// it's valid.
+func validateStackOptionInput(definitions rokka.StackOptionsResponse, name, val string, options rokka.StackOptions) error {
+	var v interface{} = val
+	switch definitions.Properties[name].Type {
+	case "integer":
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return err
+		}
+		v = n
+	case "number":
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		v = n
+	case "boolean":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v = b
+	}
+
+	option := rokka.StackOptions{name: v}
+	spec, err := rokka.NewStackOptionsSpec(option)
+	if err != nil {
+		return err
+	}
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	// options unknown to this version of the client are validated using the definitions.
+	if err := definitions.Validate(option); err != nil {
+		return err
+	}
+	options[name] = v
 	return nil
 }
 
@@ -259,8 +385,21 @@ func createStack(c *rokka.Client, args []string) (interface{}, error) {
 	return c.CreateStack(org, name, req, createStackOverwrite)
 }
 
+// logDecodeWarnings prints the options of stacks which couldn't be decoded. The affected operations are kept as they
+// are, so they aren't changed when the stack is saved again.
+func logDecodeWarnings(warnings []rokka.DecodeWarning) {
+	for _, w := range warnings {
+		logger.Errorf("Warning: %s\n", w)
+	}
+}
+
 func listStacks(c *rokka.Client, args []string) (interface{}, error) {
-	return c.ListStacks(args[0])
+	res, err := c.ListStacks(args[0])
+	if err != nil {
+		return nil, err
+	}
+	logDecodeWarnings(res.Warnings)
+	return res, nil
 }
 
 func deleteStack(c *rokka.Client, args []string) (interface{}, error) {
@@ -298,7 +437,8 @@ var stacksCreateCmd = &cobra.Command{
 	Use:   "create [org] [name]",
 	Short: "Create or update a stack for an organization",
 	Long: `A stack can be created by either passing the JSON data of a new stack in a pipe to this command, or by simply executing the create function.
-If the create function is executed without a pipe a manual mode allows to select which operations and their options should be added.`,
+If the create function is executed without a pipe a manual mode allows to select which operations and their options should be added.
+Afterwards stack options can be added, their values are validated against the stack options known by rokka.`,
 	Example: `  # create a stack in manual mode
   rokka stacks create test-organization test-stack
 
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/expression"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stacksExplainDPR       float64
+	stacksExplainWidth     int
+	stacksExplainHeight    int
+	stacksExplainFormat    string
+	stacksExplainVariables []string
+)
+
+// stackExplainExpression is the outcome of a single expression of a stack.
+type stackExplainExpression struct {
+	Expression string
+	Applies    bool
+	// Error is set if the expression is invalid.
+	Error     string `json:",omitempty"`
+	Options   map[string]interface{}
+	Variables map[string]interface{}
+}
+
+// stackExplainResult lists which expressions of a stack apply to a simulated request.
+type stackExplainResult struct {
+	Stack       string
+	Request     expression.Request
+	Expressions []stackExplainExpression
+	// Options and Variables are the ones resulting from applying the overrides.
+	Options   rokka.StackOptions
+	Variables rokka.StackVariables
+}
+
+// findStack returns the stack with the given name.
+func findStack(c *rokka.Client, org, name string) (rokka.Stack, error) {
+	stacks, err := c.ListStacks(org)
+	if err != nil {
+		return rokka.Stack{}, err
+	}
+	logDecodeWarnings(stacks.Warnings)
+	for _, s := range stacks.Items {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return rokka.Stack{}, fmt.Errorf("stack %s not found in organization %s", name, org)
+}
+
+// parseVariableFlags parses variables in the form name=value. Numbers and booleans are converted.
+func parseVariableFlags(flags []string) (rokka.StackVariables, error) {
+	variables := make(rokka.StackVariables)
+	for _, f := range flags {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid variable '%s', expected name=value", f)
+		}
+		var v interface{} = parts[1]
+		if n, err := strconv.ParseFloat(parts[1], 64); err == nil {
+			v = n
+		} else if parts[1] == "true" || parts[1] == "false" {
+			v = parts[1] == "true"
+		}
+		variables[parts[0]] = v
+	}
+	return variables, nil
+}
+
+func explainStack(c *rokka.Client, args []string) (interface{}, error) {
+	stack, err := findStack(c, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	variables, err := parseVariableFlags(stacksExplainVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	req := expression.Request{
+		DPR:         stacksExplainDPR,
+		FinalWidth:  stacksExplainWidth,
+		FinalHeight: stacksExplainHeight,
+		Format:      stacksExplainFormat,
+		Options:     stack.StackOptions,
+		Variables:   variables,
+	}
+	explanation := expression.Explain(stack.StackExpressions, req)
+
+	res := stackExplainResult{
+		Stack:       stack.Name,
+		Request:     req,
+		Expressions: make([]stackExplainExpression, 0, len(explanation.Results)),
+		Options:     explanation.Options,
+		Variables:   explanation.Variables,
+	}
+	for _, r := range explanation.Results {
+		e := stackExplainExpression{Expression: r.Expression.Expression, Applies: r.Applies}
+		if r.Err != nil {
+			e.Error = r.Err.Error()
+		}
+		e.Options, _ = r.Expression.Overrides["options"].(map[string]interface{})
+		e.Variables, _ = r.Expression.Overrides["variables"].(map[string]interface{})
+		res.Expressions = append(res.Expressions, e)
+	}
+	return res, nil
+}
+
+const stacksExplainTemplate = `Stack {{.Stack}} with dpr {{.Request.DPR}}, final size {{.Request.FinalWidth}}x{{.Request.FinalHeight}}{{if .Request.Format}}, format {{.Request.Format}}{{end}}:
+{{range .Expressions}}{{if .Error}}!{{else if .Applies}}+{{else}}-{{end}} {{.Expression}}
+{{if .Error}}    {{.Error}}
+{{else if .Applies}}{{range $k, $v := .Options}}    options.{{$k}} = {{$v}}
+{{end}}{{range $k, $v := .Variables}}    ${{$k}} = {{$v}}
+{{end}}{{end}}{{else}}  The stack doesn't have any expressions.
+{{end}}
+Resulting options:
+{{range $k, $v := .Options}}  {{$k}}	{{$v}}
+{{else}}  none
+{{end}}`
+
+var stacksExplainCmd = &cobra.Command{
+	Use:   "explain [org] [stack]",
+	Short: "Show which expressions of a stack apply to a request",
+	Long: `Explain evaluates the expressions of a stack for a simulated request and shows which overrides apply.
+Expressions with syntax errors or which can't be evaluated are marked with "!", the ones which apply with "+".
+
+The request is described using flags, variables are available as $name in expressions.`,
+	Example: `  # show which expressions apply to a retina display
+  rokka stacks explain test-organization product --dpr 2
+
+  # simulate a webp request with a variable
+  rokka stacks explain test-organization product --format webp --width 800 --height 600 --variable text=hello`,
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run:                   run(explainStack, stacksExplainTemplate),
+}
+
+func init() {
+	stacksCmd.AddCommand(stacksExplainCmd)
+
+	stacksExplainCmd.Flags().Float64Var(&stacksExplainDPR, "dpr", 1, "Device pixel ratio of the request")
+	stacksExplainCmd.Flags().IntVar(&stacksExplainWidth, "width", 0, "Final width of the rendered image ($finalWidth)")
+	stacksExplainCmd.Flags().IntVar(&stacksExplainHeight, "height", 0, "Final height of the rendered image ($finalHeight)")
+	stacksExplainCmd.Flags().StringVar(&stacksExplainFormat, "format", "", "Requested format, e.g. jpg (request.format)")
+	stacksExplainCmd.Flags().StringArrayVar(&stacksExplainVariables, "variable", nil, "Variable in the form name=value, can be passed multiple times")
+}
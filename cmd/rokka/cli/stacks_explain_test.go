@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/test"
+	"github.com/spf13/cobra"
+)
+
+func TestExplainStack(t *testing.T) {
+	org := "test-org"
+	ts := test.NewMockAPI(t, test.Routes{"GET /stacks/" + org: test.NewResponse(http.StatusOK, "../../../rokka/fixtures/ListStacksWithExpressions.json")})
+	defer ts.Close()
+
+	stdOut, err := ioutil.TempFile(os.TempDir(), "stdout")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(stdOut.Name())
+
+	logger = newCLILog(false)
+	logger.StdOut = stdOut
+	rokkaClient = rokka.NewClient(&rokka.Config{APIAddress: ts.URL})
+
+	stacksExplainDPR = 2
+	stacksExplainFormat = "jpg"
+	defer func() {
+		stacksExplainDPR = 1
+		stacksExplainFormat = ""
+	}()
+	run(explainStack, stacksExplainTemplate)(&cobra.Command{}, []string{org, "product"})
+
+	stdOut.Seek(0, 0)
+	out, err := ioutil.ReadAll(stdOut)
+	if err != nil {
+		panic(err)
+	}
+
+	expected := `Stack product with dpr 2, final size 0x0, format jpg:
++ options.dpr >= 2
+    options.jpg.quality = 60
+- request.format == 'webp'
+! $finalWidth >
+    expression: syntax error at column 14: unexpected end of expression
+
+Resulting options:
+  jpg.quality  60
+`
+	if string(out) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, out)
+	}
+
+	if _, err := explainStack(rokkaClient, []string{org, "missing"}); err == nil {
+		t.Error("Expected an error for a missing stack")
+	}
+}
+
+func TestParseVariableFlags(t *testing.T) {
+	v, err := parseVariableFlags([]string{"text=a=b", "size=2", "flag=true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["text"] != "a=b" || v["size"] != float64(2) || v["flag"] != true {
+		t.Errorf("Unexpected variables %v", v)
+	}
+	if _, err := parseVariableFlags([]string{"text"}); err == nil {
+		t.Error("Expected an error for a variable without value")
+	}
+}
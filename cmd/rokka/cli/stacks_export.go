@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// stacksExportFormat is the file format used to export stacks, either yaml or json.
+var stacksExportFormat string
+
+// stacksExportResult lists the files written by the export.
+type stacksExportResult struct {
+	Organization string
+	Files        []string
+}
+
+// marshalStackFile encodes a stack definition in a canonical form: keys are sorted, and only the definition itself
+// without generated metadata like the creation date is contained.
+func marshalStackFile(req rokka.CreateStackRequest, format string) ([]byte, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "json":
+		var out bytes.Buffer
+		enc := json.NewEncoder(&out)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	case "yaml":
+		return yaml.Marshal(jsonNumbersToYAML(v))
+	}
+	return nil, fmt.Errorf("unknown format %s, expected yaml or json", format)
+}
+
+// jsonNumbersToYAML converts json.Number values to ints or floats, otherwise they would be written as strings.
+func jsonNumbersToYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbersToYAML(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbersToYAML(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+func exportStacks(c *rokka.Client, args []string) (interface{}, error) {
+	org := args[0]
+	dir := args[1]
+
+	ext := "." + stacksExportFormat
+	if stacksExportFormat != "yaml" && stacksExportFormat != "json" {
+		return nil, fmt.Errorf("unknown format %s, expected yaml or json", stacksExportFormat)
+	}
+
+	stacks, err := c.ListStacks(org)
+	if err != nil {
+		return nil, err
+	}
+	logDecodeWarnings(stacks.Warnings)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	res := stacksExportResult{Organization: org, Files: make([]string, 0, len(stacks.Items))}
+	for _, s := range stacks.Items {
+		b, err := marshalStackFile(s.CreateStackRequest(), stacksExportFormat)
+		if err != nil {
+			return nil, fmt.Errorf("stack %s: %s", s.Name, err)
+		}
+		fileName := filepath.Join(dir, s.Name+ext)
+		if err := ioutil.WriteFile(fileName, b, 0644); err != nil {
+			return nil, err
+		}
+		res.Files = append(res.Files, fileName)
+	}
+	return res, nil
+}
+
+var stacksExportCmd = &cobra.Command{
+	Use:   "export [org] [dir]",
+	Short: "Export all stacks of an organization to files",
+	Long: `Export writes every stack of the organization to a separate file in the directory, named after the stack.
+The files contain the stack definition in the same form as read by "stacks sync" and "stacks create". Generated metadata
+like the creation date is omitted and keys are sorted, which makes the files suitable to be committed and diffed.`,
+	Example: `  # export all stacks as YAML
+  rokka stacks export test-organization ./stacks
+
+  # export all stacks as JSON
+  rokka stacks export test-organization ./stacks --format json`,
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run:                   run(exportStacks, "{{range .Files}}{{.}}\n{{end}}Exported {{len .Files}} stacks of organization {{.Organization}}.\n"),
+}
+
+func init() {
+	stacksCmd.AddCommand(stacksExportCmd)
+
+	stacksExportCmd.Flags().StringVar(&stacksExportFormat, "format", "yaml", "File format, either yaml or json")
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestExportStacks(t *testing.T) {
+	org := "test-org"
+	r := test.NewResponse(http.StatusOK, "../../../rokka/fixtures/ListStacks.json")
+	ts := test.NewMockAPI(t, test.Routes{"GET /stacks/" + org: r})
+	defer ts.Close()
+	c := rokka.NewClient(&rokka.Config{APIAddress: ts.URL})
+
+	stacks, err := c.ListStacks(org)
+	if err != nil {
+		panic(err)
+	}
+
+	table := []struct {
+		format   string
+		expected string
+	}{
+		{"yaml", "operations:\n- name: resize\n  options:\n    height: 100\n    width: 100\noptions:\n  autoformat: false\n"},
+		{"json", "{\n  \"operations\": [\n    {\n      \"name\": \"resize\",\n      \"options\": {\n        \"height\": 100,\n        \"width\": 100\n      }\n    }\n  ],\n  \"options\": {\n    \"autoformat\": false\n  }\n}\n"},
+	}
+
+	format := stacksExportFormat
+	defer func() {
+		stacksExportFormat = format
+	}()
+	for _, v := range table {
+		dir, err := ioutil.TempDir(os.TempDir(), "export")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(dir)
+
+		stacksExportFormat = v.format
+		if _, err := exportStacks(c, []string{org, dir}); err != nil {
+			t.Fatal(err)
+		}
+		first, err := ioutil.ReadFile(filepath.Join(dir, "test2."+v.format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(first) != v.expected {
+			t.Errorf("Expected %s export to be '%s', got '%s'", v.format, v.expected, first)
+		}
+
+		if _, err := exportStacks(c, []string{org, dir}); err != nil {
+			t.Fatal(err)
+		}
+		second, err := ioutil.ReadFile(filepath.Join(dir, "test2."+v.format))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(first) != string(second) {
+			t.Errorf("Expected exporting twice to result in identical %s files, got '%s' and '%s'", v.format, first, second)
+		}
+
+		// the exported files need to be in sync with the stacks they've been exported from.
+		desired, err := readStackDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := planStacksSync(stacks.Items, desired, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.HasDrift() {
+			t.Errorf("Expected no drift after exporting %s, got %v", v.format, res.Items)
+		}
+	}
+}
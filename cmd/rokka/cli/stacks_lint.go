@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/lint"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stacksLintSuppress   []string
+	stacksLintDeprecated []string
+	stacksLintFailOn     string
+	stacksLintFailed     bool
+)
+
+// stacksLintResult lists the findings of all stacks of an organization.
+type stacksLintResult struct {
+	Organization string
+	Findings     []lint.Finding
+}
+
+// parseLintFlags returns the linter config of the flags. Suppressed rules are either given as rule, which applies to
+// all stacks, or as stack:rule. Deprecated operations are given as name or name=replacement.
+func parseLintFlags(suppress, deprecated []string) lint.Config {
+	cfg := lint.Config{Suppress: make(map[string][]string), DeprecatedOperations: make(map[string]string)}
+	for _, s := range suppress {
+		stack, rule := "*", s
+		if parts := strings.SplitN(s, ":", 2); len(parts) == 2 {
+			stack, rule = parts[0], parts[1]
+		}
+		cfg.Suppress[stack] = append(cfg.Suppress[stack], rule)
+	}
+	for _, d := range deprecated {
+		parts := strings.SplitN(d, "=", 2)
+		if len(parts) == 2 {
+			cfg.DeprecatedOperations[parts[0]] = parts[1]
+		} else {
+			cfg.DeprecatedOperations[parts[0]] = ""
+		}
+	}
+	return cfg
+}
+
+func lintStacks(c *rokka.Client, args []string) (interface{}, error) {
+	failOn, err := lint.ParseSeverity(stacksLintFailOn)
+	if err != nil {
+		return nil, err
+	}
+	stacks, err := c.ListStacks(args[0])
+	if err != nil {
+		return nil, err
+	}
+	logDecodeWarnings(stacks.Warnings)
+
+	findings := lint.Lint(stacks.Items, parseLintFlags(stacksLintSuppress, stacksLintDeprecated))
+	if max := lint.MaxSeverity(findings); max != "" && max.AtLeast(failOn) {
+		stacksLintFailed = true
+	}
+	return stacksLintResult{Organization: args[0], Findings: findings}, nil
+}
+
+const stacksLintTemplate = `{{range .Findings}}{{.Stack}}	{{.Severity}}	{{.Rule}}	{{.Location}}: {{.Message}} ({{.Suggestion}})
+{{else}}No problems found in the stacks of organization {{.Organization}}.
+{{end}}`
+
+var stacksLintCmd = &cobra.Command{
+	Use:   "lint [org]",
+	Short: "Find problems in the stacks of an organization",
+	Long: fmt.Sprintf(`Lint checks the stacks of an organization for problems rokka accepts but which likely aren't intended.
+Rules can be suppressed for all stacks or a single stack using --suppress rule or --suppress stack:rule.
+Use --raw to get the findings as JSON.
+
+The operations deprecated by rokka (%s) are always reported, more can be added using --deprecated.
+
+The command exits with status code 2 if any finding is at least as severe as --fail-on.
+
+Rules:
+%s`, deprecatedOperationsHelp(), lintRulesHelp()),
+	Example: `  # check the stacks of an organization
+  rokka stacks lint test-organization
+
+  # fail on warnings in CI, ignoring a rule for a single stack
+  rokka stacks lint test-organization --fail-on warning --suppress product:redundant-resize --raw`,
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		run(lintStacks, stacksLintTemplate)(cmd, args)
+		if stacksLintFailed {
+			os.Exit(2)
+		}
+	},
+}
+
+// lintRulesHelp lists the rules with their severity and description.
+func lintRulesHelp() string {
+	lines := make([]string, 0)
+	for _, r := range lint.Rules() {
+		lines = append(lines, fmt.Sprintf("  %s (%s): %s", r.ID, r.Severity, r.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deprecatedOperationsHelp lists the operations deprecated by rokka.
+func deprecatedOperationsHelp() string {
+	names := make([]string, 0)
+	for name := range rokka.DeprecatedOperations() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	stacksCmd.AddCommand(stacksLintCmd)
+
+	stacksLintCmd.Flags().StringArrayVar(&stacksLintSuppress, "suppress", nil, "Rule to ignore, either for all stacks (rule) or a single stack (stack:rule)")
+	stacksLintCmd.Flags().StringArrayVar(&stacksLintDeprecated, "deprecated", nil, "Operation to report as deprecated in addition to the ones deprecated by rokka, optionally with a replacement (name=replacement)")
+	stacksLintCmd.Flags().StringVar(&stacksLintFailOn, "fail-on", "error", "Minimum severity of findings to exit with status code 2: info, warning or error")
+}
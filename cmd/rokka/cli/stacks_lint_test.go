@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/lint"
+	"github.com/rokka-io/rokka-go/test"
+	"github.com/spf13/cobra"
+)
+
+func TestLintStacks(t *testing.T) {
+	org := "test-org"
+	ts := test.NewMockAPI(t, test.Routes{"GET /stacks/" + org: test.NewResponse(http.StatusOK, "../../../rokka/fixtures/ListStacksForLint.json")})
+	defer ts.Close()
+
+	stdOut, err := ioutil.TempFile(os.TempDir(), "stdout")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(stdOut.Name())
+
+	logger = newCLILog(false)
+	logger.StdOut = stdOut
+	rokkaClient = rokka.NewClient(&rokka.Config{APIAddress: ts.URL})
+
+	stacksLintFailOn = "error"
+	defer func() {
+		stacksLintFailOn = "error"
+		stacksLintSuppress = nil
+		stacksLintFailed = false
+	}()
+	run(lintStacks, stacksLintTemplate)(&cobra.Command{}, []string{org})
+
+	stdOut.Seek(0, 0)
+	out, err := ioutil.ReadAll(stdOut)
+	if err != nil {
+		panic(err)
+	}
+
+	expected := `banner     error    composition-without-secondary  stack_operations[0]: composition has neither secondary_image nor secondary_color (set secondary_image to the hash of an image or secondary_color)
+thumbnail  warning  redundant-resize               stack_operations[0]: the result is resized again by operation 1 (remove the first resize or merge both into one)
+`
+	if string(out) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, out)
+	}
+	if !stacksLintFailed {
+		t.Error("Expected the lint to fail because of an error")
+	}
+
+	stacksLintFailed = false
+	stacksLintSuppress = []string{"banner:composition-without-secondary"}
+	res, err := lintStacks(rokkaClient, []string{org})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := res.(stacksLintResult).Findings; len(findings) != 1 || findings[0].Stack != "thumbnail" {
+		t.Errorf("Expected a single finding, got %v", findings)
+	}
+	if stacksLintFailed {
+		t.Error("Expected the lint not to fail for warnings")
+	}
+
+	stacksLintFailOn = "fatal"
+	if _, err := lintStacks(rokkaClient, []string{org}); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+}
+
+func TestParseLintFlags(t *testing.T) {
+	cfg := parseLintFlags([]string{"redundant-resize", "product:invalid-expression"}, []string{"primitive=blur", "glitch"})
+	expected := lint.Config{
+		Suppress:             map[string][]string{"*": {"redundant-resize"}, "product": {"invalid-expression"}},
+		DeprecatedOperations: map[string]string{"primitive": "blur", "glitch": ""},
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Expected %v, got %v", expected, cfg)
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/geometry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stacksSimulateWidth  int
+	stacksSimulateHeight int
+	stacksSimulateDPR    float64
+)
+
+// stackSimulateResult is the geometry of a stack rendered for a source image.
+type stackSimulateResult struct {
+	Stack string
+	DPR   float64
+	geometry.Result
+}
+
+func simulateStack(c *rokka.Client, args []string) (interface{}, error) {
+	stack, err := findStack(c, args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	source := geometry.Size{Width: stacksSimulateWidth, Height: stacksSimulateHeight}
+	if len(args) > 2 {
+		img, err := c.GetSourceImage(args[0], args[2])
+		if err != nil {
+			return nil, err
+		}
+		source = geometry.FromSourceImage(img)
+	} else if source.Width <= 0 || source.Height <= 0 {
+		return nil, errors.New("either a hash or the flags --width and --height are required")
+	}
+
+	options := geometry.OptionsFromStack(stack.StackOptions)
+	if stacksSimulateDPR > 0 {
+		options.DPR = stacksSimulateDPR
+	}
+	res, err := geometry.Simulate(source, stack.StackOperations, options)
+	if err != nil {
+		return nil, err
+	}
+	return stackSimulateResult{Stack: stack.Name, DPR: options.DPR, Result: res}, nil
+}
+
+const stacksSimulateTemplate = `Stack {{.Stack}} with dpr {{.DPR}}:
+Source	{{.Source}}
+{{range .Steps}}{{.Index}} {{.Operation}}	{{.Input}} -> {{.Output}}{{if .Offset}} at {{.Offset.X}},{{.Offset.Y}}{{end}}{{if .Note}}	({{if .Approximate}}approximate, {{end}}{{.Note}}){{end}}
+{{end}}Result	{{.Size}}{{if .Approximate}} (approximate){{end}}
+`
+
+var stacksSimulateCmd = &cobra.Command{
+	Use:   "simulate [org] [stack] [hash]",
+	Short: "Show the dimensions of the image a stack renders",
+	Long: `Simulate calculates the dimensions of the image after each operation of a stack without rendering it.
+The size of the source image is either taken from the image with the given hash or from the flags --width and --height.
+
+Steps which depend on the content of the image, e.g. trim, are marked as approximate.`,
+	Example: `  # show the geometry of a stack for a source image
+  rokka stacks simulate test-organization product c421f4e8cefe0fd3aab22832f51e85bacda0a47a
+
+  # show the geometry for a retina display without a source image
+  rokka stacks simulate test-organization product --width 1024 --height 768 --dpr 2`,
+	Args:                  cobra.RangeArgs(2, 3),
+	DisableFlagsInUseLine: true,
+	Run:                   run(simulateStack, stacksSimulateTemplate),
+}
+
+func init() {
+	stacksCmd.AddCommand(stacksSimulateCmd)
+
+	stacksSimulateCmd.Flags().IntVar(&stacksSimulateWidth, "width", 0, "Width of the source image if no hash is given")
+	stacksSimulateCmd.Flags().IntVar(&stacksSimulateHeight, "height", 0, "Height of the source image if no hash is given")
+	stacksSimulateCmd.Flags().Float64Var(&stacksSimulateDPR, "dpr", 0, "Device pixel ratio, overrides the dpr option of the stack")
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/test"
+	"github.com/spf13/cobra"
+)
+
+func TestSimulateStack(t *testing.T) {
+	org := "test-org"
+	hash := "8bbff49a384a4682fd05144ffe77a84f29f112ff"
+	ts := test.NewMockAPI(t, test.Routes{
+		"GET /stacks/" + org:                    test.NewResponse(http.StatusOK, "../../../rokka/fixtures/ListStacks.json"),
+		"GET /sourceimages/" + org + "/" + hash: test.NewResponse(http.StatusOK, "../../../rokka/fixtures/GetSourceImage.json"),
+	})
+	defer ts.Close()
+
+	stdOut, err := ioutil.TempFile(os.TempDir(), "stdout")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(stdOut.Name())
+
+	logger = newCLILog(false)
+	logger.StdOut = stdOut
+	rokkaClient = rokka.NewClient(&rokka.Config{APIAddress: ts.URL})
+
+	run(simulateStack, stacksSimulateTemplate)(&cobra.Command{}, []string{org, "test2", hash})
+
+	stdOut.Seek(0, 0)
+	out, err := ioutil.ReadAll(stdOut)
+	if err != nil {
+		panic(err)
+	}
+
+	expected := `Stack test2 with dpr 1:
+Source    1024x768
+0 resize  1024x768 -> 100x75
+Result    100x75
+`
+	if string(out) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, out)
+	}
+
+	stacksSimulateDPR = 2
+	defer func() { stacksSimulateDPR = 0 }()
+	res, err := simulateStack(rokkaClient, []string{org, "test2", hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := res.(stackSimulateResult).Size; s.Width != 200 || s.Height != 150 {
+		t.Errorf("Expected 200x150 with dpr 2, got %s", s)
+	}
+
+	if _, err := simulateStack(rokkaClient, []string{org, "test2"}); err == nil {
+		t.Error("Expected an error without hash and size")
+	}
+}
@@ -0,0 +1,312 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	stacksSyncDryRun bool
+	stacksSyncDelete bool
+
+	// stacksSyncDrift is set by syncStacks in case the stacks of the organization don't match the files.
+	stacksSyncDrift bool
+)
+
+// stackFileExtensions are the extensions of the files containing stack definitions.
+var stackFileExtensions = []string{".json", ".yaml", ".yml"}
+
+// stackSyncAction is what needs to be done with a stack to match its definition.
+type stackSyncAction string
+
+const (
+	stackSyncCreate stackSyncAction = "create"
+	stackSyncUpdate stackSyncAction = "update"
+	stackSyncDelete stackSyncAction = "delete"
+	stackSyncKeep   stackSyncAction = "unchanged"
+)
+
+// stackSyncItem contains the planned action for a single stack.
+type stackSyncItem struct {
+	Name    string
+	Action  stackSyncAction
+	Changes []rokka.StackChange
+}
+
+// stacksSyncResult is the outcome of syncing stacks.
+type stacksSyncResult struct {
+	Organization string
+	DryRun       bool
+	Items        []stackSyncItem
+	// Unmanaged contains stacks which exist in the organization but not in the directory and are kept.
+	Unmanaged []string
+}
+
+// readStackFile reads a stack definition in the form of rokka.CreateStackRequest from a JSON or YAML file.
+func readStackFile(path string) (rokka.CreateStackRequest, error) {
+	req := rokka.CreateStackRequest{}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return req, err
+	}
+
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		// Operations only know how to unmarshal JSON, therefore YAML is converted to JSON first.
+		var v interface{}
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			return req, fmt.Errorf("%s: %s", path, err)
+		}
+		if b, err = json.Marshal(yamlToJSONValue(v)); err != nil {
+			return req, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	if err := json.Unmarshal(b, &req); err != nil {
+		return req, fmt.Errorf("%s: %s", path, err)
+	}
+	return req, nil
+}
+
+// yamlToJSONValue converts the maps decoded by the YAML package to maps which can be encoded to JSON.
+func yamlToJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprintf("%v", k)] = yamlToJSONValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = yamlToJSONValue(e)
+		}
+	}
+	return v
+}
+
+// readStackDir reads all stack definitions of a directory. The name of a stack is the file name without the extension.
+func readStackDir(dir string) (map[string]rokka.CreateStackRequest, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := make(map[string]rokka.CreateStackRequest)
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || !ListContains(stackFileExtensions, ext) {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), ext)
+		if _, ok := stacks[name]; ok {
+			return nil, fmt.Errorf("stack %s is defined in multiple files", name)
+		}
+
+		req, err := readStackFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		stacks[name] = req
+	}
+	return stacks, nil
+}
+
+// planStacksSync compares the stacks of an organization with the desired ones and returns what needs to be done.
+func planStacksSync(current []rokka.Stack, desired map[string]rokka.CreateStackRequest, deleteStacks bool) (stacksSyncResult, error) {
+	res := stacksSyncResult{Items: make([]stackSyncItem, 0), Unmanaged: make([]string, 0)}
+
+	existing := make(map[string]rokka.CreateStackRequest)
+	for _, s := range current {
+		existing[s.Name] = s.CreateStackRequest()
+	}
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cur, ok := existing[name]
+		if !ok {
+			changes, err := rokka.DiffStack(rokka.CreateStackRequest{}, desired[name])
+			if err != nil {
+				return res, err
+			}
+			res.Items = append(res.Items, stackSyncItem{Name: name, Action: stackSyncCreate, Changes: changes})
+			continue
+		}
+
+		changes, err := rokka.DiffStack(cur, desired[name])
+		if err != nil {
+			return res, err
+		}
+		action := stackSyncKeep
+		if len(changes) > 0 {
+			action = stackSyncUpdate
+		}
+		res.Items = append(res.Items, stackSyncItem{Name: name, Action: action, Changes: changes})
+	}
+
+	for _, s := range current {
+		if _, ok := desired[s.Name]; ok {
+			continue
+		}
+		if deleteStacks {
+			res.Items = append(res.Items, stackSyncItem{Name: s.Name, Action: stackSyncDelete})
+		} else {
+			res.Unmanaged = append(res.Unmanaged, s.Name)
+		}
+	}
+	sort.Strings(res.Unmanaged)
+
+	return res, nil
+}
+
+// HasDrift returns true if any stack needs to be created, updated or deleted.
+func (r stacksSyncResult) HasDrift() bool {
+	for _, i := range r.Items {
+		if i.Action != stackSyncKeep {
+			return true
+		}
+	}
+	return false
+}
+
+// stacksCount returns the number of stacks, e.g. "1 stack" or "2 stacks".
+func stacksCount(n int) string {
+	if n == 1 {
+		return "1 stack"
+	}
+	return fmt.Sprintf("%d stacks", n)
+}
+
+// Summary describes the changes which have been applied. The stacks are only reported to be in sync if nothing has
+// been changed and no stack is left which isn't defined in the directory.
+func (r stacksSyncResult) Summary() string {
+	if r.DryRun {
+		return "Dry run, no stacks have been changed."
+	}
+
+	counts := make(map[stackSyncAction]int)
+	for _, i := range r.Items {
+		counts[i.Action]++
+	}
+	changes := make([]string, 0)
+	for _, a := range []struct {
+		action stackSyncAction
+		verb   string
+	}{{stackSyncCreate, "created"}, {stackSyncUpdate, "updated"}, {stackSyncDelete, "deleted"}} {
+		if counts[a.action] > 0 {
+			changes = append(changes, a.verb+" "+stacksCount(counts[a.action]))
+		}
+	}
+
+	var summary string
+	switch len(changes) {
+	case 0:
+		if len(r.Unmanaged) == 0 {
+			return fmt.Sprintf("Stacks of organization %s are in sync.", r.Organization)
+		}
+		summary = fmt.Sprintf("No stacks of organization %s have been changed.", r.Organization)
+	case 1:
+		summary = fmt.Sprintf("%s of organization %s.", changes[0], r.Organization)
+	default:
+		summary = fmt.Sprintf("%s and %s of organization %s.", strings.Join(changes[:len(changes)-1], ", "), changes[len(changes)-1], r.Organization)
+	}
+	summary = strings.ToUpper(summary[:1]) + summary[1:]
+	if len(r.Unmanaged) > 0 {
+		summary += fmt.Sprintf(" Kept %s not defined in the directory.", stacksCount(len(r.Unmanaged)))
+	}
+	return summary
+}
+
+func syncStacks(c *rokka.Client, args []string) (interface{}, error) {
+	org := args[0]
+
+	desired, err := readStackDir(args[1])
+	if err != nil {
+		return nil, err
+	}
+	current, err := c.ListStacks(org)
+	if err != nil {
+		return nil, err
+	}
+	logDecodeWarnings(current.Warnings)
+
+	res, err := planStacksSync(current.Items, desired, stacksSyncDelete)
+	if err != nil {
+		return nil, err
+	}
+	res.Organization = org
+	res.DryRun = stacksSyncDryRun
+	stacksSyncDrift = res.HasDrift()
+
+	if stacksSyncDryRun {
+		return res, nil
+	}
+
+	for _, i := range res.Items {
+		switch i.Action {
+		case stackSyncCreate:
+			_, err = c.CreateStack(org, i.Name, desired[i.Name], false)
+		case stackSyncUpdate:
+			_, err = c.CreateStack(org, i.Name, desired[i.Name], true)
+		case stackSyncDelete:
+			err = c.DeleteStack(org, i.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s stack %s: %s", i.Action, i.Name, err)
+		}
+	}
+	return res, nil
+}
+
+const stacksSyncTemplate = `{{range .Items}}{{if eq .Action "create"}}+{{else if eq .Action "update"}}~{{else if eq .Action "delete"}}-{{else}}={{end}} {{.Name}} ({{.Action}})
+{{range .Changes}}    {{.}}
+{{end}}{{end}}{{range .Unmanaged}}  {{.}} (not defined in directory, use --delete to remove)
+{{end}}
+{{.Summary}}
+`
+
+var stacksSyncCmd = &cobra.Command{
+	Use:   "sync [org] [dir]",
+	Short: "Create, update and delete stacks to match the definitions in a directory",
+	Long: `Sync reads the stack definitions from the JSON or YAML files of a directory and compares them to the stacks of the organization.
+Every file contains a single stack in the same form as passed to "stacks create", the file name without extension is the name of the stack.
+
+The differences are printed before missing stacks are created and changed stacks are overwritten.
+Stacks not defined in the directory are only deleted if the --delete flag is passed.
+
+With --dry-run nothing is changed, and the command exits with status code 2 if the stacks of the organization differ from the directory.`,
+	Example: `  # show the differences without changing anything
+  rokka stacks sync test-organization ./stacks --dry-run
+
+  # make the organization match the directory exactly
+  rokka stacks sync test-organization ./stacks --delete`,
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		run(syncStacks, stacksSyncTemplate)(cmd, args)
+		if stacksSyncDryRun && stacksSyncDrift {
+			os.Exit(2)
+		}
+	},
+}
+
+func init() {
+	stacksCmd.AddCommand(stacksSyncCmd)
+
+	stacksSyncCmd.Flags().BoolVar(&stacksSyncDryRun, "dry-run", false, "Only show the differences without changing any stack")
+	stacksSyncCmd.Flags().BoolVar(&stacksSyncDelete, "delete", false, "Delete stacks which are not defined in the directory")
+}
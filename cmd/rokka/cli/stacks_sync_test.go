@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func writeStackFiles(files map[string]string) string {
+	dir, err := ioutil.TempDir(os.TempDir(), "stacks")
+	if err != nil {
+		panic(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			panic(err)
+		}
+	}
+	return dir
+}
+
+func TestReadStackDir(t *testing.T) {
+	dir := writeStackFiles(map[string]string{
+		"test2.yaml": "operations:\n  - name: resize\n    options:\n      width: 200\n      height: 100\noptions:\n  autoformat: false\n",
+		"test3.json": `{"operations":[{"name":"grayscale"}],"expressions":[{"expression":"options.dpr >= 2","overrides":{"options":{"jpg.quality":60}}}]}`,
+		"README.md":  "not a stack",
+	})
+	defer os.RemoveAll(dir)
+
+	stacks, err := readStackDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stacks) != 2 {
+		t.Fatalf("Expected 2 stacks, got %d", len(stacks))
+	}
+
+	resize, ok := stacks["test2"].Operations[0].(*rokka.ResizeOperation)
+	if !ok {
+		t.Fatalf("Expected a resize operation, got %#v", stacks["test2"].Operations[0])
+	}
+	if *resize.Width != 200 || *resize.Height != 100 {
+		t.Errorf("Expected resize to 200x100, got %dx%d", *resize.Width, *resize.Height)
+	}
+	if stacks["test2"].Options["autoformat"] != false {
+		t.Errorf("Expected autoformat to be false, got '%v'", stacks["test2"].Options["autoformat"])
+	}
+	if len(stacks["test3"].Expressions) != 1 {
+		t.Errorf("Expected 1 expression, got %d", len(stacks["test3"].Expressions))
+	}
+}
+
+func TestSyncStacks(t *testing.T) {
+	org := "test-org"
+	dir := writeStackFiles(map[string]string{
+		"test2.yml":  "operations:\n  - name: resize\n    options:\n      width: 200\n      height: 100\noptions:\n  autoformat: false\n",
+		"test3.json": `{"operations":[{"name":"grayscale"}]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	table := []struct {
+		dryRun   bool
+		delete   bool
+		requests []string
+		output   []string
+	}{
+		{true, false, []string{}, []string{
+			"~ test2 (update)\n    ~ operations[0].options.width: 100 -> 200\n",
+			"+ test3 (create)\n    + operations[0]: {\"name\":\"grayscale\"}\n",
+			"  test1 (not defined in directory, use --delete to remove)\n",
+			"Dry run, no stacks have been changed.",
+		}},
+		{false, false, []string{"PUT /stacks/test-org/test2?overwrite=true", "PUT /stacks/test-org/test3"}, []string{
+			"  test1 (not defined in directory, use --delete to remove)\n",
+			"Created 1 stack and updated 1 stack of organization test-org. Kept 1 stack not defined in the directory.",
+		}},
+		{false, true, []string{"PUT /stacks/test-org/test2?overwrite=true", "PUT /stacks/test-org/test3", "DELETE /stacks/test-org/test1"}, []string{
+			"- test1 (delete)\n",
+			"Created 1 stack, updated 1 stack and deleted 1 stack of organization test-org.",
+		}},
+	}
+
+	for _, v := range table {
+		requests := make([]string, 0)
+		record := func(t *testing.T, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.String())
+			if r.Method == http.MethodPut {
+				req := rokka.CreateStackRequest{}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+		update := test.NewResponse(http.StatusOK, "../../../rokka/fixtures/CreateStack.json")
+		update.Assertion = record
+		del := test.NewResponse(http.StatusNoContent, "")
+		del.Assertion = record
+
+		ts := test.NewMockAPI(t, test.Routes{
+			"GET /stacks/" + org:               test.NewResponse(http.StatusOK, "../../../rokka/fixtures/ListStacks.json"),
+			"PUT /stacks/" + org + "/test2":    update,
+			"PUT /stacks/" + org + "/test3":    update,
+			"DELETE /stacks/" + org + "/test1": del,
+		})
+
+		f, err := ioutil.TempFile(os.TempDir(), "stdout")
+		if err != nil {
+			panic(err)
+		}
+
+		log := newCLILog(false)
+		log.StdOut = f
+		logger = log
+		rokkaClient = rokka.NewClient(&rokka.Config{APIAddress: ts.URL, HTTPClient: newHTTPClient(log)})
+
+		stacksSyncDryRun = v.dryRun
+		stacksSyncDelete = v.delete
+		stacksSyncDrift = false
+		// the command itself exits in case of a drift during a dry run.
+		run(syncStacks, stacksSyncTemplate)(stacksSyncCmd, []string{org, dir})
+		ts.Close()
+
+		f.Seek(0, 0)
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		os.Remove(f.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		if !stacksSyncDrift {
+			t.Error("Expected a drift to be detected")
+		}
+		if strings.Join(requests, ",") != strings.Join(v.requests, ",") {
+			t.Errorf("Expected requests '%v', got '%v'", v.requests, requests)
+		}
+		for _, o := range v.output {
+			if !strings.Contains(string(b), o) {
+				t.Errorf("Expected output to contain '%s', got '%s'", o, b)
+			}
+		}
+	}
+}
+
+func TestStacksSyncResult_Summary(t *testing.T) {
+	table := []struct {
+		res      stacksSyncResult
+		expected string
+	}{
+		{stacksSyncResult{Items: []stackSyncItem{{Name: "a", Action: stackSyncKeep}}}, "Stacks of organization test-org are in sync."},
+		{stacksSyncResult{Unmanaged: []string{"a", "b"}}, "No stacks of organization test-org have been changed. Kept 2 stacks not defined in the directory."},
+		{stacksSyncResult{Items: []stackSyncItem{{Name: "a", Action: stackSyncUpdate}, {Name: "b", Action: stackSyncUpdate}}}, "Updated 2 stacks of organization test-org."},
+		{stacksSyncResult{DryRun: true, Items: []stackSyncItem{{Name: "a", Action: stackSyncCreate}}}, "Dry run, no stacks have been changed."},
+	}
+
+	for _, v := range table {
+		v.res.Organization = "test-org"
+		if s := v.res.Summary(); s != v.expected {
+			t.Errorf("Expected summary '%s', got '%s'", v.expected, s)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -178,3 +179,77 @@ func TestCreateStack_CLI(t *testing.T) {
 		t.Fatalf("Expected %d options to be added, got %d", 0, len(req.Options))
 	}
 }
+
+func TestCreateStack_CLIStackOptions(t *testing.T) {
+	org := "test-org"
+	name := "test-name"
+	ts := test.NewMockAPI(t, test.Routes{
+		"GET /operations":   test.NewResponse(http.StatusOK, "../../../rokka/fixtures/GetOperations.json"),
+		"GET /stackoptions": test.NewResponse(http.StatusOK, "../../../rokka/fixtures/GetStackOptions.json"),
+	})
+	defer ts.Close()
+	c := rokka.NewClient(&rokka.Config{APIAddress: ts.URL})
+
+	f, err := ioutil.TempFile(os.TempDir(), "stdin")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(f.Name())
+	stdin = f
+
+	inputs := []string{
+		"q",        // don't add any operation
+		"y",        // add stack options
+		"",         // basestack - empty input
+		"diagonal", // interlacing.mode - wrong input
+		"y",        // yes, want to retry
+		"line",     // interlacing.mode - correct input
+		"101",      // jpg.quality - above maximum
+		"y",        // yes, want to retry
+		"90",       // jpg.quality - correct input
+		"10",       // png.compression_level - above maximum
+		"n",        // no, don't want to retry
+		"",         // webp.quality - empty input
+		"",         // newline in the end is required
+	}
+	_, err = f.Write([]byte(strings.Join(inputs, "\n")))
+	if err != nil {
+		panic(err)
+	}
+	f.Seek(0, 0)
+
+	req := rokka.CreateStackRequest{}
+	if err := cliCreateStack(c, name, org, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := rokka.StackOptions{
+		"interlacing.mode": "line",
+		"jpg.quality":      90,
+	}
+	if !reflect.DeepEqual(req.Options, expected) {
+		t.Errorf("Expected options %v, got %v", expected, req.Options)
+	}
+}
+
+func TestValidateStackOptionInput_Boolean(t *testing.T) {
+	var definitions rokka.StackOptionsResponse
+	if err := json.Unmarshal([]byte(`{"properties": {"autoformat": {"type": "boolean"}}}`), &definitions); err != nil {
+		t.Fatal(err)
+	}
+
+	options := make(rokka.StackOptions)
+	if err := validateStackOptionInput(definitions, "autoformat", "true", options); err != nil {
+		t.Fatal(err)
+	}
+	if options["autoformat"] != true {
+		t.Errorf("Expected autoformat to be true, got %v", options["autoformat"])
+	}
+
+	for _, val := range []string{"yes", "ture", ""} {
+		options := make(rokka.StackOptions)
+		if err := validateStackOptionInput(definitions, "autoformat", val, options); err == nil {
+			t.Errorf("Expected an error for %q, got options %v", val, options)
+		}
+	}
+}
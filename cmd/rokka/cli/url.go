@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/spf13/cobra"
+)
+
+var (
+	urlSignKey     string
+	urlSignExpires time.Duration
+)
+
+// explainedOperation is an operation of a render URL with its options as a map, which is easier to print.
+type explainedOperation struct {
+	Name    string
+	Options map[string]interface{}
+}
+
+// explainedURL is the parsed render URL as printed by "url explain".
+type explainedURL struct {
+	rokka.RenderURL
+	Operations []explainedOperation
+}
+
+func explainURL(c *rokka.Client, args []string) (interface{}, error) {
+	u, err := c.ParseURL(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	res := explainedURL{RenderURL: u, Operations: make([]explainedOperation, 0, len(u.Operations))}
+	for _, o := range u.Operations {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return nil, err
+		}
+		op := explainedOperation{Name: o.Name()}
+		if err := json.Unmarshal(b, &op.Options); err != nil {
+			return nil, err
+		}
+		res.Operations = append(res.Operations, op)
+	}
+	return res, nil
+}
+
+func signURL(c *rokka.Client, args []string) (interface{}, error) {
+	if urlSignKey == "" {
+		return nil, errors.New("the signature key needs to be passed using --key")
+	}
+
+	var until time.Time
+	if urlSignExpires > 0 {
+		until = time.Now().Add(urlSignExpires)
+	}
+	return rokka.SignURL(args[0], urlSignKey, until)
+}
+
+const urlExplainTemplate = `Organization:	{{.Organization}}
+Stack:	{{.Stack}}
+Hash:	{{.Hash}}
+{{if .Filename}}Filename:	{{.Filename}}
+{{end}}Format:	{{.Format}}
+{{if .Operations}}Operations:
+{{range .Operations}}  {{.Name}}{{range $k, $v := .Options}} {{$k}}={{$v}}{{end}}
+{{end}}{{end}}{{if .Options}}Stack options:
+{{range $k, $v := .Options}}  {{$k}}	{{$v}}
+{{end}}{{end}}`
+
+// urlCmd represents the url command
+var urlCmd = &cobra.Command{
+	Use:                   "url",
+	Short:                 "Work with render URLs",
+	Run:                   nil,
+	DisableFlagsInUseLine: true,
+}
+
+var urlExplainCmd = &cobra.Command{
+	Use:   "explain [url]",
+	Short: "Show the organization, stack, operations and options of a render URL",
+	Long: `Explain parses a render URL without contacting the API and prints its parts.
+URLs using a custom image host are parsed according to the configured image host.`,
+	Example:               "  rokka url explain https://test.rokka.io/dynamic/resize-width-200--options-autoformat-true/c1b110.jpg",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run:                   run(explainURL, urlExplainTemplate),
+}
+
+var urlSignCmd = &cobra.Command{
+	Use:   "sign [url]",
+	Short: "Sign a render URL for a protected stack",
+	Long: `Sign appends the signature of the URL as "sig" parameter, replacing an existing signature.
+The signature key can be found in the settings of the organization. With --expires the URL is only valid for the given duration.`,
+	Example:               "  rokka url sign https://test.rokka.io/protected/c1b110.jpg --key my-signature-key --expires 24h",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run:                   run(signURL, "{{.}}\n"),
+}
+
+func init() {
+	rootCmd.AddCommand(urlCmd)
+
+	urlCmd.AddCommand(urlExplainCmd)
+	urlCmd.AddCommand(urlSignCmd)
+
+	urlSignCmd.Flags().StringVar(&urlSignKey, "key", "", "Signature key of the organization")
+	urlSignCmd.Flags().DurationVar(&urlSignExpires, "expires", 0, "Duration after which the signed URL expires, e.g. 1h (default never)")
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/spf13/cobra"
+)
+
+func TestExplainURL(t *testing.T) {
+	stdOut, err := ioutil.TempFile(os.TempDir(), "stdout")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(stdOut.Name())
+
+	logger = newCLILog(false)
+	logger.StdOut = stdOut
+	rokkaClient = rokka.NewClient(&rokka.Config{})
+
+	run(explainURL, urlExplainTemplate)(&cobra.Command{}, []string{"https://test.rokka.io/dynamic/resize-mode-fill-width-200--grayscale--options-autoformat-true/c1b110/image.jpg"})
+
+	stdOut.Seek(0, 0)
+	out, err := ioutil.ReadAll(stdOut)
+	if err != nil {
+		panic(err)
+	}
+
+	expected := `Organization:  test
+Stack:         dynamic
+Hash:          c1b110
+Filename:      image
+Format:        jpg
+Operations:
+  resize mode=fill width=200
+  grayscale
+Stack options:
+  autoformat  true
+`
+	if string(out) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, out)
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	u := "https://test.rokka.io/dynamic/noop/c1b110.jpg"
+
+	urlSignKey = ""
+	if _, err := signURL(nil, []string{u}); err == nil {
+		t.Error("Expected an error without a signature key")
+	}
+
+	urlSignKey = "secret"
+	urlSignExpires = time.Hour
+	defer func() {
+		urlSignKey = ""
+		urlSignExpires = 0
+	}()
+	res, err := signURL(nil, []string{u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rokka.VerifySignedURL(res.(string), []string{"secret"}); err != nil {
+		t.Errorf("Unexpected error verifying '%s': %s", res, err)
+	}
+	if !strings.Contains(res.(string), "sigopts=") {
+		t.Errorf("Expected '%s' to contain an expiry", res)
+	}
+}
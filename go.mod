@@ -10,4 +10,5 @@ require (
 	github.com/spf13/cobra v0.0.0-20180115160933-0c34d16c3123
 	github.com/spf13/pflag v1.0.0
 	gopkg.in/cheggaaa/pb.v1 v1.0.22
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,6 +1,7 @@
 package rokka
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 var errorAPIKeyMissing = errors.New("API key must be set")
@@ -48,6 +50,18 @@ type Config struct {
 	Verbose            bool
 	HTTPClient         HTTPRequester
 	RetryingHTTPClient HTTPRequester
+
+	// SignatureKeys are the keys used to sign render URLs. The first one is used for signing, all of them are
+	// accepted by Client.VerifySignedURL, which allows to rotate keys.
+	SignatureKeys []string
+	// SignURLs enables signing of the URLs returned by GetURL and GetURLForStack.
+	SignURLs bool
+	// SignatureValidity limits how long signed URLs are valid. They don't expire if it's zero.
+	SignatureValidity time.Duration
+
+	// StrictOperations makes requests returning stacks fail if a stack contains an operation unknown to this version
+	// of the client. By default such operations are kept as RawOperation.
+	StrictOperations bool
 }
 
 // APIError is returned by the API in case of errors.
@@ -59,10 +73,13 @@ type APIError struct {
 }
 
 // StatusCodeError satifies the Error interface and is returned when a response contains a status code >= 400.
+// It wraps one of the typed errors (e.g. *NotFoundError) depending on the status code, which allows to use
+// errors.Is and errors.As to check for the kind of error.
 type StatusCodeError struct {
 	Code     int
 	APIError *APIError
 	Body     []byte
+	Header   http.Header
 }
 
 // Error creates an error string.
@@ -74,6 +91,11 @@ func (e StatusCodeError) Error() string {
 	return s
 }
 
+// Unwrap returns the typed error matching the status code, or nil if there is none.
+func (e StatusCodeError) Unwrap() error {
+	return kindError(e)
+}
+
 type responseHandler func(resp *http.Response, v interface{}) error
 
 // DefaultConfig is used when calling NewClient with not all config options set.
@@ -113,7 +135,7 @@ func NewClient(config *Config) (c *Client) {
 	}
 
 	if config.RetryingHTTPClient == nil {
-		config.RetryingHTTPClient = NewRetryingHTTPClient(config.HTTPClient, 10, 6000)
+		config.RetryingHTTPClient = NewRetryingHTTPClientWithPolicy(config.HTTPClient, DefaultRetryPolicy())
 	}
 
 	return &Client{
@@ -130,8 +152,9 @@ func handleStatusCodeError(resp *http.Response) error {
 
 	rErr := APIError{}
 	sErr := StatusCodeError{
-		Code: resp.StatusCode,
-		Body: body,
+		Code:   resp.StatusCode,
+		Body:   body,
+		Header: resp.Header,
 	}
 	if len(body) == 0 {
 		return sErr
@@ -175,6 +198,7 @@ func handleUnmarshalError(err error, body []byte) error {
 //    cl := rokka.NewClient(&rokka.Config{})
 //    cl.AutoRetry().GetOrganization("example")
 //
+// The retry behaviour can be adjusted by passing a RetryingHTTPClient created using NewRetryingHTTPClientWithPolicy.
 // By following the pattern outlined in `http.go`, an own, more tailored retry pattern can be implemented if needed.
 // When instantiating the rokka client such a specific implementation can be passed to the config as RetryingHTTPClient.
 //
@@ -188,6 +212,7 @@ func (c *Client) AutoRetry() *Client {
 // Call executes an HTTP request.
 // It automatically adds the Api-Version and Api-Key headers to the request.
 // If the response contains a status code >= 400 a StatusCodeError is returned.
+// The request is cancelled as soon as the context of req is done.
 func (c *Client) Call(req *http.Request, v interface{}, rh responseHandler) error {
 	req.Header.Add("Api-Version", c.config.APIVersion)
 	req.Header.Add("Accept", "application/json")
@@ -200,19 +225,37 @@ func (c *Client) Call(req *http.Request, v interface{}, rh responseHandler) erro
 		req.Header.Add("Content-Type", "application/json")
 	}
 
-	resp, err := c.config.HTTPClient.Do(req)
+	resp, err := do(c.config.HTTPClient, req)
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode >= 400 {
-		return handleStatusCodeError(resp)
-	}
 	if rh != nil {
 		return rh(resp, v)
 	}
 	return nil
 }
 
+// do executes the request using hc and converts responses with a status code >= 400 to a StatusCodeError.
+func do(hc HTTPRequester, req *http.Request) (*http.Response, error) {
+	resp, err := hc.Do(req)
+	if err != nil {
+		// prefer the context error over the error of the transport to make it easier for callers to check for it.
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// when retrying failed because of the status code, expose the StatusCodeError of the last response.
+		if mErr, ok := err.(ErrMaxRetriesReached); ok && mErr.LastError == nil && resp != nil && resp.StatusCode >= 400 {
+			mErr.LastError = handleStatusCodeError(resp)
+			return nil, mErr
+		}
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, handleStatusCodeError(resp)
+	}
+	return resp, nil
+}
+
 func jsonResponseHandler(resp *http.Response, v interface{}) error {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -236,23 +279,39 @@ func (c *Client) CallJSONResponse(req *http.Request, v interface{}) error {
 
 // NewRequest constructs a new http.Request used for executing using Call.
 func (c *Client) NewRequest(method, path string, body io.Reader, query url.Values) (*http.Request, error) {
+	return c.NewRequestWithContext(context.Background(), method, path, body, query)
+}
+
+// NewRequestWithContext constructs a new http.Request bound to ctx used for executing using Call.
+func (c *Client) NewRequestWithContext(ctx context.Context, method, path string, body io.Reader, query url.Values) (*http.Request, error) {
+	if ctx == nil {
+		return nil, errors.New("rokka: nil context")
+	}
 	req, err := http.NewRequest(method, c.config.APIAddress+path, body)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(query) > 0 {
 		req.URL.RawQuery = query.Encode()
 	}
 
-	return req, err
+	return req.WithContext(ctx), nil
 }
 
 // ValidAPIKey can be used to check if the API key is valid. It will execute a request to `/` which is an undocumented API.
 // This function only returns true if there has been no error and the status code is < 400.
 func (c *Client) ValidAPIKey() (bool, error) {
+	return c.ValidAPIKeyWithContext(context.Background())
+}
+
+// ValidAPIKeyWithContext is the same as ValidAPIKey with the addition of passing a context.
+func (c *Client) ValidAPIKeyWithContext(ctx context.Context) (bool, error) {
 	if len(c.config.APIKey) == 0 {
 		return false, errorAPIKeyMissing
 	}
 
-	req, err := c.NewRequest(http.MethodGet, "/", nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/", nil, nil)
 	if err != nil {
 		return false, err
 	}
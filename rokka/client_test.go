@@ -1,6 +1,7 @@
 package rokka
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"testing"
@@ -77,6 +78,35 @@ func TestValidAPIKey_InvalidKey(t *testing.T) {
 	}
 }
 
+func TestCall_ContextCanceled(t *testing.T) {
+	r := test.NewResponse(http.StatusOK, "./fixtures/GetValidAPIKey.json")
+	ts := test.NewMockAPI(t, test.Routes{"GET /": r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL, APIKey: "test"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ok, err := c.ValidAPIKeyWithContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("Expected error '%v', got '%v'", context.Canceled, err)
+	}
+	if ok {
+		t.Error("Expected to not have a valid API key")
+	}
+}
+
+func TestNewRequestWithContext_NilContext(t *testing.T) {
+	c := NewClient(&Config{})
+
+	//lint:ignore SA1012 testing the nil check on purpose
+	_, err := c.NewRequestWithContext(nil, http.MethodGet, "/", nil, nil)
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
 func TestAutoRetryDoesNotAffectOriginalClient(t *testing.T) {
 	c := NewClient(&Config{})
 	for i := 0; i < 10; i++ {
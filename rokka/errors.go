@@ -0,0 +1,136 @@
+package rokka
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Sentinel errors describing the kind of a failed request. They can be checked using errors.Is, even if the
+// StatusCodeError is wrapped within an ErrMaxRetriesReached, e.g.:
+//
+//    if errors.Is(err, rokka.ErrNotFound) {
+//        // handle missing image
+//    }
+var (
+	ErrValidation   = errors.New("rokka: validation failed")
+	ErrUnauthorized = errors.New("rokka: unauthorized")
+	ErrForbidden    = errors.New("rokka: forbidden")
+	ErrNotFound     = errors.New("rokka: not found")
+	ErrConflict     = errors.New("rokka: conflict")
+	ErrRateLimited  = errors.New("rokka: rate limited")
+	ErrServer       = errors.New("rokka: server error")
+)
+
+// ValidationError is returned if the request has been rejected as invalid (status code 400 or 422).
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string { return kindErrorString(ErrValidation, e.Message) }
+
+// Unwrap returns ErrValidation.
+func (e *ValidationError) Unwrap() error { return ErrValidation }
+
+// UnauthorizedError is returned if no or invalid credentials have been supplied (status code 401).
+type UnauthorizedError struct {
+	Message string
+}
+
+func (e *UnauthorizedError) Error() string { return kindErrorString(ErrUnauthorized, e.Message) }
+
+// Unwrap returns ErrUnauthorized.
+func (e *UnauthorizedError) Unwrap() error { return ErrUnauthorized }
+
+// ForbiddenError is returned if the API key is not allowed to access the resource (status code 403).
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string { return kindErrorString(ErrForbidden, e.Message) }
+
+// Unwrap returns ErrForbidden.
+func (e *ForbiddenError) Unwrap() error { return ErrForbidden }
+
+// NotFoundError is returned if the resource does not exist (status code 404).
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string { return kindErrorString(ErrNotFound, e.Message) }
+
+// Unwrap returns ErrNotFound.
+func (e *NotFoundError) Unwrap() error { return ErrNotFound }
+
+// ConflictError is returned if the resource conflicts with an existing one (status code 409).
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string { return kindErrorString(ErrConflict, e.Message) }
+
+// Unwrap returns ErrConflict.
+func (e *ConflictError) Unwrap() error { return ErrConflict }
+
+// RateLimitedError is returned if too many requests have been sent (status code 429).
+// RetryAfter is set if rokka responded with a Retry-After header.
+type RateLimitedError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string { return kindErrorString(ErrRateLimited, e.Message) }
+
+// Unwrap returns ErrRateLimited.
+func (e *RateLimitedError) Unwrap() error { return ErrRateLimited }
+
+// ServerError is returned if rokka failed to process the request (status code >= 500).
+type ServerError struct {
+	Code    int
+	Message string
+}
+
+func (e *ServerError) Error() string { return kindErrorString(ErrServer, e.Message) }
+
+// Unwrap returns ErrServer.
+func (e *ServerError) Unwrap() error { return ErrServer }
+
+func kindErrorString(kind error, message string) string {
+	if message == "" {
+		return kind.Error()
+	}
+	return kind.Error() + " (" + message + ")"
+}
+
+// kindError derives the typed error from the code of the API error, falling back to the status code of the response.
+// It returns nil if the code doesn't match any known kind.
+func kindError(e StatusCodeError) error {
+	code := e.Code
+	message := ""
+	if e.APIError != nil {
+		message = e.APIError.Error.Message
+		if e.APIError.Error.Code >= 400 {
+			code = e.APIError.Error.Code
+		}
+	}
+
+	switch {
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return &ValidationError{Message: message}
+	case code == http.StatusUnauthorized:
+		return &UnauthorizedError{Message: message}
+	case code == http.StatusForbidden:
+		return &ForbiddenError{Message: message}
+	case code == http.StatusNotFound:
+		return &NotFoundError{Message: message}
+	case code == http.StatusConflict:
+		return &ConflictError{Message: message}
+	case code == http.StatusTooManyRequests:
+		retryAfter := retryAfterSeconds(e.Header.Get("Retry-After"), math.MaxInt32)
+		return &RateLimitedError{Message: message, RetryAfter: time.Duration(retryAfter * float64(time.Second))}
+	case code >= 500:
+		return &ServerError{Code: code, Message: message}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package rokka
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestStatusCodeError_Kinds(t *testing.T) {
+	table := []struct {
+		code     int
+		apiCode  int
+		expected error
+	}{
+		{http.StatusBadRequest, 0, ErrValidation},
+		{http.StatusUnprocessableEntity, 0, ErrValidation},
+		{http.StatusUnauthorized, 0, ErrUnauthorized},
+		{http.StatusForbidden, 0, ErrForbidden},
+		{http.StatusNotFound, 0, ErrNotFound},
+		{http.StatusConflict, 0, ErrConflict},
+		{http.StatusTooManyRequests, 0, ErrRateLimited},
+		{http.StatusServiceUnavailable, 0, ErrServer},
+		{http.StatusBadRequest, http.StatusConflict, ErrConflict},
+		{http.StatusTeapot, 0, nil},
+	}
+
+	for _, v := range table {
+		t.Run(http.StatusText(v.code), func(t *testing.T) {
+			sErr := StatusCodeError{Code: v.code}
+			if v.apiCode != 0 {
+				sErr.APIError = &APIError{}
+				sErr.APIError.Error.Code = v.apiCode
+			}
+			if v.expected == nil {
+				if sErr.Unwrap() != nil {
+					t.Errorf("Expected no typed error, got '%v'", sErr.Unwrap())
+				}
+				return
+			}
+			if !errors.Is(sErr, v.expected) {
+				t.Errorf("Expected error to be '%v', got '%v'", v.expected, sErr.Unwrap())
+			}
+			if !errors.Is(ErrMaxRetriesReached{LastError: sErr}, v.expected) {
+				t.Errorf("Expected wrapped error to be '%v'", v.expected)
+			}
+		})
+	}
+}
+
+func TestStatusCodeError_As(t *testing.T) {
+	r := test.NewResponse(http.StatusTooManyRequests, "")
+	r.Headers["Retry-After"] = "2"
+	ts := test.NewMockAPI(t, test.Routes{"GET /organizations/test": r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL})
+
+	_, err := c.GetOrganization("test")
+
+	var sErr StatusCodeError
+	if !errors.As(err, &sErr) {
+		t.Fatalf("Expected error of type '%T', got '%T'", sErr, err)
+	}
+	var rlErr *RateLimitedError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("Expected error of type '%T', got '%T'", rlErr, err)
+	}
+	if rlErr.RetryAfter != 2*time.Second {
+		t.Errorf("Expected RetryAfter to be '%s', got '%s'", 2*time.Second, rlErr.RetryAfter)
+	}
+}
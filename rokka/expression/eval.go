@@ -0,0 +1,344 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+// Request is a simulated render request the expressions are evaluated for.
+type Request struct {
+	// DPR is the value of options.dpr. It defaults to 1.
+	DPR float64
+	// FinalWidth and FinalHeight are the dimensions of the rendered image, available as $finalWidth and $finalHeight.
+	FinalWidth  int
+	FinalHeight int
+	// Format is the requested format, e.g. jpg, available as request.format.
+	Format string
+	// Options are the stack options, e.g. the ones of the stack combined with the ones of the render URL.
+	Options rokka.StackOptions
+	// Variables are the stack variables.
+	Variables rokka.StackVariables
+}
+
+// EvalError describes an expression which can't be evaluated, e.g. because of an undefined variable or comparing
+// values of different types.
+type EvalError struct {
+	// Pos is the byte offset of the node causing the error within the expression.
+	Pos     int
+	Message string
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("expression: evaluation failed at column %d: %s", e.Pos+1, e.Message)
+}
+
+func evalErrorf(n Node, format string, a ...interface{}) error {
+	return &EvalError{n.Pos(), fmt.Sprintf(format, a...)}
+}
+
+// lookup returns the value of an identifier or variable.
+func (r Request) lookup(n Node) (interface{}, error) {
+	switch n := n.(type) {
+	case Variable:
+		switch n.Name {
+		case "finalWidth":
+			return float64(r.FinalWidth), nil
+		case "finalHeight":
+			return float64(r.FinalHeight), nil
+		}
+		v, ok := r.Variables[n.Name]
+		if !ok {
+			return nil, evalErrorf(n, "undefined variable $%s", n.Name)
+		}
+		return value(n, v)
+	case Identifier:
+		if len(n.Path) > 1 && n.Path[0] == "options" {
+			name := strings.Join(n.Path[1:], ".")
+			if name == "dpr" {
+				if r.DPR == 0 {
+					return float64(1), nil
+				}
+				return r.DPR, nil
+			}
+			// options which aren't set are null, rokka uses their default value.
+			return value(n, r.Options[name])
+		}
+		if len(n.Path) == 2 && n.Path[0] == "request" && n.Path[1] == "format" {
+			return r.Format, nil
+		}
+		return nil, evalErrorf(n, "unknown identifier %s", n)
+	}
+	return nil, evalErrorf(n, "can't look up %s", n)
+}
+
+// normalize converts all numbers to float64.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// value returns the value of an option or variable if it's of a type supported by expressions.
+func value(n Node, v interface{}) (interface{}, error) {
+	v = normalize(v)
+	switch v.(type) {
+	case nil, float64, string, bool:
+		return v, nil
+	}
+	return nil, evalErrorf(n, "%s has an unsupported value of type %T", n, v)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Eval evaluates the syntax tree for the request and returns the result, which is either a float64, a string,
+// a bool or nil.
+func Eval(n Node, r Request) (interface{}, error) {
+	switch n := n.(type) {
+	case Number:
+		return n.Value, nil
+	case String:
+		return n.Value, nil
+	case Bool:
+		return n.Value, nil
+	case Null:
+		return nil, nil
+	case Variable, Identifier:
+		return r.lookup(n)
+	case Unary:
+		x, err := Eval(n.X, r)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case "!":
+			b, ok := x.(bool)
+			if !ok {
+				return nil, evalErrorf(n, "operator ! expects a boolean, got %s", typeName(x))
+			}
+			return !b, nil
+		case "-":
+			f, ok := x.(float64)
+			if !ok {
+				return nil, evalErrorf(n, "operator - expects a number, got %s", typeName(x))
+			}
+			return -f, nil
+		}
+	case Binary:
+		return evalBinary(n, r)
+	}
+	return nil, evalErrorf(n, "unknown node %T", n)
+}
+
+func evalBinary(n Binary, r Request) (interface{}, error) {
+	x, err := Eval(n.X, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// the right operand of logical operators is only evaluated if needed.
+	if n.Op == "&&" || n.Op == "||" {
+		a, ok := x.(bool)
+		if !ok {
+			return nil, evalErrorf(n, "operator %s expects booleans, got %s", n.Op, typeName(x))
+		}
+		if (n.Op == "&&" && !a) || (n.Op == "||" && a) {
+			return a, nil
+		}
+		y, err := Eval(n.Y, r)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := y.(bool)
+		if !ok {
+			return nil, evalErrorf(n, "operator %s expects booleans, got %s", n.Op, typeName(y))
+		}
+		return b, nil
+	}
+
+	y, err := Eval(n.Y, r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case "==":
+		return x == y, nil
+	case "!=":
+		return x != y, nil
+	case "<", "<=", ">", ">=":
+		return compare(n, x, y)
+	}
+
+	a, aok := x.(float64)
+	b, bok := y.(float64)
+	if n.Op == "+" {
+		if s, ok := x.(string); ok {
+			if t, ok := y.(string); ok {
+				return s + t, nil
+			}
+		}
+	}
+	if !aok || !bok {
+		return nil, evalErrorf(n, "operator %s expects numbers, got %s and %s", n.Op, typeName(x), typeName(y))
+	}
+	switch n.Op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, evalErrorf(n, "division by zero")
+		}
+		return a / b, nil
+	case "%":
+		if b == 0 {
+			return nil, evalErrorf(n, "division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+	return nil, evalErrorf(n, "unknown operator %s", n.Op)
+}
+
+func compare(n Binary, x, y interface{}) (interface{}, error) {
+	var c int
+	switch a := x.(type) {
+	case float64:
+		b, ok := y.(float64)
+		if !ok {
+			return nil, evalErrorf(n, "can't compare %s and %s", typeName(x), typeName(y))
+		}
+		switch {
+		case a < b:
+			c = -1
+		case a > b:
+			c = 1
+		}
+	case string:
+		b, ok := y.(string)
+		if !ok {
+			return nil, evalErrorf(n, "can't compare %s and %s", typeName(x), typeName(y))
+		}
+		c = strings.Compare(a, b)
+	default:
+		return nil, evalErrorf(n, "can't compare %s and %s", typeName(x), typeName(y))
+	}
+
+	switch n.Op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// Evaluate parses the expression and returns whether it's true for the request.
+func Evaluate(expression string, r Request) (bool, error) {
+	n, err := Parse(expression)
+	if err != nil {
+		return false, err
+	}
+	v, err := Eval(n, r)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, &EvalError{n.Pos(), fmt.Sprintf("expected a boolean result, got %s", typeName(v))}
+	}
+	return b, nil
+}
+
+// Result is the outcome of a single expression of a stack.
+type Result struct {
+	Expression rokka.Expression
+	// Applies is true if the expression is true for the request and its overrides are therefore applied.
+	Applies bool
+	// Err is set if the expression is invalid, it doesn't apply in that case.
+	Err error
+}
+
+// Explanation lists the outcome of the expressions of a stack in order and the options and variables resulting from
+// applying the overrides.
+type Explanation struct {
+	Results   []Result
+	Options   rokka.StackOptions
+	Variables rokka.StackVariables
+}
+
+// Explain evaluates the expressions of a stack for the request. The overrides of all expressions which apply are
+// applied in order, later ones therefore take precedence. Invalid expressions are reported in the results.
+func Explain(expressions []rokka.Expression, r Request) Explanation {
+	res := Explanation{
+		Results:   make([]Result, 0, len(expressions)),
+		Options:   make(rokka.StackOptions),
+		Variables: make(rokka.StackVariables),
+	}
+	for k, v := range r.Options {
+		res.Options[k] = v
+	}
+	for k, v := range r.Variables {
+		res.Variables[k] = v
+	}
+
+	for _, e := range expressions {
+		applies, err := Evaluate(e.Expression, r)
+		res.Results = append(res.Results, Result{Expression: e, Applies: applies, Err: err})
+		if !applies {
+			continue
+		}
+		if options, ok := e.Overrides["options"].(map[string]interface{}); ok {
+			for k, v := range options {
+				res.Options[k] = v
+			}
+		}
+		if variables, ok := e.Overrides["variables"].(map[string]interface{}); ok {
+			for k, v := range variables {
+				res.Variables[k] = v
+			}
+		}
+	}
+	return res
+}
+
+// Errors returns the errors of all invalid expressions.
+func (e Explanation) Errors() []error {
+	errs := make([]error, 0)
+	for _, r := range e.Results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,463 @@
+// Package expression parses and evaluates the expressions of stacks, e.g. `options.dpr >= 2` or
+// `$finalWidth > 100 && request.format == "webp"`.
+//
+// Parse returns the syntax tree of an expression or a *SyntaxError with the position of the mistake, which allows
+// finding mistakes before rokka rejects the stack. Explain evaluates the expressions of a stack for a simulated
+// request and reports which overrides apply:
+//
+//    res, err := expression.Explain(stack.StackExpressions, expression.Request{DPR: 2, Format: "jpg"})
+//
+// The grammar supports numbers, strings in single or double quotes, true, false and null, the comparison operators
+// ==, !=, <, <=, > and >=, the arithmetic operators +, -, *, / and %, the logical operators &&, || and ! (or and, or
+// and not), as well as parentheses. Values of the request are referenced using
+//
+//    options.<name>   the stack options, e.g. options.dpr or options.jpg.quality
+//    request.format   the requested format, e.g. jpg
+//    $<name>          the stack variables, including $finalWidth and $finalHeight
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of the syntax tree of an expression.
+type Node interface {
+	// Pos returns the byte offset of the node within the expression.
+	Pos() int
+	// String returns the node in the form of an expression.
+	String() string
+}
+
+// Number is a numeric literal.
+type Number struct {
+	Position int
+	Value    float64
+}
+
+// String is a string literal.
+type String struct {
+	Position int
+	Value    string
+}
+
+// Bool is either true or false.
+type Bool struct {
+	Position int
+	Value    bool
+}
+
+// Null is the null literal.
+type Null struct {
+	Position int
+}
+
+// Variable references a stack variable, e.g. `$finalWidth`.
+type Variable struct {
+	Position int
+	Name     string
+}
+
+// Identifier references a value of the request by its path, e.g. `options.dpr` is [options dpr].
+type Identifier struct {
+	Position int
+	Path     []string
+}
+
+// Unary is an operation with a single operand, either `!` or `-`.
+type Unary struct {
+	Position int
+	Op       string
+	X        Node
+}
+
+// Binary is an operation with two operands, e.g. `>=` or `&&`. The keywords `and` and `or` are stored as `&&` and
+// `||`.
+type Binary struct {
+	Position int
+	Op       string
+	X        Node
+	Y        Node
+}
+
+// Pos implements Node.Pos.
+func (n Number) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n String) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n Bool) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n Null) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n Variable) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n Identifier) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n Unary) Pos() int { return n.Position }
+
+// Pos implements Node.Pos.
+func (n Binary) Pos() int { return n.Position }
+
+func (n Number) String() string     { return strconv.FormatFloat(n.Value, 'f', -1, 64) }
+func (n String) String() string     { return strconv.Quote(n.Value) }
+func (n Bool) String() string       { return strconv.FormatBool(n.Value) }
+func (n Null) String() string       { return "null" }
+func (n Variable) String() string   { return "$" + n.Name }
+func (n Identifier) String() string { return strings.Join(n.Path, ".") }
+func (n Unary) String() string      { return n.Op + n.X.String() }
+func (n Binary) String() string {
+	return "(" + n.X.String() + " " + n.Op + " " + n.Y.String() + ")"
+}
+
+// SyntaxError describes an invalid expression.
+type SyntaxError struct {
+	Expression string
+	// Pos is the byte offset of the mistake within the expression.
+	Pos     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("expression: syntax error at column %d: %s", e.Pos+1, e.Message)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenVariable
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	value string
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return "string " + strconv.Quote(t.value)
+	case tokenVariable:
+		return "'$" + t.value + "'"
+	}
+	return "'" + t.value + "'"
+}
+
+// operators lists the operators ordered by length to match the longest first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "."}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// tokenize splits the expression into tokens.
+func tokenize(s string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c):
+			j := i
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			if j+1 < len(s) && s[j] == '.' && isDigit(s[j+1]) {
+				j++
+				for j < len(s) && isDigit(s[j]) {
+					j++
+				}
+			}
+			tokens = append(tokens, token{tokenNumber, i, s[i:j]})
+			i = j
+		case isLetter(c) || c == '$':
+			j := i + 1
+			for j < len(s) && (isLetter(s[j]) || isDigit(s[j])) {
+				j++
+			}
+			if c == '$' {
+				if j == i+1 {
+					return nil, &SyntaxError{s, i, "missing name of variable after '$'"}
+				}
+				tokens = append(tokens, token{tokenVariable, i, s[i+1 : j]})
+			} else {
+				tokens = append(tokens, token{tokenIdent, i, s[i:j]})
+			}
+			i = j
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, &SyntaxError{s, i, "unterminated string"}
+			}
+			tokens = append(tokens, token{tokenString, i, b.String()})
+			i = j + 1
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &SyntaxError{s, i, fmt.Sprintf("unexpected character '%c'", c)}
+			}
+			tokens = append(tokens, token{tokenOperator, i, op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokenEOF, len(s), ""}), nil
+}
+
+type parser struct {
+	expression string
+	tokens     []token
+	i          int
+}
+
+// Parse returns the syntax tree of the expression.
+func Parse(expression string) (Node, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{expression: expression, tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, p.errorf(p.peek(), "empty expression")
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, a ...interface{}) error {
+	return &SyntaxError{p.expression, t.pos, fmt.Sprintf(format, a...)}
+}
+
+// accept consumes the next token and returns the normalized operator if it's one of the given operators or keywords.
+func (p *parser) accept(ops ...string) (token, string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator && t.kind != tokenIdent {
+		return t, "", false
+	}
+	for _, op := range ops {
+		if t.value == op {
+			p.next()
+			switch op {
+			case "and":
+				return t, "&&", true
+			case "or":
+				return t, "||", true
+			case "not":
+				return t, "!", true
+			}
+			return t, op, true
+		}
+	}
+	return t, "", false
+}
+
+func (p *parser) parseOr() (Node, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, op, ok := p.accept("||", "or")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{t.pos, op, x, y}
+	}
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, op, ok := p.accept("&&", "and")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{t.pos, op, x, y}
+	}
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if t, op, ok := p.accept("!", "not"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Unary{t.pos, op, x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	x, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	t, op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if next, _, ok := p.accept("==", "!=", "<=", ">=", "<", ">"); ok {
+		return nil, p.errorf(next, "comparisons can't be chained, use parentheses")
+	}
+	return Binary{t.pos, op, x, y}, nil
+}
+
+func (p *parser) parseAdditive() (Node, error) {
+	x, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, op, ok := p.accept("+", "-")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{t.pos, op, x, y}
+	}
+}
+
+func (p *parser) parseMultiplicative() (Node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return x, nil
+		}
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = Binary{t.pos, op, x, y}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if t, op, ok := p.accept("-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Unary{t.pos, op, x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %s", t.value)
+		}
+		return Number{t.pos, v}, nil
+	case tokenString:
+		return String{t.pos, t.value}, nil
+	case tokenVariable:
+		return Variable{t.pos, t.value}, nil
+	case tokenIdent:
+		switch t.value {
+		case "true", "false":
+			return Bool{t.pos, t.value == "true"}, nil
+		case "null":
+			return Null{t.pos}, nil
+		case "and", "or", "not":
+			return nil, p.errorf(t, "unexpected %s", t)
+		}
+		path := []string{t.value}
+		for {
+			if _, _, ok := p.accept("."); !ok {
+				break
+			}
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, p.errorf(name, "expected a name after '.', got %s", name)
+			}
+			path = append(path, name.value)
+		}
+		return Identifier{t.pos, path}, nil
+	case tokenOperator:
+		if t.value == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if end := p.next(); end.kind != tokenOperator || end.value != ")" {
+				return nil, p.errorf(end, "expected ')', got %s", end)
+			}
+			return x, nil
+		}
+	}
+	return nil, p.errorf(t, "unexpected %s", t)
+}
@@ -0,0 +1,170 @@
+package expression
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"options.dpr >= 2", "(options.dpr >= 2)"},
+		{"$finalWidth > 100 && request.format == 'webp'", `(($finalWidth > 100) && (request.format == "webp"))`},
+		{"not (options.autoformat or $a) and $b", "(!(options.autoformat || $a) && $b)"},
+		{"options.jpg.quality != null", "(options.jpg.quality != null)"},
+		{"$w * 2 + 1 > -3.5", "((($w * 2) + 1) > -3.5)"},
+		{`"it's" == 'it\'s'`, `("it's" == "it's")`},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Expected %s to be valid, got %s", tt.input, err)
+			continue
+		}
+		if n.String() != tt.expected {
+			t.Errorf("Expected %s to be parsed as %s, got %s", tt.input, tt.expected, n)
+		}
+	}
+}
+
+func TestParse_Positions(t *testing.T) {
+	n, err := Parse("options.dpr >= 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Binary{12, ">=", Identifier{0, []string{"options", "dpr"}}, Number{15, 2}}
+	if !reflect.DeepEqual(n, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, n)
+	}
+}
+
+func TestParse_SyntaxError(t *testing.T) {
+	tests := []struct {
+		input   string
+		pos     int
+		message string
+	}{
+		{"", 0, "empty expression"},
+		{"options.dpr >= ", 15, "unexpected end of expression"},
+		{"options.dpr => 2", 12, "unexpected character '='"},
+		{"(options.dpr > 2", 16, "expected ')', got end of expression"},
+		{"options. > 2", 9, "expected a name after '.', got '>'"},
+		{"$ > 2", 0, "missing name of variable after '$'"},
+		{"'abc", 0, "unterminated string"},
+		{"1 < $a < 3", 7, "comparisons can't be chained, use parentheses"},
+		{"$a = 2", 3, "unexpected character '='"},
+		{"$a 2", 3, "unexpected '2'"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Expected %q to return a *SyntaxError, got %v", tt.input, err)
+			continue
+		}
+		if serr.Pos != tt.pos || serr.Message != tt.message {
+			t.Errorf("Expected %q to fail at %d with '%s', got %d with '%s'", tt.input, tt.pos, tt.message, serr.Pos, serr.Message)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	r := Request{
+		DPR:        2,
+		FinalWidth: 300,
+		Format:     "webp",
+		Options:    rokka.StackOptions{"jpg.quality": 80, "autoformat": true},
+		Variables:  rokka.StackVariables{"text": "Hello", "size": 3},
+	}
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"options.dpr >= 2", true},
+		{"options.dpr > 2", false},
+		{"$finalWidth > 100 && request.format == 'webp'", true},
+		{"$finalHeight == 0", true},
+		{"options.jpg.quality < 90 and options.autoformat", true},
+		{"options.webp.quality == null", true},
+		{"$text == 'Hello' || $undefined > 1", true},
+		{"$size * 100 == $finalWidth", true},
+		{"$size % 2 == 1 && !(request.format == 'jpg')", true},
+		{"'abc' < 'abd'", true},
+	}
+	for _, tt := range tests {
+		res, err := Evaluate(tt.input, r)
+		if err != nil {
+			t.Errorf("Expected %s to be valid, got %s", tt.input, err)
+			continue
+		}
+		if res != tt.expected {
+			t.Errorf("Expected %s to be %t, got %t", tt.input, tt.expected, res)
+		}
+	}
+
+	if res, _ := Evaluate("options.dpr == 1", Request{}); !res {
+		t.Error("Expected options.dpr to default to 1")
+	}
+}
+
+func TestEvaluate_Error(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{"$undefined > 1", 0},
+		{"request.width > 1", 0},
+		{"$text > 1", 6},
+		{"options.dpr + 1", 12},
+		{"$text && true", 6},
+		{"1 / 0 > 1", 2},
+	}
+	for _, tt := range tests {
+		_, err := Evaluate(tt.input, Request{Variables: rokka.StackVariables{"text": "Hello"}})
+		eerr, ok := err.(*EvalError)
+		if !ok {
+			t.Errorf("Expected %q to return an *EvalError, got %v", tt.input, err)
+			continue
+		}
+		if eerr.Pos != tt.pos {
+			t.Errorf("Expected %q to fail at %d, got %d: %s", tt.input, tt.pos, eerr.Pos, eerr)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	expressions := []rokka.Expression{
+		{Expression: "options.dpr >= 2", Overrides: map[string]interface{}{
+			"options": map[string]interface{}{"jpg.quality": 60},
+		}},
+		{Expression: "options.dpr >= 3", Overrides: map[string]interface{}{
+			"options": map[string]interface{}{"jpg.quality": 40},
+		}},
+		{Expression: "$finalWidth >", Overrides: map[string]interface{}{}},
+		{Expression: "request.format == 'jpg'", Overrides: map[string]interface{}{
+			"variables": map[string]interface{}{"text": "JPEG"},
+		}},
+	}
+	res := Explain(expressions, Request{DPR: 2, Format: "jpg", Options: rokka.StackOptions{"jpg.quality": 80}})
+
+	applies := make([]bool, len(res.Results))
+	for i, r := range res.Results {
+		applies[i] = r.Applies
+	}
+	if !reflect.DeepEqual(applies, []bool{true, false, false, true}) {
+		t.Errorf("Unexpected expressions applied: %v", applies)
+	}
+	if errs := res.Errors(); len(errs) != 1 {
+		t.Errorf("Expected 1 invalid expression, got %v", errs)
+	}
+	if !reflect.DeepEqual(res.Options, rokka.StackOptions{"jpg.quality": 60}) {
+		t.Errorf("Unexpected options %v", res.Options)
+	}
+	if !reflect.DeepEqual(res.Variables, rokka.StackVariables{"text": "JPEG"}) {
+		t.Errorf("Unexpected variables %v", res.Variables)
+	}
+}
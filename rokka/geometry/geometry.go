@@ -0,0 +1,445 @@
+// Package geometry calculates the dimensions of the image a stack renders without rendering it.
+//
+// Simulate walks the operations starting from the size of the source image and returns the size after each
+// operation:
+//
+//    res, err := geometry.SimulateStack(sourceImage, stack)
+//    fmt.Println(res.Size.Width, res.Size.Height)
+//
+// The dimensions produced by some operations depend on the content of the image, e.g. trim removes the border of
+// an image. Such steps are marked as approximate and assume the size is unchanged.
+package geometry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+var errInvalidSource = errors.New("geometry: width and height of the source image must be positive")
+
+// Size is the width and height of an image in pixels.
+type Size struct {
+	Width  int
+	Height int
+}
+
+func (s Size) String() string {
+	return fmt.Sprintf("%dx%d", s.Width, s.Height)
+}
+
+// Point is the offset of a cropped area from the top left corner of the image.
+type Point struct {
+	X int
+	Y int
+}
+
+// Step describes how a single operation changes the size of the image.
+type Step struct {
+	// Index is the position of the operation within the stack.
+	Index     int
+	Operation string
+	Input     Size
+	Output    Size
+	// Offset is the top left corner of the area kept by crop. It's nil if the area depends on the content of the
+	// image, e.g. for the anchor smart.
+	Offset *Point `json:",omitempty"`
+	// Approximate is set if the output depends on the content of the image and can only be estimated.
+	Approximate bool
+	// Note explains the calculation, e.g. why a step was skipped.
+	Note string `json:",omitempty"`
+}
+
+// Result lists the steps of a simulation and the final size.
+type Result struct {
+	Source Size
+	Steps  []Step
+	Size   Size
+	// Approximate is set if any step is approximate.
+	Approximate bool
+}
+
+// Options are the stack options affecting the geometry.
+type Options struct {
+	// DPR multiplies the dimensions of resize, crop and composition. It defaults to 1.
+	DPR float64
+}
+
+// OptionsFromStack returns the Options of the stack options, e.g. options.dpr.
+func OptionsFromStack(options rokka.StackOptions) Options {
+	o := Options{DPR: 1}
+	switch v := options["dpr"].(type) {
+	case float64:
+		o.DPR = v
+	case int:
+		o.DPR = float64(v)
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			o.DPR = f
+		}
+	}
+	return o
+}
+
+// FromSourceImage returns the size of a source image as returned by GetSourceImage.
+func FromSourceImage(img rokka.GetSourceImageResponse) Size {
+	return Size{Width: img.Width, Height: img.Height}
+}
+
+// SimulateStack calculates the size of the image rendered with the stack.
+func SimulateStack(img rokka.GetSourceImageResponse, stack rokka.Stack) (Result, error) {
+	return Simulate(FromSourceImage(img), stack.StackOperations, OptionsFromStack(stack.StackOptions))
+}
+
+// Simulate calculates the size after each of the operations starting with the size of the source image.
+func Simulate(source Size, operations []rokka.Operation, o Options) (Result, error) {
+	if source.Width <= 0 || source.Height <= 0 {
+		return Result{}, errInvalidSource
+	}
+	if o.DPR <= 0 {
+		o.DPR = 1
+	}
+
+	res := Result{Source: source, Steps: make([]Step, 0, len(operations)), Size: source}
+	for i, op := range operations {
+		op = value(op)
+		if isNil(op) {
+			return Result{}, fmt.Errorf("geometry: operation %d is nil", i)
+		}
+		s := Step{Index: i, Operation: op.Name(), Input: res.Size, Output: res.Size}
+		if enabled(op) {
+			apply(&s, op, o)
+		} else {
+			s.Note = "disabled"
+		}
+		if s.Output.Width < 1 {
+			s.Output.Width = 1
+		}
+		if s.Output.Height < 1 {
+			s.Output.Height = 1
+		}
+		res.Steps = append(res.Steps, s)
+		res.Size = s.Output
+		res.Approximate = res.Approximate || s.Approximate
+	}
+	return res, nil
+}
+
+// value dereferences pointers to the operations affecting the geometry, as returned when decoding stacks. Nil
+// pointers result in nil.
+func value(op rokka.Operation) rokka.Operation {
+	switch o := op.(type) {
+	case *rokka.ResizeOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.CropOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.RotateOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.AutorotateOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.TrimOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.CompositionOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.DropshadowOperation:
+		if o != nil {
+			return *o
+		}
+	case *rokka.RawOperation:
+		if o != nil {
+			return *o
+		}
+	default:
+		return op
+	}
+	return nil
+}
+
+// isNil returns true for nil and nil pointers.
+func isNil(op rokka.Operation) bool {
+	if op == nil {
+		return true
+	}
+	v := reflect.ValueOf(op)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// enabled returns false if the enabled option of the operation is false.
+func enabled(op rokka.Operation) bool {
+	var e *bool
+	switch op := op.(type) {
+	case rokka.ResizeOperation:
+		e = op.Enabled
+	case rokka.CropOperation:
+		e = op.Enabled
+	case rokka.RotateOperation:
+		e = op.Enabled
+	case rokka.AutorotateOperation:
+		e = op.Enabled
+	case rokka.TrimOperation:
+		e = op.Enabled
+	case rokka.CompositionOperation:
+		e = op.Enabled
+	case rokka.DropshadowOperation:
+		e = op.Enabled
+	}
+	return e == nil || *e
+}
+
+// apply sets the output of the step.
+func apply(s *Step, op rokka.Operation, o Options) {
+	switch op := op.(type) {
+	case rokka.ResizeOperation:
+		resize(s, op, o)
+	case rokka.CropOperation:
+		crop(s, op, o)
+	case rokka.RotateOperation:
+		angle := 0.0
+		if op.Angle != nil {
+			angle = *op.Angle
+		}
+		s.Output = rotate(s.Input, angle)
+	case rokka.AutorotateOperation:
+		autorotate(s, op)
+	case rokka.TrimOperation:
+		s.Approximate = true
+		s.Note = "depends on the border of the image, assuming nothing is trimmed"
+	case rokka.CompositionOperation:
+		composition(s, op, o)
+	case rokka.DropshadowOperation:
+		dropshadow(s, op)
+	case rokka.RawOperation:
+		s.Approximate = true
+		s.Note = "unknown operation, assuming the size is unchanged"
+	}
+}
+
+func round(x float64) int {
+	return int(math.Floor(x + 0.5))
+}
+
+func scale(v *int, dpr float64) int {
+	if v == nil {
+		return 0
+	}
+	return round(float64(*v) * dpr)
+}
+
+func resize(s *Step, op rokka.ResizeOperation, o Options) {
+	in := s.Input
+	w, h := scale(op.Width, o.DPR), scale(op.Height, o.DPR)
+	if w == 0 && h == 0 {
+		s.Note = "neither width nor height set"
+		return
+	}
+	mode := "box"
+	if op.Mode != nil {
+		mode = *op.Mode
+	}
+
+	var out Size
+	switch {
+	case mode == "absolute" && w != 0 && h != 0:
+		out = Size{w, h}
+	case w == 0:
+		out = Size{round(float64(in.Width) * float64(h) / float64(in.Height)), h}
+	case h == 0:
+		out = Size{w, round(float64(in.Height) * float64(w) / float64(in.Width))}
+	default:
+		fx, fy := float64(w)/float64(in.Width), float64(h)/float64(in.Height)
+		f := math.Min(fx, fy)
+		if mode == "fill" {
+			f = math.Max(fx, fy)
+		}
+		out = Size{round(float64(in.Width) * f), round(float64(in.Height) * f)}
+		// the requested dimension is used exactly to avoid rounding errors.
+		if f == fx {
+			out.Width = w
+		} else {
+			out.Height = h
+		}
+	}
+
+	// without upscaling, the image isn't resized if it would get bigger, except because of the dpr if upscale_dpr
+	// is enabled (the default).
+	if op.Upscale != nil && !*op.Upscale && (out.Width > in.Width || out.Height > in.Height) {
+		limit := 1.0
+		if o.DPR > 1 && (op.UpscaleDpr == nil || *op.UpscaleDpr) {
+			limit = o.DPR
+		}
+		f := math.Min(float64(out.Width)/float64(in.Width), float64(out.Height)/float64(in.Height))
+		if mode == "absolute" {
+			f = math.Max(float64(out.Width)/float64(in.Width), float64(out.Height)/float64(in.Height))
+		}
+		if f > limit {
+			out = Size{round(float64(out.Width) * limit / f), round(float64(out.Height) * limit / f)}
+			s.Note = "upscaling disabled"
+		}
+	}
+	s.Output = out
+}
+
+func crop(s *Step, op rokka.CropOperation, o Options) {
+	in := s.Input
+	if op.Width == nil || op.Height == nil {
+		s.Note = "width and height are required"
+		return
+	}
+
+	var out Size
+	if op.Mode != nil && *op.Mode == "ratio" {
+		// the largest area with the aspect ratio of width and height, optionally scaled down in percent.
+		ratio := float64(*op.Width) / float64(*op.Height)
+		out = Size{in.Width, round(float64(in.Width) / ratio)}
+		if out.Height > in.Height {
+			out = Size{round(float64(in.Height) * ratio), in.Height}
+		}
+		if op.Scale != nil && *op.Scale > 0 && *op.Scale < 100 {
+			out = Size{round(float64(out.Width) * *op.Scale / 100), round(float64(out.Height) * *op.Scale / 100)}
+		}
+	} else {
+		out = Size{scale(op.Width, o.DPR), scale(op.Height, o.DPR)}
+		if out.Width > in.Width || out.Height > in.Height {
+			s.Note = "crop area larger than the image"
+		}
+		if out.Width > in.Width {
+			out.Width = in.Width
+		}
+		if out.Height > in.Height {
+			out.Height = in.Height
+		}
+	}
+	s.Output = out
+
+	anchor := "auto"
+	if op.Anchor != nil {
+		anchor = *op.Anchor
+	}
+	if op.Area != nil {
+		s.Note = fmt.Sprintf("position depends on the subject area %s", *op.Area)
+		return
+	}
+	offset, ok := anchorOffset(anchor, in, out)
+	if !ok {
+		s.Note = fmt.Sprintf("position depends on the content of the image (anchor %s)", anchor)
+		return
+	}
+	s.Offset = &offset
+}
+
+// anchorOffset returns the top left corner of an area of size out within in for an anchor like center_top or
+// 100-50. Anchors depending on the image content, e.g. smart or face, return false.
+func anchorOffset(anchor string, in, out Size) (Point, bool) {
+	parts := strings.FieldsFunc(anchor, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) != 2 {
+		return Point{}, false
+	}
+	x, ok := anchorPosition(parts[0], in.Width-out.Width, "left", "right")
+	if !ok {
+		return Point{}, false
+	}
+	y, ok := anchorPosition(parts[1], in.Height-out.Height, "top", "bottom")
+	if !ok {
+		return Point{}, false
+	}
+	return Point{x, y}, true
+}
+
+func anchorPosition(anchor string, free int, start, end string) (int, bool) {
+	switch anchor {
+	case start:
+		return 0, true
+	case "center":
+		return free / 2, true
+	case end:
+		return free, true
+	}
+	n, err := strconv.Atoi(anchor)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	if n > free {
+		n = free
+	}
+	return n, true
+}
+
+// rotate returns the bounding box of the rotated image.
+func rotate(in Size, angle float64) Size {
+	angle = math.Mod(angle, 360)
+	switch angle {
+	case 0, 180:
+		return in
+	case 90, 270:
+		return Size{in.Height, in.Width}
+	}
+	rad := angle * math.Pi / 180
+	sin, cos := math.Abs(math.Sin(rad)), math.Abs(math.Cos(rad))
+	return Size{
+		round(float64(in.Width)*cos + float64(in.Height)*sin),
+		round(float64(in.Width)*sin + float64(in.Height)*cos),
+	}
+}
+
+// autorotate rotates the image by 90 degrees if its orientation differs from the one of width and height.
+func autorotate(s *Step, op rokka.AutorotateOperation) {
+	if op.Width == nil || op.Height == nil || *op.Width == *op.Height {
+		s.Note = "no orientation given"
+		return
+	}
+	landscape := *op.Width > *op.Height
+	if landscape == (s.Input.Width > s.Input.Height) || s.Input.Width == s.Input.Height {
+		return
+	}
+	s.Output = Size{s.Input.Height, s.Input.Width}
+}
+
+func composition(s *Step, op rokka.CompositionOperation, o Options) {
+	if op.ResizeToPrimary != nil && *op.ResizeToPrimary {
+		return
+	}
+	if op.Width == nil || op.Height == nil {
+		s.Note = "width and height not set, assuming the size of the image"
+		return
+	}
+	s.Output = Size{scale(op.Width, o.DPR), scale(op.Height, o.DPR)}
+}
+
+// dropshadow extends the canvas by the offset and the blur of the shadow.
+func dropshadow(s *Step, op rokka.DropshadowOperation) {
+	blur := 0.0
+	if op.BlurRadius != nil {
+		blur = *op.BlurRadius
+	}
+	extend := func(offset *int) int {
+		e := 2 * int(math.Ceil(blur))
+		if offset != nil {
+			if *offset < 0 {
+				e -= *offset
+			} else {
+				e += *offset
+			}
+		}
+		return e
+	}
+	s.Output = Size{s.Input.Width + extend(op.Horizontal), s.Input.Height + extend(op.Vertical)}
+	s.Approximate = true
+	s.Note = "the margin of the shadow is estimated"
+}
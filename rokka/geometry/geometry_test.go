@@ -0,0 +1,113 @@
+package geometry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+func TestSimulate(t *testing.T) {
+	source := Size{1000, 500}
+
+	table := []struct {
+		name       string
+		operations []rokka.Operation
+		options    Options
+		expected   Size
+	}{
+		{"no operations", nil, Options{}, Size{1000, 500}},
+		{"resize width", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(200)}}, Options{}, Size{200, 100}},
+		{"resize height", []rokka.Operation{rokka.ResizeOperation{Height: rokka.IntPtr(100)}}, Options{}, Size{200, 100}},
+		{"resize box", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(300), Height: rokka.IntPtr(300)}}, Options{}, Size{300, 150}},
+		{"resize fill", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(300), Height: rokka.IntPtr(300), Mode: rokka.StrPtr("fill")}}, Options{}, Size{600, 300}},
+		{"resize absolute", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(300), Height: rokka.IntPtr(300), Mode: rokka.StrPtr("absolute")}}, Options{}, Size{300, 300}},
+		{"resize upscale", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(2000)}}, Options{}, Size{2000, 1000}},
+		{"resize without upscale", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(2000), Upscale: rokka.BoolPtr(false)}}, Options{}, Size{1000, 500}},
+		{"resize dpr", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(300)}}, Options{DPR: 2}, Size{600, 300}},
+		{"resize dpr without upscale", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(800), Upscale: rokka.BoolPtr(false)}}, Options{DPR: 2}, Size{1600, 800}},
+		{"resize dpr without upscale_dpr", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(800), Upscale: rokka.BoolPtr(false), UpscaleDpr: rokka.BoolPtr(false)}}, Options{DPR: 2}, Size{1000, 500}},
+		{"resize disabled", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(300), Enabled: rokka.BoolPtr(false)}}, Options{}, Size{1000, 500}},
+		{"crop", []rokka.Operation{rokka.CropOperation{Width: rokka.IntPtr(200), Height: rokka.IntPtr(800)}}, Options{}, Size{200, 500}},
+		{"crop ratio", []rokka.Operation{rokka.CropOperation{Width: rokka.IntPtr(1), Height: rokka.IntPtr(1), Mode: rokka.StrPtr("ratio")}}, Options{}, Size{500, 500}},
+		{"crop ratio scaled", []rokka.Operation{rokka.CropOperation{Width: rokka.IntPtr(4), Height: rokka.IntPtr(1), Mode: rokka.StrPtr("ratio"), Scale: rokka.Float64Ptr(50)}}, Options{}, Size{500, 125}},
+		{"rotate 90", []rokka.Operation{rokka.RotateOperation{Angle: rokka.Float64Ptr(90)}}, Options{}, Size{500, 1000}},
+		{"rotate 45", []rokka.Operation{rokka.RotateOperation{Angle: rokka.Float64Ptr(45)}}, Options{}, Size{1061, 1061}},
+		{"autorotate", []rokka.Operation{rokka.AutorotateOperation{Width: rokka.IntPtr(3), Height: rokka.IntPtr(4)}}, Options{}, Size{500, 1000}},
+		{"composition", []rokka.Operation{rokka.CompositionOperation{Width: rokka.IntPtr(1200), Height: rokka.IntPtr(1200)}}, Options{}, Size{1200, 1200}},
+		{"composition resize_to_primary", []rokka.Operation{rokka.CompositionOperation{Width: rokka.IntPtr(1200), Height: rokka.IntPtr(1200), ResizeToPrimary: rokka.BoolPtr(true)}}, Options{}, Size{1000, 500}},
+		{"trim", []rokka.Operation{rokka.TrimOperation{}}, Options{}, Size{1000, 500}},
+		{"pointers", []rokka.Operation{&rokka.ResizeOperation{Width: rokka.IntPtr(500)}, &rokka.CropOperation{Width: rokka.IntPtr(100), Height: rokka.IntPtr(100)}}, Options{}, Size{100, 100}},
+		{"other operations", []rokka.Operation{rokka.GrayscaleOperation{}, &rokka.BlurOperation{}}, Options{}, Size{1000, 500}},
+	}
+
+	for _, v := range table {
+		res, err := Simulate(source, v.operations, v.options)
+		if err != nil {
+			t.Errorf("%s: %s", v.name, err)
+			continue
+		}
+		if res.Size != v.expected {
+			t.Errorf("%s: expected %s, got %s", v.name, v.expected, res.Size)
+		}
+		if len(res.Steps) != len(v.operations) {
+			t.Errorf("%s: expected %d steps, got %d", v.name, len(v.operations), len(res.Steps))
+		}
+	}
+}
+
+func TestSimulate_Steps(t *testing.T) {
+	ops := []rokka.Operation{
+		rokka.ResizeOperation{Width: rokka.IntPtr(400)},
+		rokka.CropOperation{Width: rokka.IntPtr(200), Height: rokka.IntPtr(100), Anchor: rokka.StrPtr("right-center")},
+		rokka.TrimOperation{},
+		rokka.CropOperation{Width: rokka.IntPtr(100), Height: rokka.IntPtr(100), Anchor: rokka.StrPtr("smart")},
+	}
+	res, err := Simulate(Size{1000, 1000}, ops, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Step{
+		{Index: 0, Operation: "resize", Input: Size{1000, 1000}, Output: Size{400, 400}},
+		{Index: 1, Operation: "crop", Input: Size{400, 400}, Output: Size{200, 100}, Offset: &Point{200, 150}},
+		{Index: 2, Operation: "trim", Input: Size{200, 100}, Output: Size{200, 100}, Approximate: true, Note: "depends on the border of the image, assuming nothing is trimmed"},
+		{Index: 3, Operation: "crop", Input: Size{200, 100}, Output: Size{100, 100}, Note: "position depends on the content of the image (anchor smart)"},
+	}
+	if !reflect.DeepEqual(res.Steps, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, res.Steps)
+	}
+	if !res.Approximate {
+		t.Error("Expected the result to be approximate")
+	}
+}
+
+func TestSimulate_Error(t *testing.T) {
+	if _, err := Simulate(Size{}, nil, Options{}); err == nil {
+		t.Error("Expected an error for a source without size")
+	}
+	var op *rokka.ResizeOperation
+	if _, err := Simulate(Size{100, 100}, []rokka.Operation{op}, Options{}); err == nil {
+		t.Error("Expected an error for a nil operation")
+	}
+	// pointers of operations without a geometry are passed through by value.
+	var grayscale *rokka.GrayscaleOperation
+	if _, err := Simulate(Size{100, 100}, []rokka.Operation{grayscale}, Options{}); err == nil {
+		t.Error("Expected an error for a nil grayscale operation")
+	}
+}
+
+func TestSimulateStack(t *testing.T) {
+	img := rokka.GetSourceImageResponse{Width: 1024, Height: 768}
+	stack := rokka.Stack{
+		StackOperations: rokka.Operations{&rokka.ResizeOperation{Width: rokka.IntPtr(256)}},
+		StackOptions:    rokka.StackOptions{"dpr": float64(2)},
+	}
+	res, err := SimulateStack(img, stack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Size{512, 384}); res.Size != expected {
+		t.Errorf("Expected %s, got %s", expected, res.Size)
+	}
+}
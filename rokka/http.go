@@ -1,33 +1,125 @@
 package rokka
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"time"
 )
 
-// RetryingHTTPClient implements HTTPRequester and wraps another HTTPRequester.
-type RetryingHTTPClient struct {
-	c          HTTPRequester
-	maxRetries int
-	maxDelay   int
+// RetryPolicy defines in which cases and how often RetryingHTTPClient retries a request.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of attempts done for a request. At least one attempt is always done.
+	MaxRetries int
+	// MaxDelay caps the delay between two attempts, including delays requested using a Retry-After header.
+	// Defaults to the MaxDelay of DefaultRetryPolicy if not set.
+	MaxDelay time.Duration
+	// BackoffBase is the base of the exponential backoff. The delay before the n-th retry is `(BackoffBase^n - 1)` seconds.
+	// Defaults to 1.2 if not set.
+	BackoffBase float64
+	// Jitter randomly shortens each delay by up to the given fraction (between 0 and 1) to avoid
+	// many clients retrying at the same time.
+	Jitter float64
+	// Budget is the overall time allowed to be spent on a request including all retries. No retry is done
+	// if the next attempt would start after the budget has been used up. Zero means no limit.
+	Budget time.Duration
+	// RetryableStatusCodes contains the status codes of responses which are retried. Defaults to the status codes of
+	// DefaultRetryPolicy if nil, an empty slice retries no status code at all.
+	RetryableStatusCodes []int
+	// RetryMethod decides whether requests with the given HTTP method may be retried.
+	// If nil, requests of all methods are retried. Use IdempotentMethod to exclude e.g. POST requests.
+	RetryMethod func(method string) bool
+	// OnRetry is called before waiting for the next attempt, e.g. for logging or collecting metrics.
+	OnRetry func(info RetryInfo)
 }
 
-// NewRetryingHTTPClient wraps an HTTPRequester in order to do automatic retries.
+// RetryInfo describes a failed attempt which is going to be retried.
+type RetryInfo struct {
+	// Attempt is the number of the failed attempt, starting at 1.
+	Attempt  int
+	Request  *http.Request
+	Response *http.Response
+	Err      error
+	// Delay is the time waited before the next attempt is started.
+	Delay time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used by NewRetryingHTTPClient.
 //
-// These are done in the following cases:
+// Retries are done in the following cases:
 //  - a HTTP, Network or Transport error occurred
 //  - a status code of 429 (too many requests), 502 (bad gateway), 503 (service unavailable), or 504 (gateway timeout) has been received.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:  10,
+		MaxDelay:    6 * time.Second,
+		BackoffBase: 1.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// IdempotentMethod can be used as RetryPolicy.RetryMethod to only retry requests with an idempotent HTTP method.
+func IdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// RetryingHTTPClient implements HTTPRequester and wraps another HTTPRequester.
+type RetryingHTTPClient struct {
+	c      HTTPRequester
+	policy RetryPolicy
+}
+
+// NewRetryingHTTPClient wraps an HTTPRequester in order to do automatic retries using the DefaultRetryPolicy
+// with maxRetries attempts and a maximum delay of maxDelay milliseconds.
 func NewRetryingHTTPClient(c HTTPRequester, maxRetries int, maxDelay int) *RetryingHTTPClient {
+	p := DefaultRetryPolicy()
+	p.MaxRetries = maxRetries
+	p.MaxDelay = time.Duration(maxDelay) * time.Millisecond
+	return NewRetryingHTTPClientWithPolicy(c, p)
+}
+
+// NewRetryingHTTPClientWithPolicy wraps an HTTPRequester in order to do automatic retries as defined by the policy.
+// Fields which aren't set are taken from DefaultRetryPolicy.
+func NewRetryingHTTPClientWithPolicy(c HTTPRequester, p RetryPolicy) *RetryingHTTPClient {
+	d := DefaultRetryPolicy()
+	if p.BackoffBase == 0 {
+		p.BackoffBase = d.BackoffBase
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = d.MaxDelay
+	}
+	if p.RetryableStatusCodes == nil {
+		p.RetryableStatusCodes = d.RetryableStatusCodes
+	}
+	if p.MaxRetries < 1 {
+		p.MaxRetries = 1
+	}
 	return &RetryingHTTPClient{
-		c:          c,
-		maxRetries: maxRetries,
-		maxDelay:   maxDelay,
+		c:      c,
+		policy: p,
 	}
 }
 
+// Policy returns the retry policy in use.
+func (hc *RetryingHTTPClient) Policy() RetryPolicy {
+	return hc.policy
+}
+
 // ErrMaxRetriesReached is returned if after maxRetries number of retries the request still fails.
 type ErrMaxRetriesReached struct {
 	LastError error
@@ -37,49 +129,175 @@ func (e ErrMaxRetriesReached) Error() string {
 	return fmt.Sprintf("rokka: max retries reached. Last error: %s", e.LastError)
 }
 
-// Do executes an HTTP request and retries in case the response status code is one 429, 502, 503, 504 or if error is set.
+// Unwrap returns LastError.
+func (e ErrMaxRetriesReached) Unwrap() error {
+	return e.LastError
+}
+
+// Do executes an HTTP request and retries it as defined by the RetryPolicy.
+// The request body is rebuilt for every attempt, either by using req.GetBody, by seeking it back to the start or by
+// buffering it in memory. Bodies larger than maxBufferedBodySize which can't be rebuilt otherwise are sent only once.
+// If the context of req is done, either during the request or while waiting for the next retry, Do stops immediately
+// and returns the context's error.
 func (hc *RetryingHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	var resp *http.Response
 	var err error
 
-	for i := 0; i < hc.maxRetries; i++ {
-		resp, err = hc.c.Do(req)
+	r, replayable, closeBody, err := newReplayableRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody()
+
+	p := hc.policy
+	ctx := req.Context()
+	start := time.Now()
+	for i := 0; i < p.MaxRetries; i++ {
+		attempt := r
+		if i > 0 && r.GetBody != nil {
+			attempt = r.WithContext(ctx)
+			if attempt.Body, err = r.GetBody(); err != nil {
+				return nil, err
+			}
+		}
 
-		if !shouldRetry(resp, err) {
+		resp, err = hc.c.Do(attempt)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			drainBody(resp)
+			return nil, ctxErr
+		}
+
+		if !replayable || !p.shouldRetry(req, resp, err) {
 			return resp, err
 		}
 
-		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
-			retryAfter := retryAfterSeconds(resp.Header.Get("Retry-After"), float64(hc.maxDelay))
-			if retryAfter != 0.0 {
-				time.Sleep(time.Duration(retryAfter) * time.Second)
-				continue
-			}
+		delay := p.delay(i, resp)
+		if i == p.MaxRetries-1 || (p.Budget > 0 && time.Since(start)+delay > p.Budget) {
+			break
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(RetryInfo{Attempt: i + 1, Request: req, Response: resp, Err: err, Delay: delay})
+		}
+		drainBody(resp)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
 		}
-		delay := calculateBackoff(i, hc.maxDelay)
-		time.Sleep(time.Duration(delay) * time.Millisecond)
 	}
 
 	return resp, ErrMaxRetriesReached{LastError: err}
 }
 
+// maxBufferedBodySize is the maximum size of a request body buffered in memory in order to replay it on retries.
+const maxBufferedBodySize = 1 << 20
+
+// newReplayableRequest returns a request whose body can be rebuilt for every attempt using GetBody.
+// In case req.GetBody is already set or there is no body, req is returned unchanged.
+// If the body is an io.Seeker it is seeked back for every attempt, otherwise it gets buffered in memory.
+// In case the body is too large to be buffered, replayable is false and the request must be sent only once.
+// The returned closeBody function closes the original body if it's not going to be closed by the transport.
+func newReplayableRequest(req *http.Request) (r *http.Request, replayable bool, closeBody func(), err error) {
+	closeBody = func() {}
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return req, true, closeBody, nil
+	}
+
+	r = req.WithContext(req.Context())
+	if s, ok := req.Body.(io.Seeker); ok {
+		if offset, err := s.Seek(0, io.SeekCurrent); err == nil {
+			body := req.Body
+			// prevent the transport from closing the body after the first attempt.
+			r.Body = ioutil.NopCloser(body)
+			r.GetBody = func() (io.ReadCloser, error) {
+				if _, err := s.Seek(offset, io.SeekStart); err != nil {
+					return nil, err
+				}
+				return ioutil.NopCloser(body), nil
+			}
+			return r, true, func() { body.Close() }, nil
+		}
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(req.Body, maxBufferedBodySize+1))
+	if err != nil {
+		req.Body.Close()
+		return nil, false, closeBody, err
+	}
+	if len(b) > maxBufferedBodySize {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(b), req.Body), req.Body}
+		return r, false, closeBody, nil
+	}
+	req.Body.Close()
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	return r, true, closeBody, nil
+}
+
+// sleep pauses for the duration d or until ctx is done, whichever happens first.
+// It returns the context's error in the latter case.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// drainBody drains and closes the body of a response which is not going to be returned to the caller,
+// allowing the underlying connection to be reused.
+func drainBody(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
 // shouldRetry determines in which cases a retry is tried.
-// Retries if there was any error (http error, network error, transport error etc.).
-// Also retries on codes 429 (too many requests), 502 (bad gateway), 503 (service unavailable), 504 (gateway timeout).
-func shouldRetry(resp *http.Response, err error) bool {
+// Requests with a method not allowed by RetryMethod are never retried.
+// Otherwise it retries if there was any error (http error, network error, transport error etc.) or if the
+// status code is one of RetryableStatusCodes.
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if p.RetryMethod != nil && !p.RetryMethod(req.Method) {
+		return false
+	}
 	if err != nil {
 		return true
 	}
-	sc := resp.StatusCode
-	if sc == http.StatusTooManyRequests ||
-		sc == http.StatusBadGateway ||
-		sc == http.StatusServiceUnavailable ||
-		sc == http.StatusGatewayTimeout {
-		return true
+	for _, sc := range p.RetryableStatusCodes {
+		if resp.StatusCode == sc {
+			return true
+		}
 	}
 	return false
 }
 
+// delay calculates the time to wait after the given number of retries. A Retry-After header sent along with
+// a 429 response takes precedence over the exponential backoff.
+func (p RetryPolicy) delay(retries int, resp *http.Response) time.Duration {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := retryAfterSeconds(resp.Header.Get("Retry-After"), p.MaxDelay.Seconds())
+		if retryAfter != 0.0 {
+			return time.Duration(retryAfter * float64(time.Second))
+		}
+	}
+
+	delay := time.Duration(backoff(p.BackoffBase, retries, int(p.MaxDelay/time.Millisecond))) * time.Millisecond
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
 // retryAfterSeconds calculates the delay to wait in seconds based on the Retry-After header.
 // It takes into account both varieties of the header: number of seconds or a Date.
 // To account for possible issues with the Retry-After header, it doesn't wait longer than `max` seconds.
@@ -111,7 +329,13 @@ func retryAfterSeconds(retryAfter string, max float64) float64 {
 // Example wait times can be seen in the accompanying test.
 // If the calculated wait time exceeds max, will always return max.
 func calculateBackoff(retries int, max int) int {
-	backoffFactor := math.Pow(1.2, float64(retries)) - 1
+	return backoff(1.2, retries, max)
+}
+
+// backoff calculates an exponential backoff time in miliseconds using the given base.
+// If the calculated wait time exceeds max, will always return max.
+func backoff(base float64, retries int, max int) int {
+	backoffFactor := math.Pow(base, float64(retries)) - 1
 	delay := int(round(backoffFactor * 1000))
 	if max > delay {
 		return delay
@@ -1,8 +1,16 @@
 package rokka
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -36,8 +44,12 @@ func TestRetry_TooManyRequests(t *testing.T) {
 	if !ok {
 		t.Fatalf("Expected error of type '%T', got '%T'", ErrMaxRetriesReached{}, err)
 	}
-	if maxRetriesErr.LastError != nil {
-		t.Errorf("Expected no LastError, got '%v'", maxRetriesErr.LastError)
+	sErr, ok := maxRetriesErr.LastError.(StatusCodeError)
+	if !ok || sErr.Code != 429 {
+		t.Errorf("Expected LastError to be a StatusCodeError with code 429, got '%v'", maxRetriesErr.LastError)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected error to be '%v', got '%v'", ErrRateLimited, err)
 	}
 }
 
@@ -66,8 +78,12 @@ func TestRetry_BadGateway(t *testing.T) {
 	if !ok {
 		t.Fatalf("Expected error of type '%T', got '%T'", ErrMaxRetriesReached{}, err)
 	}
-	if maxRetriesErr.LastError != nil {
-		t.Errorf("Expected no LastError, got '%v'", maxRetriesErr.LastError)
+	sErr, ok := maxRetriesErr.LastError.(StatusCodeError)
+	if !ok || sErr.Code != 502 {
+		t.Errorf("Expected LastError to be a StatusCodeError with code 502, got '%v'", maxRetriesErr.LastError)
+	}
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("Expected error to be '%v', got '%v'", ErrServer, err)
 	}
 }
 
@@ -99,11 +115,317 @@ func TestRetry_NoConnection(t *testing.T) {
 	if maxRetriesErr.LastError == nil {
 		t.Fatalf("Expected LastError, got nil")
 	}
-	if !strings.Contains(maxRetriesErr.LastError.Error(), "Get http://localhost:0/:") {
+	if !strings.Contains(maxRetriesErr.LastError.Error(), "http://localhost:0/") {
 		t.Errorf("Expected LastError, got: '%s'", maxRetriesErr.LastError.Error())
 	}
 }
 
+func TestRetry_ContextCanceledDuringBackoff(t *testing.T) {
+	r := test.NewResponse(http.StatusServiceUnavailable, "")
+	ts := test.NewMockAPI(t, test.Routes{"GET /": r})
+	defer ts.Close()
+
+	c := NewClient(&Config{
+		APIAddress:         ts.URL,
+		APIKey:             "test",
+		RetryingHTTPClient: NewRetryingHTTPClient(DefaultConfig().HTTPClient, 10, 10000),
+	})
+	retryingClient := c.AutoRetry()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, err := retryingClient.NewRequestWithContext(ctx, http.MethodGet, "/", nil, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	start := time.Now()
+	err = retryingClient.Call(req, nil, nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected error '%v', got '%v'", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected backoff to be aborted by the context, took %s", elapsed)
+	}
+}
+
+func TestRetry_Policy(t *testing.T) {
+	table := []struct {
+		name             string
+		method           string
+		policy           RetryPolicy
+		expectedAttempts int
+		expectedRetries  int
+	}{
+		{"default policy", http.MethodPost, RetryPolicy{MaxRetries: 3, RetryableStatusCodes: []int{503}}, 3, 2},
+		{"non idempotent method", http.MethodPost, RetryPolicy{MaxRetries: 3, RetryableStatusCodes: []int{503}, RetryMethod: IdempotentMethod}, 1, 0},
+		{"idempotent method", http.MethodPut, RetryPolicy{MaxRetries: 3, RetryableStatusCodes: []int{503}, RetryMethod: IdempotentMethod}, 3, 2},
+		{"status code not retryable", http.MethodPost, RetryPolicy{MaxRetries: 3, RetryableStatusCodes: []int{502}}, 1, 0},
+		{"partial policy", http.MethodPost, RetryPolicy{MaxRetries: 2}, 2, 1},
+		{"budget exceeded", http.MethodPost, RetryPolicy{MaxRetries: 10, MaxDelay: time.Second, RetryableStatusCodes: []int{503}, Budget: 150 * time.Millisecond}, 2, 1},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			attempts := 0
+			r := test.NewResponse(http.StatusServiceUnavailable, "")
+			r.Assertion = func(t *testing.T, r *http.Request) {
+				attempts++
+			}
+			ts := test.NewMockAPI(t, test.Routes{v.method + " /": r})
+			defer ts.Close()
+
+			retries := 0
+			v.policy.OnRetry = func(info RetryInfo) {
+				retries++
+				if info.Attempt != retries {
+					t.Errorf("Expected attempt %d, got %d", retries, info.Attempt)
+				}
+			}
+
+			c := NewClient(&Config{
+				APIAddress:         ts.URL,
+				RetryingHTTPClient: NewRetryingHTTPClientWithPolicy(DefaultConfig().HTTPClient, v.policy),
+			})
+			retryingClient := c.AutoRetry()
+
+			req, err := retryingClient.NewRequest(v.method, "/", nil, nil)
+			if err != nil {
+				panic(err)
+			}
+
+			err = retryingClient.Call(req, nil, nil)
+			if !errors.Is(err, ErrServer) {
+				t.Errorf("Expected error '%v', got '%v'", ErrServer, err)
+			}
+			if attempts != v.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %d", v.expectedAttempts, attempts)
+			}
+			if retries != v.expectedRetries {
+				t.Errorf("Expected OnRetry to be called %d times, got %d", v.expectedRetries, retries)
+			}
+		})
+	}
+}
+
+func TestNewRetryingHTTPClientWithPolicy_Defaults(t *testing.T) {
+	p := NewRetryingHTTPClientWithPolicy(DefaultConfig().HTTPClient, RetryPolicy{MaxRetries: 5, OnRetry: func(RetryInfo) {}}).Policy()
+	d := DefaultRetryPolicy()
+	if p.MaxRetries != 5 || p.OnRetry == nil {
+		t.Errorf("Expected the fields of the policy to be kept, got %+v", p)
+	}
+	if p.MaxDelay != d.MaxDelay || p.BackoffBase != d.BackoffBase {
+		t.Errorf("Expected MaxDelay '%s' and BackoffBase %v, got '%s' and %v", d.MaxDelay, d.BackoffBase, p.MaxDelay, p.BackoffBase)
+	}
+	if !reflect.DeepEqual(p.RetryableStatusCodes, d.RetryableStatusCodes) {
+		t.Errorf("Expected retryable status codes %v, got %v", d.RetryableStatusCodes, p.RetryableStatusCodes)
+	}
+
+	p = NewRetryingHTTPClientWithPolicy(DefaultConfig().HTTPClient, RetryPolicy{RetryableStatusCodes: []int{}}).Policy()
+	if len(p.RetryableStatusCodes) != 0 {
+		t.Errorf("Expected an empty slice to retry no status codes, got %v", p.RetryableStatusCodes)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{BackoffBase: 2, MaxDelay: 10 * time.Second}
+	if d := p.delay(2, nil); d != 3*time.Second {
+		t.Errorf("Expected delay of '%s', got '%s'", 3*time.Second, d)
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.delay(2, nil)
+		if d > 3*time.Second || d < 1500*time.Millisecond {
+			t.Fatalf("Expected delay between '%s' and '%s', got '%s'", 1500*time.Millisecond, 3*time.Second, d)
+		}
+	}
+
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"20"}}}
+	if d := p.delay(2, resp); d != 10*time.Second {
+		t.Errorf("Expected Retry-After to be capped at '%s', got '%s'", 10*time.Second, d)
+	}
+}
+
+// flakyResponse returns a response which fails with 503 for the given number of times before returning r.
+func flakyResponse(r test.Response, failures int) test.Response {
+	for i := 0; i < failures; i++ {
+		f := test.NewResponse(http.StatusServiceUnavailable, "")
+		f.Assertion = r.Assertion
+		r.Preceding = append(r.Preceding, f)
+	}
+	return r
+}
+
+func TestRetry_ReplaysBody(t *testing.T) {
+	content := "{\"test\": \"testing\"}"
+	f, err := ioutil.TempFile(os.TempDir(), "body")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		panic(err)
+	}
+
+	table := []struct {
+		name string
+		body func() io.Reader
+	}{
+		{"buffer", func() io.Reader { return bytes.NewBufferString(content) }},
+		{"file", func() io.Reader { f.Seek(0, io.SeekStart); return f }},
+		{"reader", func() io.Reader { return ioutil.NopCloser(strings.NewReader(content)) }},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			attempts := 0
+			r := test.NewResponse(http.StatusNoContent, "")
+			r.Assertion = func(t *testing.T, r *http.Request) {
+				attempts++
+				b, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(b) != content {
+					t.Errorf("Expected body of attempt %d to be '%s', got '%s'", attempts, content, b)
+				}
+			}
+			ts := test.NewMockAPI(t, test.Routes{"PUT /": flakyResponse(r, 3)})
+			defer ts.Close()
+
+			c := NewClient(&Config{
+				APIAddress:         ts.URL,
+				RetryingHTTPClient: NewRetryingHTTPClient(DefaultConfig().HTTPClient, 10, 1),
+			}).AutoRetry()
+
+			req, err := c.NewRequest(http.MethodPut, "/", v.body(), nil)
+			if err != nil {
+				panic(err)
+			}
+			if err := c.Call(req, nil, nil); err != nil {
+				t.Error(err)
+			}
+			if attempts != 4 {
+				t.Errorf("Expected 4 attempts, got %d", attempts)
+			}
+		})
+	}
+}
+
+func TestRetry_ReplaysBodyOfCreateStack(t *testing.T) {
+	org := "test-org"
+	name := "test-stack"
+	attempts := 0
+	r := test.NewResponse(http.StatusOK, "./fixtures/CreateStack.json")
+	r.Assertion = func(t *testing.T, r *http.Request) {
+		attempts++
+		req := CreateStackRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Unable to decode body of attempt %d: %s", attempts, err)
+		}
+		if len(req.Operations) != 1 {
+			t.Errorf("Expected 1 operation in attempt %d, got %d", attempts, len(req.Operations))
+		}
+	}
+	ts := test.NewMockAPI(t, test.Routes{"PUT /stacks/" + org + "/" + name: flakyResponse(r, 3)})
+	defer ts.Close()
+
+	c := NewClient(&Config{
+		APIAddress:         ts.URL,
+		RetryingHTTPClient: NewRetryingHTTPClient(DefaultConfig().HTTPClient, 10, 1),
+	}).AutoRetry()
+
+	_, err := c.CreateStack(org, name, CreateStackRequest{Operations: Operations{ResizeOperation{Width: IntPtr(100)}}}, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts, got %d", attempts)
+	}
+}
+
+func TestRetry_ReplaysBodyOfCreateSourceImage(t *testing.T) {
+	org := "test"
+	image, err := ioutil.ReadFile("./fixtures/image.png")
+	if err != nil {
+		panic(err)
+	}
+
+	attempts := 0
+	r := test.NewResponse(http.StatusOK, "./fixtures/CreateSourceImage.json")
+	r.Assertion = func(t *testing.T, r *http.Request) {
+		attempts++
+		f, _, err := r.FormFile("filename")
+		if err != nil {
+			t.Fatalf("Unable to read file of attempt %d: %s", attempts, err)
+		}
+		defer f.Close()
+		d, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(d, image) {
+			t.Errorf("Expected file of attempt %d to have %d bytes, got %d", attempts, len(image), len(d))
+		}
+	}
+	ts := test.NewMockAPI(t, test.Routes{"POST /sourceimages/" + org: flakyResponse(r, 3)})
+	defer ts.Close()
+
+	c := NewClient(&Config{
+		APIAddress:         ts.URL,
+		RetryingHTTPClient: NewRetryingHTTPClient(DefaultConfig().HTTPClient, 10, 1),
+	}).AutoRetry()
+
+	file, err := os.Open("./fixtures/image.png")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	if _, err := c.CreateSourceImage(org, "image.png", file); err != nil {
+		t.Error(err)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts, got %d", attempts)
+	}
+}
+
+func TestRetry_LargeBodyIsNotReplayed(t *testing.T) {
+	attempts := 0
+	r := test.NewResponse(http.StatusServiceUnavailable, "")
+	r.Assertion = func(t *testing.T, r *http.Request) {
+		attempts++
+		n, err := io.Copy(ioutil.Discard, r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != maxBufferedBodySize+10 {
+			t.Errorf("Expected body to have %d bytes, got %d", maxBufferedBodySize+10, n)
+		}
+	}
+	ts := test.NewMockAPI(t, test.Routes{"PUT /": r})
+	defer ts.Close()
+
+	c := NewClient(&Config{
+		APIAddress:         ts.URL,
+		RetryingHTTPClient: NewRetryingHTTPClient(DefaultConfig().HTTPClient, 10, 1),
+	}).AutoRetry()
+
+	body := ioutil.NopCloser(bytes.NewReader(make([]byte, maxBufferedBodySize+10)))
+	req, err := c.NewRequest(http.MethodPut, "/", body, nil)
+	if err != nil {
+		panic(err)
+	}
+	if err := c.Call(req, nil, nil); !errors.Is(err, ErrServer) {
+		t.Errorf("Expected error '%v', got '%v'", ErrServer, err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
 func TestCalculateBackoff(t *testing.T) {
 	table := []struct {
 		retries  int
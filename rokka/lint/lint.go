@@ -0,0 +1,160 @@
+// Package lint finds problems in stack definitions which rokka accepts but which likely don't do what was intended,
+// e.g. a resize whose result is resized again right away.
+//
+// Every Rule has an ID, a severity and a suggestion how to fix the problem. Rules can be suppressed per stack:
+//
+//    findings := lint.Lint(stacks.Items, lint.Config{Suppress: map[string][]string{"product": {"redundant-resize"}}})
+//    for _, f := range findings {
+//        fmt.Println(f)
+//    }
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+// Severity describes how likely a finding is a mistake.
+type Severity string
+
+// Severities ordered from the least to the most severe.
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+var severityLevels = map[Severity]int{SeverityInfo: 1, SeverityWarning: 2, SeverityError: 3}
+
+// AtLeast returns true if the severity is the same or more severe than s.
+func (sev Severity) AtLeast(s Severity) bool {
+	return severityLevels[sev] >= severityLevels[s]
+}
+
+// ParseSeverity returns the severity by its name.
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(name)
+	if _, ok := severityLevels[s]; !ok {
+		return "", fmt.Errorf("lint: unknown severity %s, expected info, warning or error", name)
+	}
+	return s, nil
+}
+
+// Rule is a check of stacks.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	// Suggestion describes how to fix findings of the rule.
+	Suggestion string
+	check      func(s rokka.Stack, cfg Config, report func(location, message string))
+}
+
+// Finding is a problem found in a stack.
+type Finding struct {
+	Stack    string
+	Rule     string
+	Severity Severity
+	// Location is the part of the stack the finding refers to, e.g. `stack_operations[1]` or
+	// `stack_options.webp.quality`.
+	Location   string
+	Message    string
+	Suggestion string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %s at %s: %s (%s)", f.Stack, f.Severity, f.Rule, f.Location, f.Message, f.Suggestion)
+}
+
+// Config configures the linter.
+type Config struct {
+	// Suppress lists the IDs of rules which are ignored for a stack by its name. Rules listed for the name `*` are
+	// ignored for all stacks.
+	Suppress map[string][]string
+	// DeprecatedOperations lists operations which shouldn't be used anymore by name and the suggested replacement,
+	// in addition to the ones deprecated by rokka, see rokka.DeprecatedOperations. Operations unknown to the client
+	// are always reported as they may have been removed.
+	DeprecatedOperations map[string]string
+}
+
+// deprecationMessage returns the message for a deprecated operation and whether it's deprecated at all.
+func (cfg Config) deprecationMessage(name string) (string, bool) {
+	msg := fmt.Sprintf("operation %s is deprecated", name)
+	if replacement, ok := cfg.DeprecatedOperations[name]; ok {
+		if replacement != "" {
+			msg += ", use " + replacement + " instead"
+		}
+		return msg, true
+	}
+	note, ok := rokka.DeprecatedOperations()[name]
+	if note != "" {
+		msg += ": " + strings.ToLower(note[:1]) + note[1:]
+	}
+	return msg, ok
+}
+
+func (cfg Config) suppressed(stack, rule string) bool {
+	for _, name := range []string{"*", stack} {
+		for _, id := range cfg.Suppress[name] {
+			if id == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Rules returns all rules in the order they're checked.
+func Rules() []Rule {
+	rules := make([]Rule, len(allRules))
+	copy(rules, allRules)
+	return rules
+}
+
+// LintStack returns the findings of a stack in the order of the rules.
+func LintStack(s rokka.Stack, cfg Config) []Finding {
+	findings := make([]Finding, 0)
+	for _, r := range allRules {
+		if cfg.suppressed(s.Name, r.ID) {
+			continue
+		}
+		r.check(s, cfg, func(location, message string) {
+			findings = append(findings, Finding{
+				Stack:      s.Name,
+				Rule:       r.ID,
+				Severity:   r.Severity,
+				Location:   location,
+				Message:    message,
+				Suggestion: r.Suggestion,
+			})
+		})
+	}
+	return findings
+}
+
+// Lint returns the findings of all stacks ordered by the name of the stack.
+func Lint(stacks []rokka.Stack, cfg Config) []Finding {
+	sorted := make([]rokka.Stack, len(stacks))
+	copy(sorted, stacks)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	findings := make([]Finding, 0)
+	for _, s := range sorted {
+		findings = append(findings, LintStack(s, cfg)...)
+	}
+	return findings
+}
+
+// MaxSeverity returns the highest severity of the findings, an empty string if there aren't any.
+func MaxSeverity(findings []Finding) Severity {
+	var max Severity
+	for _, f := range findings {
+		if max == "" || f.Severity.AtLeast(max) {
+			max = f.Severity
+		}
+	}
+	return max
+}
@@ -0,0 +1,188 @@
+package lint
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+func TestLintStack(t *testing.T) {
+	table := []struct {
+		name     string
+		stack    rokka.Stack
+		expected []string
+	}{
+		{
+			"valid",
+			rokka.Stack{
+				StackOperations: rokka.Operations{rokka.AutorotateOperation{}, rokka.CropOperation{Width: rokka.IntPtr(10), Height: rokka.IntPtr(10)}, rokka.ResizeOperation{Width: rokka.IntPtr(10)}},
+				StackOptions:    rokka.StackOptions{"jpg.quality": 80},
+			},
+			[]string{},
+		},
+		{
+			"redundant resize",
+			rokka.Stack{StackOperations: rokka.Operations{&rokka.ResizeOperation{Width: rokka.IntPtr(500)}, &rokka.ResizeOperation{Width: rokka.IntPtr(200), UpscaleDpr: rokka.BoolPtr(false)}}},
+			[]string{"redundant-resize stack_operations[0]"},
+		},
+		{
+			"relevant resizes",
+			rokka.Stack{StackOperations: rokka.Operations{
+				rokka.ResizeOperation{Width: rokka.IntPtr(500), Height: rokka.IntPtr(100), Mode: rokka.StrPtr("absolute")},
+				rokka.ResizeOperation{Width: rokka.IntPtr(200)},
+				rokka.ResizeOperation{Width: rokka.IntPtr(400), Upscale: rokka.BoolPtr(false), UpscaleDpr: rokka.BoolPtr(false)},
+				rokka.ResizeOperation{Width: rokka.IntPtr(100), Enabled: rokka.BoolPtr(false)},
+			}},
+			[]string{},
+		},
+		{
+			"crop before autorotate",
+			rokka.Stack{StackOperations: rokka.Operations{rokka.CropOperation{Width: rokka.IntPtr(10), Height: rokka.IntPtr(10)}, rokka.GrayscaleOperation{}, rokka.AutorotateOperation{}}},
+			[]string{"crop-before-autorotate stack_operations[0]"},
+		},
+		{
+			"upscale without upscale_dpr",
+			rokka.Stack{StackOperations: rokka.Operations{rokka.ResizeOperation{Width: rokka.IntPtr(10), Upscale: rokka.BoolPtr(false)}}},
+			[]string{"upscale-without-upscale-dpr stack_operations[0]"},
+		},
+		{
+			"composition without secondary",
+			rokka.Stack{StackOperations: rokka.Operations{rokka.CompositionOperation{Width: rokka.IntPtr(10), Height: rokka.IntPtr(10)}, rokka.CompositionOperation{SecondaryColor: rokka.StrPtr("FFFFFF")}}},
+			[]string{"composition-without-secondary stack_operations[0]"},
+		},
+		{
+			"expressions",
+			rokka.Stack{StackExpressions: []rokka.Expression{
+				{Expression: "options.dpr >", Overrides: map[string]interface{}{}},
+				{Expression: "1 > 2", Overrides: map[string]interface{}{"options": map[string]interface{}{"jpg.quality": 10}}},
+				{Expression: "options.dpr >= 2", Overrides: map[string]interface{}{"options": map[string]interface{}{"jpg.quality": 60}}},
+				{Expression: "options.dpr>=2", Overrides: map[string]interface{}{"options": map[string]interface{}{"jpg.quality": 50, "webp.quality": 50}}},
+				{Expression: "options.dpr >= 3", Overrides: map[string]interface{}{"options": map[string]interface{}{"jpg.quality": 40}}},
+			}},
+			[]string{"invalid-expression stack_expressions[0]", "unreachable-expression stack_expressions[1]", "unreachable-expression stack_expressions[2]"},
+		},
+		{
+			"contradicting options",
+			rokka.Stack{
+				StackOperations: rokka.Operations{rokka.GrayscaleOperation{}},
+				StackOptions: rokka.StackOptions{
+					"source_file":            true,
+					"webp.lossless":          "true",
+					"webp.quality":           80,
+					"optim.disable_all":      true,
+					"optim.quality":          5,
+					"jpg.transparency.color": "000000",
+				},
+			},
+			[]string{
+				"contradicting-options stack_operations",
+				"contradicting-options stack_options.webp.quality",
+				"contradicting-options stack_options.optim.quality",
+				"contradicting-options stack_options.jpg.transparency.color",
+			},
+		},
+		{
+			"unknown operation",
+			rokka.Stack{StackOperations: rokka.Operations{&rokka.RawOperation{OperationName: "vignette"}, rokka.GrayscaleOperation{}}},
+			[]string{"deprecated-operation stack_operations[0]"},
+		},
+	}
+
+	for _, v := range table {
+		findings := LintStack(v.stack, Config{})
+		got := make([]string, len(findings))
+		for i, f := range findings {
+			got[i] = f.Rule + " " + f.Location
+		}
+		if !reflect.DeepEqual(got, v.expected) {
+			t.Errorf("%s: expected %v, got %v", v.name, v.expected, got)
+		}
+	}
+}
+
+func TestLintStack_DeprecatedOperations(t *testing.T) {
+	s := rokka.Stack{Name: "product", StackOperations: rokka.Operations{rokka.PrimitiveOperation{}}}
+	findings := LintStack(s, Config{DeprecatedOperations: map[string]string{"primitive": "blur"}})
+
+	expected := []Finding{{
+		Stack:      "product",
+		Rule:       "deprecated-operation",
+		Severity:   SeverityWarning,
+		Location:   "stack_operations[0]",
+		Message:    "operation primitive is deprecated, use blur instead",
+		Suggestion: "replace the operation",
+	}}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("Expected %v, got %v", expected, findings)
+	}
+	if s := findings[0].String(); s != "product: warning deprecated-operation at stack_operations[0]: operation primitive is deprecated, use blur instead (replace the operation)" {
+		t.Errorf("Unexpected string '%s'", s)
+	}
+}
+
+func TestLintStack_DeprecatedByRokka(t *testing.T) {
+	s := rokka.Stack{Name: "product", StackOperations: rokka.Operations{rokka.NoopOperation{}}}
+	findings := LintStack(s, Config{})
+	expected := "operation noop is deprecated: use an empty stack operations collection to get the same behaviour"
+	if len(findings) != 1 || findings[0].Message != expected {
+		t.Errorf("Expected message '%s', got %v", expected, findings)
+	}
+}
+
+func TestLint(t *testing.T) {
+	upscale := rokka.Operations{rokka.ResizeOperation{Width: rokka.IntPtr(10), Upscale: rokka.BoolPtr(false)}}
+	composition := rokka.Operations{rokka.CompositionOperation{}}
+	stacks := []rokka.Stack{
+		{Name: "b", StackOperations: upscale},
+		{Name: "a", StackOperations: composition},
+		{Name: "c", StackOperations: upscale},
+	}
+
+	findings := Lint(stacks, Config{})
+	if len(findings) != 3 || findings[0].Stack != "a" || findings[1].Stack != "b" || findings[2].Stack != "c" {
+		t.Errorf("Expected findings ordered by stack, got %v", findings)
+	}
+	if s := MaxSeverity(findings); s != SeverityError {
+		t.Errorf("Expected max severity error, got %s", s)
+	}
+
+	cfg := Config{Suppress: map[string][]string{"*": {"composition-without-secondary"}, "b": {"upscale-without-upscale-dpr"}}}
+	findings = Lint(stacks, cfg)
+	if len(findings) != 1 || findings[0].Stack != "c" {
+		t.Errorf("Expected only the finding of stack c, got %v", findings)
+	}
+	if s := MaxSeverity(findings); s != SeverityInfo {
+		t.Errorf("Expected max severity info, got %s", s)
+	}
+	if s := MaxSeverity(nil); s != "" {
+		t.Errorf("Expected no severity, got %s", s)
+	}
+}
+
+func TestLint_DecodedStacks(t *testing.T) {
+	var res rokka.ListStacksResponse
+	data := `{"items": [{"name": "s", "stack_operations": [{"name": "resize", "options": {"width": 100}}, {"name": "resize", "options": {"width": 50}}]}]}`
+	if err := json.Unmarshal([]byte(data), &res); err != nil {
+		t.Fatal(err)
+	}
+	if findings := Lint(res.Items, Config{}); len(findings) != 1 || findings[0].Rule != "redundant-resize" {
+		t.Errorf("Expected a redundant-resize finding, got %v", findings)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	if !SeverityError.AtLeast(SeverityWarning) || SeverityInfo.AtLeast(SeverityWarning) || !SeverityWarning.AtLeast(SeverityWarning) {
+		t.Error("Unexpected order of severities")
+	}
+	if s, err := ParseSeverity("warning"); err != nil || s != SeverityWarning {
+		t.Errorf("Expected warning, got %s (%v)", s, err)
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+	if len(Rules()) != 8 {
+		t.Errorf("Expected 8 rules, got %d", len(Rules()))
+	}
+}
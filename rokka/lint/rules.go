@@ -0,0 +1,288 @@
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/expression"
+)
+
+var allRules = []Rule{
+	{
+		ID:          "redundant-resize",
+		Severity:    SeverityWarning,
+		Description: "A resize is directly followed by another resize which determines the size on its own.",
+		Suggestion:  "remove the first resize or merge both into one",
+		check:       checkRedundantResize,
+	},
+	{
+		ID:          "crop-before-autorotate",
+		Severity:    SeverityWarning,
+		Description: "An image is cropped before it's rotated by autorotate, the crop area is therefore rotated too.",
+		Suggestion:  "move autorotate before crop to crop the image in its final orientation",
+		check:       checkCropBeforeAutorotate,
+	},
+	{
+		ID:          "upscale-without-upscale-dpr",
+		Severity:    SeverityInfo,
+		Description: "A resize disables upscale without setting upscale_dpr, images requested with a dpr are still upscaled.",
+		Suggestion:  "set upscale_dpr explicitly, false to never upscale",
+		check:       checkUpscaleWithoutUpscaleDPR,
+	},
+	{
+		ID:          "composition-without-secondary",
+		Severity:    SeverityError,
+		Description: "A composition has neither a secondary image nor a secondary color.",
+		Suggestion:  "set secondary_image to the hash of an image or secondary_color",
+		check:       checkCompositionWithoutSecondary,
+	},
+	{
+		ID:          "invalid-expression",
+		Severity:    SeverityError,
+		Description: "An expression has a syntax error.",
+		Suggestion:  "fix the syntax of the expression, see `rokka stacks explain`",
+		check:       checkInvalidExpression,
+	},
+	{
+		ID:          "unreachable-expression",
+		Severity:    SeverityWarning,
+		Description: "The overrides of an expression never take effect, because it's never true or later overridden by an expression with the same condition.",
+		Suggestion:  "remove the expression or merge its overrides into the other one",
+		check:       checkUnreachableExpression,
+	},
+	{
+		ID:          "contradicting-options",
+		Severity:    SeverityWarning,
+		Description: "A stack option has no effect because of another option.",
+		Suggestion:  "remove the option without effect",
+		check:       checkContradictingOptions,
+	},
+	{
+		ID:          "deprecated-operation",
+		Severity:    SeverityWarning,
+		Description: "An operation is deprecated or unknown to the client.",
+		Suggestion:  "replace the operation",
+		check:       checkDeprecatedOperation,
+	},
+}
+
+func operationLocation(i int) string {
+	return fmt.Sprintf("stack_operations[%d]", i)
+}
+
+func expressionLocation(i int) string {
+	return fmt.Sprintf("stack_expressions[%d]", i)
+}
+
+// operations returns the enabled operations of the stack by their index. Pointers are dereferenced.
+func operations(s rokka.Stack) map[int]rokka.Operation {
+	ops := make(map[int]rokka.Operation)
+	for i, op := range s.StackOperations {
+		v := reflect.ValueOf(op)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		if f := v.FieldByName("Enabled"); f.IsValid() && f.Kind() == reflect.Ptr && !f.IsNil() && !f.Elem().Bool() {
+			continue
+		}
+		if o, ok := v.Interface().(rokka.Operation); ok {
+			ops[i] = o
+		}
+	}
+	return ops
+}
+
+func isFalse(b *bool) bool {
+	return b != nil && !*b
+}
+
+func checkRedundantResize(s rokka.Stack, cfg Config, report func(location, message string)) {
+	ops := operations(s)
+	for i := range s.StackOperations {
+		first, ok := ops[i].(rokka.ResizeOperation)
+		if !ok {
+			continue
+		}
+		second, ok := ops[i+1].(rokka.ResizeOperation)
+		if !ok || isFalse(second.Upscale) {
+			continue
+		}
+		// the first resize is only relevant if it changes the aspect ratio used by the second one.
+		secondAbsolute := second.Mode != nil && *second.Mode == "absolute" && second.Width != nil && second.Height != nil
+		if first.Mode != nil && *first.Mode == "absolute" && !secondAbsolute {
+			continue
+		}
+		report(operationLocation(i), fmt.Sprintf("the result is resized again by operation %d", i+1))
+	}
+}
+
+func checkCropBeforeAutorotate(s rokka.Stack, cfg Config, report func(location, message string)) {
+	ops := operations(s)
+	autorotate := -1
+	for i := len(s.StackOperations) - 1; i >= 0; i-- {
+		switch ops[i].(type) {
+		case rokka.AutorotateOperation:
+			autorotate = i
+		case rokka.CropOperation:
+			if autorotate > i {
+				report(operationLocation(i), fmt.Sprintf("the image is cropped before it's rotated by operation %d", autorotate))
+			}
+		}
+	}
+}
+
+func checkUpscaleWithoutUpscaleDPR(s rokka.Stack, cfg Config, report func(location, message string)) {
+	ops := operations(s)
+	for i := range s.StackOperations {
+		if op, ok := ops[i].(rokka.ResizeOperation); ok && isFalse(op.Upscale) && op.UpscaleDpr == nil {
+			report(operationLocation(i), "upscale is disabled, but upscale_dpr defaults to true")
+		}
+	}
+}
+
+func checkCompositionWithoutSecondary(s rokka.Stack, cfg Config, report func(location, message string)) {
+	ops := operations(s)
+	for i := range s.StackOperations {
+		op, ok := ops[i].(rokka.CompositionOperation)
+		if !ok {
+			continue
+		}
+		if (op.SecondaryImage == nil || *op.SecondaryImage == "") && (op.SecondaryColor == nil || *op.SecondaryColor == "") {
+			report(operationLocation(i), "composition has neither secondary_image nor secondary_color")
+		}
+	}
+}
+
+func checkInvalidExpression(s rokka.Stack, cfg Config, report func(location, message string)) {
+	for i, e := range s.StackExpressions {
+		if _, err := expression.Parse(e.Expression); err != nil {
+			report(expressionLocation(i), err.Error())
+		}
+	}
+}
+
+// isConstant returns true if the expression doesn't reference any value of the request.
+func isConstant(n expression.Node) bool {
+	switch n := n.(type) {
+	case expression.Variable, expression.Identifier:
+		return false
+	case expression.Unary:
+		return isConstant(n.X)
+	case expression.Binary:
+		return isConstant(n.X) && isConstant(n.Y)
+	}
+	return true
+}
+
+// overrideKeys returns the names of the options and variables overridden by an expression, e.g. `options.dpr`.
+func overrideKeys(e rokka.Expression) []string {
+	keys := make([]string, 0)
+	for _, kind := range []string{"options", "variables"} {
+		if m, ok := e.Overrides[kind].(map[string]interface{}); ok {
+			for k := range m {
+				keys = append(keys, kind+"."+k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func checkUnreachableExpression(s rokka.Stack, cfg Config, report func(location, message string)) {
+	conditions := make([]string, len(s.StackExpressions))
+	for i, e := range s.StackExpressions {
+		n, err := expression.Parse(e.Expression)
+		if err != nil {
+			continue
+		}
+		conditions[i] = n.String()
+		if isConstant(n) {
+			if v, err := expression.Eval(n, expression.Request{}); err == nil && v == false {
+				report(expressionLocation(i), fmt.Sprintf("expression %s is never true", e.Expression))
+			}
+		}
+	}
+
+	for i, e := range s.StackExpressions {
+		keys := overrideKeys(e)
+		if conditions[i] == "" || len(keys) == 0 {
+			continue
+		}
+		for j := i + 1; j < len(s.StackExpressions); j++ {
+			if conditions[j] != conditions[i] {
+				continue
+			}
+			later := make(map[string]bool)
+			for _, k := range overrideKeys(s.StackExpressions[j]) {
+				later[k] = true
+			}
+			shadowed := true
+			for _, k := range keys {
+				shadowed = shadowed && later[k]
+			}
+			if shadowed {
+				report(expressionLocation(i), fmt.Sprintf("all overrides are overridden by expression %d with the same condition", j))
+				break
+			}
+		}
+	}
+}
+
+// isTrue returns true for the boolean true, also if given as string.
+func isTrue(v interface{}) bool {
+	return v == true || v == "true"
+}
+
+func checkContradictingOptions(s rokka.Stack, cfg Config, report func(location, message string)) {
+	o := s.StackOptions
+	has := func(name string) bool {
+		_, ok := o[name]
+		return ok
+	}
+
+	if isTrue(o["source_file"]) && len(s.StackOperations) > 0 {
+		report("stack_operations", "source_file delivers the original image, the operations have no effect")
+	}
+	if isTrue(o["webp.lossless"]) && has("webp.quality") {
+		report("stack_options.webp.quality", "webp.quality has no effect with webp.lossless enabled")
+	}
+	if isTrue(o["optim.disable_all"]) {
+		for _, name := range []string{"optim.immediate", "optim.quality"} {
+			if has(name) {
+				report("stack_options."+name, fmt.Sprintf("%s has no effect with optim.disable_all enabled", name))
+			}
+		}
+	}
+	if has("jpg.transparency.color") && !isTrue(o["jpg.transparency.convert"]) {
+		report("stack_options.jpg.transparency.color", "jpg.transparency.color has no effect without jpg.transparency.convert")
+	}
+	if isTrue(o["jpg.transparency.autoformat"]) && isTrue(o["jpg.transparency.convert"]) {
+		report("stack_options.jpg.transparency.convert", "jpg.transparency.convert has no effect with jpg.transparency.autoformat enabled")
+	}
+}
+
+func checkDeprecatedOperation(s rokka.Stack, cfg Config, report func(location, message string)) {
+	ops := operations(s)
+	for i := range s.StackOperations {
+		op, ok := ops[i]
+		if !ok {
+			continue
+		}
+		name := op.Name()
+		if msg, ok := cfg.deprecationMessage(name); ok {
+			report(operationLocation(i), msg)
+			continue
+		}
+		if _, err := rokka.NewOperationByName(name); err != nil {
+			report(operationLocation(i), fmt.Sprintf("operation %s is unknown to the client and may have been removed", name))
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package local
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// canvas is an image with premultiplied RGBA values between 0 and 1, used to avoid rounding errors between
+// operations.
+type canvas struct {
+	w, h int
+	pix  []float64
+}
+
+func newCanvas(w, h int) *canvas {
+	return &canvas{w: w, h: h, pix: make([]float64, 4*w*h)}
+}
+
+func canvasFromImage(img image.Image) *canvas {
+	b := img.Bounds()
+	c := newCanvas(b.Dx(), b.Dy())
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			c.pix[i] = float64(r) / 0xffff
+			c.pix[i+1] = float64(g) / 0xffff
+			c.pix[i+2] = float64(bl) / 0xffff
+			c.pix[i+3] = float64(a) / 0xffff
+			i += 4
+		}
+	}
+	return c
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// image returns the canvas as non-premultiplied *image.NRGBA.
+func (c *canvas) image() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, c.w, c.h))
+	for i := 0; i < len(c.pix); i += 4 {
+		a := clamp(c.pix[i+3])
+		var r, g, b float64
+		if a > 0 {
+			r, g, b = clamp(c.pix[i]/a), clamp(c.pix[i+1]/a), clamp(c.pix[i+2]/a)
+		}
+		img.Pix[i] = uint8(r*255 + 0.5)
+		img.Pix[i+1] = uint8(g*255 + 0.5)
+		img.Pix[i+2] = uint8(b*255 + 0.5)
+		img.Pix[i+3] = uint8(a*255 + 0.5)
+	}
+	return img
+}
+
+// crop returns the area of size w x h at x, y.
+func (c *canvas) crop(x, y, w, h int) *canvas {
+	res := newCanvas(w, h)
+	for row := 0; row < h; row++ {
+		src := 4 * ((y+row)*c.w + x)
+		copy(res.pix[4*row*w:4*(row+1)*w], c.pix[src:src+4*w])
+	}
+	return res
+}
+
+// weights returns the contributions of the source pixels to each destination pixel when scaling a line of n pixels
+// to m pixels, using a triangle filter widened when scaling down.
+func weights(n, m int) ([][]int, [][]float64) {
+	scale := float64(n) / float64(m)
+	support := math.Max(scale, 1)
+	indices := make([][]int, m)
+	values := make([][]float64, m)
+	for i := 0; i < m; i++ {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Floor(center - support))
+		end := int(math.Ceil(center + support))
+		sum := 0.0
+		for j := start; j <= end; j++ {
+			w := 1 - math.Abs(float64(j)-center)/support
+			if w <= 0 {
+				continue
+			}
+			k := j
+			if k < 0 {
+				k = 0
+			}
+			if k >= n {
+				k = n - 1
+			}
+			indices[i] = append(indices[i], k)
+			values[i] = append(values[i], w)
+			sum += w
+		}
+		for j := range values[i] {
+			values[i][j] /= sum
+		}
+	}
+	return indices, values
+}
+
+// resize scales the canvas to w x h, first horizontally and then vertically.
+func (c *canvas) resize(w, h int) *canvas {
+	if w == c.w && h == c.h {
+		return c
+	}
+	tmp := newCanvas(w, c.h)
+	indices, values := weights(c.w, w)
+	for y := 0; y < c.h; y++ {
+		for x := 0; x < w; x++ {
+			d := 4 * (y*w + x)
+			for j, k := range indices[x] {
+				s := 4 * (y*c.w + k)
+				for ch := 0; ch < 4; ch++ {
+					tmp.pix[d+ch] += c.pix[s+ch] * values[x][j]
+				}
+			}
+		}
+	}
+
+	res := newCanvas(w, h)
+	indices, values = weights(c.h, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			d := 4 * (y*w + x)
+			for j, k := range indices[y] {
+				s := 4 * (k*w + x)
+				for ch := 0; ch < 4; ch++ {
+					res.pix[d+ch] += tmp.pix[s+ch] * values[y][j]
+				}
+			}
+		}
+	}
+	return res
+}
+
+// sample returns the bilinear interpolated premultiplied color at x, y. Outside of the canvas, the color is
+// transparent.
+func (c *canvas) sample(x, y float64) [4]float64 {
+	var res [4]float64
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	for _, p := range [4]struct {
+		x, y int
+		w    float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x0 + 1, y0, fx * (1 - fy)},
+		{x0, y0 + 1, (1 - fx) * fy},
+		{x0 + 1, y0 + 1, fx * fy},
+	} {
+		if p.x < 0 || p.y < 0 || p.x >= c.w || p.y >= c.h || p.w == 0 {
+			continue
+		}
+		i := 4 * (p.y*c.w + p.x)
+		for ch := 0; ch < 4; ch++ {
+			res[ch] += c.pix[i+ch] * p.w
+		}
+	}
+	return res
+}
+
+// rotate rotates the canvas clockwise by angle degrees into a canvas of size w x h, filling the background with the
+// non-premultiplied color bg.
+func (c *canvas) rotate(angle float64, w, h int, bg color.NRGBA) *canvas {
+	res := newCanvas(w, h)
+	rad := angle * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	a := float64(bg.A) / 255
+	background := [4]float64{float64(bg.R) / 255 * a, float64(bg.G) / 255 * a, float64(bg.B) / 255 * a, a}
+	cx, cy := float64(c.w)/2, float64(c.h)/2
+	dx, dy := float64(w)/2, float64(h)/2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// map the center of the destination pixel back to the source.
+			px, py := float64(x)+0.5-dx, float64(y)+0.5-dy
+			sx := px*cos + py*sin + cx - 0.5
+			sy := -px*sin + py*cos + cy - 0.5
+			p := c.sample(sx, sy)
+			i := 4 * (y*w + x)
+			for ch := 0; ch < 4; ch++ {
+				res.pix[i+ch] = p[ch] + background[ch]*(1-p[3])
+			}
+		}
+	}
+	return res
+}
+
+// blur applies a gaussian blur with the given standard deviation.
+func (c *canvas) blur(sigma float64) *canvas {
+	if sigma <= 0 {
+		return c
+	}
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	pass := func(src *canvas, horizontal bool) *canvas {
+		res := newCanvas(src.w, src.h)
+		for y := 0; y < src.h; y++ {
+			for x := 0; x < src.w; x++ {
+				d := 4 * (y*src.w + x)
+				for i, k := range kernel {
+					sx, sy := x, y
+					if horizontal {
+						sx = clampInt(x+i-radius, src.w)
+					} else {
+						sy = clampInt(y+i-radius, src.h)
+					}
+					s := 4 * (sy*src.w + sx)
+					for ch := 0; ch < 4; ch++ {
+						res.pix[d+ch] += src.pix[s+ch] * k
+					}
+				}
+			}
+		}
+		return res
+	}
+	return pass(pass(c, true), false)
+}
+
+func clampInt(v, n int) int {
+	if v < 0 {
+		return 0
+	}
+	if v >= n {
+		return n - 1
+	}
+	return v
+}
+
+// transformColors calls fn with the non-premultiplied color and alpha of every pixel.
+func (c *canvas) transformColors(fn func(rgb [3]float64, a float64) ([3]float64, float64)) {
+	for i := 0; i < len(c.pix); i += 4 {
+		a := c.pix[i+3]
+		var rgb [3]float64
+		if a > 0 {
+			rgb = [3]float64{c.pix[i] / a, c.pix[i+1] / a, c.pix[i+2] / a}
+		}
+		rgb, a = fn(rgb, a)
+		a = clamp(a)
+		for ch := 0; ch < 3; ch++ {
+			c.pix[i+ch] = clamp(rgb[ch]) * a
+		}
+		c.pix[i+3] = a
+	}
+}
+
+func luminance(rgb [3]float64) float64 {
+	return 0.299*rgb[0] + 0.587*rgb[1] + 0.114*rgb[2]
+}
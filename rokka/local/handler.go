@@ -0,0 +1,160 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+// ErrSourceImageNotFound is returned by OpenSourceImage if there's no image with the hash.
+var ErrSourceImageNotFound = errors.New("local: source image not found")
+
+// contentTypes lists the formats which can be encoded.
+var contentTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+}
+
+// OpenSourceImage decodes the image with the hash in the folder. The file name without extension needs to be the
+// hash, short hashes match the start of the file name.
+func OpenSourceImage(dir, hash string) (image.Image, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		if f.IsDir() || hash == "" || !strings.HasPrefix(name, hash) {
+			continue
+		}
+		file, err := os.Open(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		img, _, err := image.Decode(file)
+		if err != nil {
+			return nil, fmt.Errorf("local: can't decode %s: %s", f.Name(), err)
+		}
+		return img, nil
+	}
+	return nil, ErrSourceImageNotFound
+}
+
+// ContentType returns the content type of a format, e.g. image/jpeg for jpg. It's empty if the format can't be
+// encoded.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Encode writes the image in the format, jpg, png or gif. The jpg quality is taken from the stack option
+// jpg.quality.
+func Encode(w io.Writer, img image.Image, format string, options rokka.StackOptions) error {
+	switch format {
+	case "jpg", "jpeg":
+		quality := 80
+		switch q := options["jpg.quality"].(type) {
+		case float64:
+			quality = int(q)
+		case int:
+			quality = q
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "png":
+		return png.Encode(w, img)
+	case "gif":
+		return gif.Encode(w, img, nil)
+	}
+	return fmt.Errorf("local: unsupported format %s", format)
+}
+
+// Handler serves render URLs, e.g. `/dynamic/resize-width-200/c1b110.jpg`, using the source images in Dir. Issues of
+// the rendering are sent in the header X-Local-Render-Issues.
+type Handler struct {
+	// Dir contains the source images named by their hash, e.g. c1b110.png.
+	Dir string
+	// Stacks are the stacks available besides the dynamic stack by their name.
+	Stacks map[string]rokka.Stack
+	// Strict responds with an error if an operation can't be emulated.
+	Strict bool
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	u, err := rokka.ParseURL("http://localhost" + r.URL.RequestURI())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	contentType := ContentType(u.Format)
+	if contentType == "" {
+		http.Error(w, fmt.Sprintf("unsupported format %s", u.Format), http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]rokka.Operation, 0)
+	options := make(rokka.StackOptions)
+	if u.Stack != "dynamic" {
+		stack, ok := h.Stacks[u.Stack]
+		if !ok {
+			http.Error(w, fmt.Sprintf("stack %s not found", u.Stack), http.StatusNotFound)
+			return
+		}
+		ops = append(ops, stack.StackOperations...)
+		for k, v := range stack.StackOptions {
+			options[k] = v
+		}
+	}
+	ops = append(ops, u.Operations...)
+	for k, v := range u.Options {
+		options[k] = v
+	}
+
+	img, err := OpenSourceImage(h.Dir, u.Hash)
+	if err == ErrSourceImageNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	o := OptionsFromStack(options)
+	o.Strict = h.Strict
+	res, err := Render(img, ops, o)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if len(res.Issues) > 0 {
+		issues := make([]string, len(res.Issues))
+		for i, issue := range res.Issues {
+			issues[i] = issue.String()
+		}
+		w.Header().Set("X-Local-Render-Issues", strings.Join(issues, "; "))
+	}
+	w.Header().Set("Content-Type", contentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := Encode(w, res.Image, u.Format, options); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,91 @@
+package local
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+func TestHandler(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "local")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "c1b110d3.png"))
+	if err != nil {
+		panic(err)
+	}
+	if err := png.Encode(f, testImage(100, 50)); err != nil {
+		panic(err)
+	}
+	f.Close()
+
+	h := Handler{
+		Dir: dir,
+		Stacks: map[string]rokka.Stack{
+			"product": {StackOperations: rokka.Operations{&rokka.ResizeOperation{Width: rokka.IntPtr(50)}}},
+		},
+	}
+
+	table := []struct {
+		path        string
+		status      int
+		contentType string
+		width       int
+		height      int
+		issues      string
+	}{
+		{"/dynamic/resize-width-20/c1b110d3.png", http.StatusOK, "image/png", 20, 10, ""},
+		{"/dynamic/c1b110.jpg", http.StatusOK, "image/jpeg", 100, 50, ""},
+		{"/product/crop-width-10-height-10/c1b110/image.png", http.StatusOK, "image/png", 10, 10, ""},
+		{"/product/resize-width-20--options-dpr-2/c1b110.png", http.StatusOK, "image/png", 40, 20, ""},
+		{"/dynamic/trim/c1b110.png", http.StatusOK, "image/png", 100, 50, "operation 0 (trim): not supported by the local renderer, skipped"},
+		{"/dynamic/c1b110.webp", http.StatusBadRequest, "", 0, 0, ""},
+		{"/dynamic/abcdef.png", http.StatusNotFound, "", 0, 0, ""},
+		{"/missing/c1b110.png", http.StatusNotFound, "", 0, 0, ""},
+		{"/dynamic/vignette-strength-1/c1b110.png", http.StatusOK, "image/png", 100, 50, "operation 0 (vignette): not supported by the local renderer, skipped"},
+		{"/dynamic/resize-width/c1b110.png", http.StatusBadRequest, "", 0, 0, ""},
+	}
+
+	for _, v := range table {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, v.path, nil))
+		if w.Code != v.status {
+			t.Errorf("%s: expected status %d, got %d (%s)", v.path, v.status, w.Code, w.Body)
+			continue
+		}
+		if v.status != http.StatusOK {
+			continue
+		}
+		if ct := w.Header().Get("Content-Type"); ct != v.contentType {
+			t.Errorf("%s: expected content type %s, got %s", v.path, v.contentType, ct)
+		}
+		if issues := w.Header().Get("X-Local-Render-Issues"); issues != v.issues {
+			t.Errorf("%s: expected issues '%s', got '%s'", v.path, v.issues, issues)
+		}
+		cfg, _, err := image.DecodeConfig(w.Body)
+		if err != nil {
+			t.Errorf("%s: %s", v.path, err)
+			continue
+		}
+		if cfg.Width != v.width || cfg.Height != v.height {
+			t.Errorf("%s: expected %dx%d, got %dx%d", v.path, v.width, v.height, cfg.Width, cfg.Height)
+		}
+	}
+
+	h.Strict = true
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dynamic/trim/c1b110.png", nil))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d in strict mode, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
@@ -0,0 +1,257 @@
+// Package local emulates rendering images with rokka for offline development and tests. It's implemented using
+// the standard library only.
+//
+// Render applies resize, crop, rotate, grayscale, sepia, blur, alpha and noop operations to an image.Image using the
+// same geometry as rokka, see the geometry package. The result isn't pixel identical to the one of rokka. Operations
+// which can't be emulated are skipped and reported as issues:
+//
+//    res, err := local.Render(img, ops, local.Options{})
+//    for _, issue := range res.Issues {
+//        log.Println(issue)
+//    }
+//
+// Handler serves render URLs using the images of a local folder.
+package local
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+	"github.com/rokka-io/rokka-go/rokka/geometry"
+)
+
+// Options are the stack options used for rendering.
+type Options struct {
+	// DPR multiplies the dimensions of resize and crop. It defaults to 1.
+	DPR float64
+	// Strict returns an *UnsupportedError instead of skipping operations which can't be emulated.
+	Strict bool
+}
+
+// OptionsFromStack returns the Options of the stack options, e.g. options.dpr.
+func OptionsFromStack(options rokka.StackOptions) Options {
+	return Options{DPR: geometry.OptionsFromStack(options).DPR}
+}
+
+// Issue describes an operation which couldn't be emulated exactly.
+type Issue struct {
+	// Index is the position of the operation within the stack.
+	Index     int
+	Operation string
+	Message   string
+	// Skipped is set if the operation wasn't applied at all.
+	Skipped bool
+}
+
+func (i Issue) String() string {
+	s := fmt.Sprintf("operation %d (%s): %s", i.Index, i.Operation, i.Message)
+	if i.Skipped {
+		s += ", skipped"
+	}
+	return s
+}
+
+// UnsupportedError is returned in strict mode if any operation can't be emulated.
+type UnsupportedError struct {
+	Issues []Issue
+}
+
+func (e *UnsupportedError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.String()
+	}
+	return fmt.Sprintf("local: can't emulate operations: %s", strings.Join(msgs, "; "))
+}
+
+// Result contains the rendered image and the operations which couldn't be emulated exactly.
+type Result struct {
+	Image  *image.NRGBA
+	Issues []Issue
+}
+
+// Render applies the operations to the image.
+func Render(img image.Image, operations []rokka.Operation, o Options) (Result, error) {
+	res := Result{Issues: make([]Issue, 0)}
+	c := canvasFromImage(img)
+	for i, op := range operations {
+		// the geometry is calculated for each operation on its own, as skipped operations don't change the size.
+		geo, err := geometry.Simulate(geometry.Size{Width: c.w, Height: c.h}, []rokka.Operation{op}, geometry.Options{DPR: o.DPR})
+		if err != nil {
+			return Result{}, fmt.Errorf("local: operation %d: %s", i, err)
+		}
+		if !enabled(op) {
+			continue
+		}
+
+		issue := Issue{Index: i, Operation: op.Name()}
+		c, issue.Message, issue.Skipped = apply(c, op, geo.Steps[0])
+		if issue.Message != "" {
+			res.Issues = append(res.Issues, issue)
+		}
+	}
+	if o.Strict && len(res.Issues) > 0 {
+		return Result{}, &UnsupportedError{Issues: res.Issues}
+	}
+	res.Image = c.image()
+	return res, nil
+}
+
+// apply applies a single operation. It returns a message if the operation couldn't be emulated exactly and whether
+// it was skipped entirely.
+func apply(c *canvas, op rokka.Operation, step geometry.Step) (*canvas, string, bool) {
+	switch op := value(op).(type) {
+	case rokka.NoopOperation:
+		return c, "", false
+	case rokka.ResizeOperation:
+		return c.resize(step.Output.Width, step.Output.Height), "", false
+	case rokka.CropOperation:
+		return crop(c, op, step)
+	case rokka.RotateOperation:
+		return rotate(c, op, step)
+	case rokka.GrayscaleOperation:
+		c.transformColors(func(rgb [3]float64, a float64) ([3]float64, float64) {
+			l := luminance(rgb)
+			return [3]float64{l, l, l}, a
+		})
+		return c, "", false
+	case rokka.SepiaOperation:
+		c.transformColors(func(rgb [3]float64, a float64) ([3]float64, float64) {
+			return [3]float64{
+				0.393*rgb[0] + 0.769*rgb[1] + 0.189*rgb[2],
+				0.349*rgb[0] + 0.686*rgb[1] + 0.168*rgb[2],
+				0.272*rgb[0] + 0.534*rgb[1] + 0.131*rgb[2],
+			}, a
+		})
+		return c, "", false
+	case rokka.BlurOperation:
+		sigma := 0.0
+		if op.Sigma != nil {
+			sigma = *op.Sigma
+		}
+		return c.blur(sigma), "", false
+	case rokka.AlphaOperation:
+		return alpha(c, op)
+	}
+	return c, "not supported by the local renderer", true
+}
+
+func crop(c *canvas, op rokka.CropOperation, step geometry.Step) (*canvas, string, bool) {
+	w, h := step.Output.Width, step.Output.Height
+	if step.Offset != nil {
+		return c.crop(step.Offset.X, step.Offset.Y, w, h), "", false
+	}
+	// the position depends on the content of the image, e.g. for smart crops, the center is used instead.
+	anchor := "auto"
+	if op.Anchor != nil {
+		anchor = *op.Anchor
+	}
+	var msg string
+	switch {
+	case op.Area != nil:
+		msg = fmt.Sprintf("area %s depends on the subject areas of the image, cropped at the center instead", *op.Area)
+	case anchor != "auto":
+		// auto uses the center for images without subject area, which local images never have.
+		msg = fmt.Sprintf("anchor %s depends on the content of the image, cropped at the center instead", anchor)
+	}
+	return c.crop((c.w-w)/2, (c.h-h)/2, w, h), msg, false
+}
+
+func rotate(c *canvas, op rokka.RotateOperation, step geometry.Step) (*canvas, string, bool) {
+	angle := 0.0
+	if op.Angle != nil {
+		angle = math.Mod(*op.Angle, 360)
+	}
+	if angle == 0 {
+		return c, "", false
+	}
+	// rokka fills the background with transparent white by default.
+	bg := color.NRGBA{0xff, 0xff, 0xff, 0}
+	if op.BackgroundColor != nil {
+		if v, err := strconv.ParseUint(*op.BackgroundColor, 16, 32); err == nil && len(*op.BackgroundColor) == 6 {
+			bg.R, bg.G, bg.B = uint8(v>>16), uint8(v>>8), uint8(v)
+		}
+	}
+	if op.BackgroundOpacity != nil {
+		bg.A = uint8(math.Floor(*op.BackgroundOpacity/100*255 + 0.5))
+	}
+	return c.rotate(angle, step.Output.Width, step.Output.Height, bg), "", false
+}
+
+func alpha(c *canvas, op rokka.AlphaOperation) (*canvas, string, bool) {
+	if op.Opacity != nil {
+		opacity := float64(*op.Opacity) / 100
+		c.transformColors(func(rgb [3]float64, a float64) ([3]float64, float64) {
+			return rgb, a * opacity
+		})
+	}
+	if op.Mode == nil {
+		return c, "", false
+	}
+	switch *op.Mode {
+	case "remove":
+		c.transformColors(func(rgb [3]float64, a float64) ([3]float64, float64) {
+			return rgb, 1
+		})
+	case "extract":
+		c.transformColors(func(rgb [3]float64, a float64) ([3]float64, float64) {
+			return [3]float64{a, a, a}, 1
+		})
+	default:
+		return c, fmt.Sprintf("mode %s is not supported by the local renderer", *op.Mode), true
+	}
+	return c, "", false
+}
+
+// value dereferences pointers to operations, as returned when decoding stacks or parsing URLs.
+func value(op rokka.Operation) rokka.Operation {
+	switch o := op.(type) {
+	case *rokka.NoopOperation:
+		return *o
+	case *rokka.ResizeOperation:
+		return *o
+	case *rokka.CropOperation:
+		return *o
+	case *rokka.RotateOperation:
+		return *o
+	case *rokka.GrayscaleOperation:
+		return *o
+	case *rokka.SepiaOperation:
+		return *o
+	case *rokka.BlurOperation:
+		return *o
+	case *rokka.AlphaOperation:
+		return *o
+	}
+	return op
+}
+
+// enabled returns false if the enabled option of a supported operation is false. Unsupported operations are always
+// reported.
+func enabled(op rokka.Operation) bool {
+	var e *bool
+	switch op := value(op).(type) {
+	case rokka.NoopOperation:
+		e = op.Enabled
+	case rokka.ResizeOperation:
+		e = op.Enabled
+	case rokka.CropOperation:
+		e = op.Enabled
+	case rokka.RotateOperation:
+		e = op.Enabled
+	case rokka.GrayscaleOperation:
+		e = op.Enabled
+	case rokka.SepiaOperation:
+		e = op.Enabled
+	case rokka.BlurOperation:
+		e = op.Enabled
+	case rokka.AlphaOperation:
+		e = op.Enabled
+	}
+	return e == nil || *e
+}
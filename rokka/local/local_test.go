@@ -0,0 +1,136 @@
+package local
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+var (
+	red   = color.NRGBA{0xff, 0, 0, 0xff}
+	blue  = color.NRGBA{0, 0, 0xff, 0xff}
+	white = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// testImage returns an image of size w x h with the left half red and the right half blue.
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func TestRender_Geometry(t *testing.T) {
+	table := []struct {
+		name       string
+		operations []rokka.Operation
+		options    Options
+		width      int
+		height     int
+	}{
+		{"noop", []rokka.Operation{rokka.NoopOperation{}}, Options{}, 100, 50},
+		{"resize", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(40)}}, Options{}, 40, 20},
+		{"resize dpr", []rokka.Operation{&rokka.ResizeOperation{Width: rokka.IntPtr(40)}}, Options{DPR: 2}, 80, 40},
+		{"resize fill", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(40), Height: rokka.IntPtr(40), Mode: rokka.StrPtr("fill")}}, Options{}, 80, 40},
+		{"resize without upscale", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(200), Upscale: rokka.BoolPtr(false)}}, Options{}, 100, 50},
+		{"crop", []rokka.Operation{rokka.CropOperation{Width: rokka.IntPtr(30), Height: rokka.IntPtr(20)}}, Options{}, 30, 20},
+		{"rotate", []rokka.Operation{rokka.RotateOperation{Angle: rokka.Float64Ptr(90)}}, Options{}, 50, 100},
+		{"disabled", []rokka.Operation{rokka.ResizeOperation{Width: rokka.IntPtr(40), Enabled: rokka.BoolPtr(false)}}, Options{}, 100, 50},
+	}
+
+	for _, v := range table {
+		res, err := Render(testImage(100, 50), v.operations, v.options)
+		if err != nil {
+			t.Errorf("%s: %s", v.name, err)
+			continue
+		}
+		if b := res.Image.Bounds(); b.Dx() != v.width || b.Dy() != v.height {
+			t.Errorf("%s: expected %dx%d, got %dx%d", v.name, v.width, v.height, b.Dx(), b.Dy())
+		}
+		if len(res.Issues) != 0 {
+			t.Errorf("%s: expected no issues, got %v", v.name, res.Issues)
+		}
+	}
+}
+
+func TestRender_Pixels(t *testing.T) {
+	table := []struct {
+		name       string
+		operations []rokka.Operation
+		x, y       int
+		expected   color.NRGBA
+	}{
+		{"crop anchor", []rokka.Operation{rokka.CropOperation{Width: rokka.IntPtr(10), Height: rokka.IntPtr(10), Anchor: rokka.StrPtr("right-top")}}, 0, 0, blue},
+		{"crop offset", []rokka.Operation{rokka.CropOperation{Width: rokka.IntPtr(10), Height: rokka.IntPtr(10), Anchor: rokka.StrPtr("0-0")}}, 9, 9, red},
+		{"rotate clockwise", []rokka.Operation{rokka.RotateOperation{Angle: rokka.Float64Ptr(90)}}, 0, 0, red},
+		{"rotate background", []rokka.Operation{rokka.RotateOperation{Angle: rokka.Float64Ptr(45), BackgroundColor: rokka.StrPtr("FFFFFF"), BackgroundOpacity: rokka.Float64Ptr(100)}}, 0, 0, white},
+		{"grayscale", []rokka.Operation{rokka.GrayscaleOperation{}}, 0, 0, color.NRGBA{76, 76, 76, 0xff}},
+		{"sepia", []rokka.Operation{rokka.SepiaOperation{}}, 0, 0, color.NRGBA{100, 89, 69, 0xff}},
+		{"alpha opacity", []rokka.Operation{rokka.AlphaOperation{Opacity: rokka.IntPtr(50)}}, 0, 0, color.NRGBA{0xff, 0, 0, 0x80}},
+		{"alpha extract", []rokka.Operation{rokka.AlphaOperation{Opacity: rokka.IntPtr(0)}, rokka.AlphaOperation{Mode: rokka.StrPtr("extract")}}, 0, 0, color.NRGBA{0, 0, 0, 0xff}},
+		{"blur", []rokka.Operation{rokka.BlurOperation{Sigma: rokka.Float64Ptr(2)}}, 0, 0, red},
+	}
+
+	for _, v := range table {
+		res, err := Render(testImage(100, 50), v.operations, Options{})
+		if err != nil {
+			t.Errorf("%s: %s", v.name, err)
+			continue
+		}
+		if c := res.Image.NRGBAAt(v.x, v.y); c != v.expected {
+			t.Errorf("%s: expected %v at %d,%d, got %v", v.name, v.expected, v.x, v.y, c)
+		}
+	}
+
+	// blurring mixes the colors at the border of the two halves.
+	res, err := Render(testImage(100, 50), []rokka.Operation{rokka.BlurOperation{Sigma: rokka.Float64Ptr(2)}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := res.Image.NRGBAAt(50, 25); c.R == 0 || c.B == 0 {
+		t.Errorf("Expected the colors to be mixed at the border, got %v", c)
+	}
+}
+
+func TestRender_Issues(t *testing.T) {
+	ops := []rokka.Operation{
+		rokka.TrimOperation{},
+		rokka.CropOperation{Width: rokka.IntPtr(10), Height: rokka.IntPtr(10), Anchor: rokka.StrPtr("smart")},
+		rokka.AlphaOperation{Mode: rokka.StrPtr("mask")},
+	}
+	res, err := Render(testImage(100, 50), ops, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Issue{
+		{Index: 0, Operation: "trim", Message: "not supported by the local renderer", Skipped: true},
+		{Index: 1, Operation: "crop", Message: "anchor smart depends on the content of the image, cropped at the center instead"},
+		{Index: 2, Operation: "alpha", Message: "mode mask is not supported by the local renderer", Skipped: true},
+	}
+	if len(res.Issues) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, res.Issues)
+	}
+	for i, issue := range res.Issues {
+		if issue != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], issue)
+		}
+	}
+	if b := res.Image.Bounds(); b.Dx() != 10 || b.Dy() != 10 {
+		t.Errorf("Expected 10x10, got %dx%d", b.Dx(), b.Dy())
+	}
+
+	_, err = Render(testImage(100, 50), ops, Options{Strict: true})
+	if e, ok := err.(*UnsupportedError); !ok || len(e.Issues) != 3 {
+		t.Errorf("Expected *UnsupportedError with 3 issues, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package rokka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 )
@@ -39,6 +40,11 @@ type Membership struct {
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#assign-a-user-to-an-organization
 func (c *Client) CreateMembership(org, userid string, roles []MembershipRole) error {
+	return c.CreateMembershipWithContext(context.Background(), org, userid, roles)
+}
+
+// CreateMembershipWithContext is the same as CreateMembership with the addition of passing a context.
+func (c *Client) CreateMembershipWithContext(ctx context.Context, org, userid string, roles []MembershipRole) error {
 	b := new(bytes.Buffer)
 	err := json.NewEncoder(b).Encode(createMembershipRequest{
 		Roles: roles,
@@ -46,7 +52,7 @@ func (c *Client) CreateMembership(org, userid string, roles []MembershipRole) er
 	if err != nil {
 		return err
 	}
-	req, err := c.NewRequest(http.MethodPut, "/organizations/"+org+"/memberships/"+userid, b, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPut, "/organizations/"+org+"/memberships/"+userid, b, nil)
 	if err != nil {
 		return err
 	}
@@ -58,6 +64,11 @@ func (c *Client) CreateMembership(org, userid string, roles []MembershipRole) er
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#create-a-new-user-object-and-automatically-assign-it-to-an-organisation
 func (c *Client) CreateNewMembershipWithCurrentUser(org string, roles []MembershipRole) (Membership, error) {
+	return c.CreateNewMembershipWithCurrentUserWithContext(context.Background(), org, roles)
+}
+
+// CreateNewMembershipWithCurrentUserWithContext is the same as CreateNewMembershipWithCurrentUser with the addition of passing a context.
+func (c *Client) CreateNewMembershipWithCurrentUserWithContext(ctx context.Context, org string, roles []MembershipRole) (Membership, error) {
 	result := Membership{}
 
 	b := new(bytes.Buffer)
@@ -67,7 +78,7 @@ func (c *Client) CreateNewMembershipWithCurrentUser(org string, roles []Membersh
 	if err != nil {
 		return result, err
 	}
-	req, err := c.NewRequest(http.MethodPost, "/organizations/"+org+"/memberships", b, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPost, "/organizations/"+org+"/memberships", b, nil)
 	if err != nil {
 		return result, err
 	}
@@ -80,9 +91,14 @@ func (c *Client) CreateNewMembershipWithCurrentUser(org string, roles []Membersh
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#list-memberships
 func (c *Client) ListMembership(org string) (ListMembershipsResponse, error) {
+	return c.ListMembershipWithContext(context.Background(), org)
+}
+
+// ListMembershipWithContext is the same as ListMembership with the addition of passing a context.
+func (c *Client) ListMembershipWithContext(ctx context.Context, org string) (ListMembershipsResponse, error) {
 	result := ListMembershipsResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/organizations/"+org+"/memberships", nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/organizations/"+org+"/memberships", nil, nil)
 
 	if err != nil {
 		return result, err
@@ -97,9 +113,14 @@ func (c *Client) ListMembership(org string) (ListMembershipsResponse, error) {
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#list-memberships
 func (c *Client) ListMembershipForUUID(org string, uuid string) (Membership, error) {
+	return c.ListMembershipForUUIDWithContext(context.Background(), org, uuid)
+}
+
+// ListMembershipForUUIDWithContext is the same as ListMembershipForUUID with the addition of passing a context.
+func (c *Client) ListMembershipForUUIDWithContext(ctx context.Context, org string, uuid string) (Membership, error) {
 	result := Membership{}
 
-	req, err := c.NewRequest(http.MethodGet, "/organizations/"+org+"/memberships/"+uuid, nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/organizations/"+org+"/memberships/"+uuid, nil, nil)
 
 	if err != nil {
 		return result, err
@@ -114,7 +135,12 @@ func (c *Client) ListMembershipForUUID(org string, uuid string) (Membership, err
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#remove-a-user-from-an-organization
 func (c *Client) DeleteMembership(org string, uuid string) error {
-	req, err := c.NewRequest(http.MethodDelete, "/organizations/"+org+"/memberships/"+uuid, nil, nil)
+	return c.DeleteMembershipWithContext(context.Background(), org, uuid)
+}
+
+// DeleteMembershipWithContext is the same as DeleteMembership with the addition of passing a context.
+func (c *Client) DeleteMembershipWithContext(ctx context.Context, org string, uuid string) error {
+	req, err := c.NewRequestWithContext(ctx, http.MethodDelete, "/organizations/"+org+"/memberships/"+uuid, nil, nil)
 
 	if err != nil {
 		return err
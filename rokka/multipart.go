@@ -0,0 +1,165 @@
+package rokka
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+)
+
+// sourceImageUpload is the multipart/form-data body used to create a source image.
+// Instead of buffering the image in memory, the body is streamed from data through a pipe.
+type sourceImageUpload struct {
+	boundary        string
+	name            string
+	data            io.Reader
+	userMetadata    map[string]interface{}
+	dynamicMetadata map[string]interface{}
+
+	// start is the offset of data at the time the upload has been created. It is only set if data is an io.Seeker
+	// and allows to replay the body.
+	start    int64
+	seekable bool
+
+	// body and done belong to the body returned last, done is closed once writing to body stopped.
+	body *io.PipeReader
+	done chan struct{}
+}
+
+func newSourceImageUpload(name string, data io.Reader, userMetadata, dynamicMetadata map[string]interface{}) *sourceImageUpload {
+	u := &sourceImageUpload{
+		boundary:        multipart.NewWriter(nil).Boundary(),
+		name:            name,
+		data:            data,
+		userMetadata:    userMetadata,
+		dynamicMetadata: dynamicMetadata,
+	}
+	if s, ok := data.(io.Seeker); ok {
+		start, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			u.start = start
+			u.seekable = true
+		}
+	}
+	return u
+}
+
+// FormDataContentType returns the Content-Type header value including the boundary.
+func (u *sourceImageUpload) FormDataContentType() string {
+	return "multipart/form-data; boundary=" + u.boundary
+}
+
+// ContentLength returns the length of the whole body in bytes, or -1 if the size of data can't be determined.
+func (u *sourceImageUpload) ContentLength() int64 {
+	size := readerSize(u.data)
+	if size < 0 {
+		return -1
+	}
+
+	var overhead countingWriter
+	if err := u.write(&overhead, eofReader{}); err != nil {
+		return -1
+	}
+	return int64(overhead) + size
+}
+
+// Body returns a reader streaming the multipart body. Writing happens in a separate goroutine which stops
+// as soon as the reader is closed.
+func (u *sourceImageUpload) Body() io.ReadCloser {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(u.write(pw, u.data))
+	}()
+	u.body, u.done = pr, done
+	return pr
+}
+
+// GetBody rewinds data and returns a new body. It is meant to be used as http.Request.GetBody and
+// is therefore nil if data can't be rewound.
+func (u *sourceImageUpload) GetBody() func() (io.ReadCloser, error) {
+	if !u.seekable {
+		return nil
+	}
+	return func() (io.ReadCloser, error) {
+		// stop streaming the previous body before rewinding data, otherwise both would read from it concurrently.
+		if u.body != nil {
+			u.body.Close()
+			<-u.done
+		}
+		if _, err := u.data.(io.Seeker).Seek(u.start, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return u.Body(), nil
+	}
+}
+
+func (u *sourceImageUpload) write(w io.Writer, data io.Reader) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(u.boundary); err != nil {
+		return err
+	}
+
+	fw, err := mw.CreateFormFile("filename", u.name)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(fw, data); err != nil {
+		return err
+	}
+	if u.userMetadata != nil {
+		ffw, err := mw.CreateFormField("meta_user[0]")
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(ffw).Encode(u.userMetadata); err != nil {
+			return err
+		}
+	}
+	for k, v := range u.dynamicMetadata {
+		ffw, err := mw.CreateFormField(fmt.Sprintf("meta_dynamic[0][%s]", k))
+		if err != nil {
+			return err
+		}
+		if err := json.NewEncoder(ffw).Encode(v); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// readerSize returns the number of bytes left to read in r, or -1 if it can't be determined without consuming r.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		cur, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - cur
+	}
+	return -1
+}
+
+// countingWriter discards everything written to it while counting the bytes.
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
+
+// eofReader is an empty reader.
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
@@ -1,8 +1,11 @@
 package rokka
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
-//go:generate go run ../cmd/gen/operations.go
+//go:generate go run ../cmd/gen -version reconstructed -operations ../cmd/gen/schema/operations.json -stackoptions ../cmd/gen/schema/stackoptions.json
 
 // OperationsResponse contains the available stack options.
 type OperationsResponse map[string]map[string]interface{}
@@ -11,9 +14,14 @@ type OperationsResponse map[string]map[string]interface{}
 //
 // See: https://rokka.io/documentation/references/operations.html
 func (c *Client) GetOperations() (OperationsResponse, error) {
+	return c.GetOperationsWithContext(context.Background())
+}
+
+// GetOperationsWithContext is the same as GetOperations with the addition of passing a context.
+func (c *Client) GetOperationsWithContext(ctx context.Context) (OperationsResponse, error) {
 	result := OperationsResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/operations", nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/operations", nil, nil)
 	if err != nil {
 		return result, err
 	}
@@ -0,0 +1,64 @@
+package rokka
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OperationBuilder is implemented by the generated builders of operations, e.g. the one returned by Resize.
+type OperationBuilder interface {
+	// BuildOperation validates the operation and returns it.
+	BuildOperation() (Operation, error)
+}
+
+// OperationChain is a list of builders resulting in the operations of a stack or a render URL, e.g.:
+//
+//    ops, err := rokka.Chain(
+//        rokka.Resize().Width(300).Mode(rokka.ResizeModeFill),
+//        rokka.Grayscale(),
+//    ).Build()
+type OperationChain []OperationBuilder
+
+// Chain returns an OperationChain of the given builders.
+func Chain(builders ...OperationBuilder) OperationChain {
+	return OperationChain(builders)
+}
+
+// Then returns a copy of the chain with the builders appended.
+func (c OperationChain) Then(builders ...OperationBuilder) OperationChain {
+	res := make(OperationChain, 0, len(c)+len(builders))
+	res = append(res, c...)
+	return append(res, builders...)
+}
+
+// Build validates all operations and returns them in the order of the chain. All invalid operations are reported
+// at once.
+func (c OperationChain) Build() (Operations, error) {
+	ops := make(Operations, 0, len(c))
+	problems := make([]string, 0)
+	for i, b := range c {
+		if b == nil {
+			problems = append(problems, fmt.Sprintf("operation %d is nil", i))
+			continue
+		}
+		op, err := b.BuildOperation()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("operation %d: %s", i, err))
+			continue
+		}
+		ops = append(ops, op)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("rokka: invalid operations: %s", strings.Join(problems, "; "))
+	}
+	return ops, nil
+}
+
+// MustBuild is like Build but panics if an operation is invalid. It's meant for stacks defined in code.
+func (c OperationChain) MustBuild() Operations {
+	ops, err := c.Build()
+	if err != nil {
+		panic(err)
+	}
+	return ops
+}
@@ -0,0 +1,66 @@
+package rokka
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	base := Resize().Width(300)
+	fill, err := base.Height(200).Mode(ResizeModeFill).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ResizeOperation{Width: IntPtr(300), Height: IntPtr(200), Mode: StrPtr(ResizeModeFill)}
+	if !reflect.DeepEqual(fill, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, fill)
+	}
+
+	// the base builder must not be changed by deriving another builder from it.
+	op, err := base.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(op, ResizeOperation{Width: IntPtr(300)}) {
+		t.Errorf("Expected base builder to be unchanged, got %+v", op)
+	}
+
+	if _, err := Resize().Mode("stretch").Build(); err == nil {
+		t.Error("Expected invalid operation to return an error")
+	}
+}
+
+func TestChain(t *testing.T) {
+	c := NewClient(&Config{ImageHost: "https://{{organization}}.rokka.io"})
+
+	ops, err := Chain(Resize().Width(300).Mode(ResizeModeFill)).Then(Grayscale()).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := c.GetURL("test", "c1b110", "jpg", ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "https://test.rokka.io/dynamic/resize-mode-fill-width-300--grayscale/c1b110.jpg"
+	if u != expected {
+		t.Errorf("Expected URL '%s', got '%s'", expected, u)
+	}
+}
+
+func TestChain_Invalid(t *testing.T) {
+	_, err := Chain(Resize(), Grayscale(), Rotate().Angle(400), nil).Build()
+	if err == nil {
+		t.Fatal("Expected invalid chain to return an error")
+	}
+	expected := `rokka: invalid operations: operation 0: rokka: invalid operation resize: one of "[width height]" is required; operation 2: rokka: invalid operation rotate: option "Angle" must be at most 360, got 400; operation 3 is nil`
+	if err.Error() != expected {
+		t.Errorf("Expected error '%s', got '%s'", expected, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected MustBuild to panic")
+		}
+	}()
+	Chain(Resize()).MustBuild()
+}
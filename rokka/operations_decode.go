@@ -0,0 +1,179 @@
+package rokka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DecodeWarning describes an option of an operation which couldn't be decoded into the field of the operation,
+// e.g. because rokka returned a legacy value of a different type. Instead of dropping the option, the whole operation
+// is kept as *RawOperation containing the options as returned by rokka. Saving the stack again therefore doesn't
+// change it.
+type DecodeWarning struct {
+	// Stack is the name of the stack, if known.
+	Stack string
+	// Index is the position of the operation within the stack.
+	Index     int
+	Operation string
+	Option    string
+	// Value is the value of the option as returned by rokka.
+	Value   json.RawMessage
+	Message string
+}
+
+func (w DecodeWarning) String() string {
+	prefix := ""
+	if w.Stack != "" {
+		prefix = "stack " + w.Stack + ": "
+	}
+	return fmt.Sprintf("%soperation %d (%s): option \"%s\" kept as %s: %s", prefix, w.Index, w.Operation, w.Option, w.Value, w.Message)
+}
+
+// decodeOperations decodes the operations of a stack. Options which aren't of the type of their field are coerced
+// if they use a known legacy representation, e.g. numbers or booleans as string. Operations containing options
+// which can't be coerced are kept as *RawOperation and reported as warnings.
+func decodeOperations(data []byte) (Operations, []DecodeWarning, error) {
+	raw := make([]rawStack, 0)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	ops := make(Operations, 0, len(raw))
+	warnings := make([]DecodeWarning, 0)
+	for i, v := range raw {
+		op, err := NewOperationByName(v.Name)
+		if err != nil {
+			// keep operations unknown to this version of the client as they are, see RawOperation.
+			ops = append(ops, &RawOperation{OperationName: v.Name, Options: v.Options})
+			continue
+		}
+		if opWarnings := decodeOperationOptions(op, v.Options); len(opWarnings) > 0 {
+			for _, w := range opWarnings {
+				w.Index = i
+				w.Operation = v.Name
+				warnings = append(warnings, w)
+			}
+			ops = append(ops, &RawOperation{OperationName: v.Name, Options: v.Options})
+			continue
+		}
+		ops = append(ops, op.(Operation))
+	}
+	return ops, warnings, nil
+}
+
+// decodeOperationOptions sets the options of op and returns a warning for every option which couldn't be set.
+func decodeOperationOptions(op interface{}, options json.RawMessage) []DecodeWarning {
+	if len(bytes.TrimSpace(options)) == 0 || bytes.Equal(bytes.TrimSpace(options), []byte("null")) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(options))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(op); err == nil {
+		return nil
+	}
+
+	values := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(options, &values); err != nil {
+		return []DecodeWarning{{Value: options, Message: "options are not an object"}}
+	}
+
+	s := reflect.ValueOf(op).Elem()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < s.NumField(); i++ {
+		name := strings.Split(s.Type().Field(i).Tag.Get("json"), ",")[0]
+		fields[name] = s.Field(i)
+	}
+
+	warnings := make([]DecodeWarning, 0)
+	for _, k := range sortedKeys(values) {
+		f, ok := fields[k]
+		if !ok {
+			warnings = append(warnings, DecodeWarning{Option: k, Value: values[k], Message: "unknown option"})
+			continue
+		}
+		if err := json.Unmarshal(values[k], f.Addr().Interface()); err == nil {
+			continue
+		}
+		if err := coerceOptionValue(f, values[k]); err != nil {
+			warnings = append(warnings, DecodeWarning{Option: k, Value: values[k], Message: err.Error()})
+		}
+	}
+	return warnings
+}
+
+// coerceOptionValue converts legacy representations of a value to the type of the field and sets it.
+func coerceOptionValue(f reflect.Value, value json.RawMessage) error {
+	typ := f.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	var coerced interface{}
+	switch typ.Kind() {
+	case reflect.Int:
+		if n, ok := coerceFloat(v); ok && n == math.Trunc(n) {
+			coerced = int(n)
+		}
+	case reflect.Float64:
+		if n, ok := coerceFloat(v); ok {
+			coerced = n
+		}
+	case reflect.Bool:
+		if s, ok := v.(string); ok && (s == "true" || s == "false") {
+			coerced = s == "true"
+		}
+	case reflect.String:
+		if n, ok := v.(json.Number); ok {
+			coerced = n.String()
+		}
+	}
+	if coerced == nil {
+		return fmt.Errorf("can't convert to %s", typ.Kind())
+	}
+
+	b, err := json.Marshal(coerced)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, f.Addr().Interface())
+}
+
+// coerceFloat returns the value of numbers and numeric strings.
+func coerceFloat(v interface{}) (float64, bool) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case string:
+		s = strings.TrimSpace(t)
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package rokka
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestDecodeOperations_Coerce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Operation
+	}{
+		{`[{"name":"resize","options":{"width":"200","height":" 100 "}}]`, &ResizeOperation{Width: IntPtr(200), Height: IntPtr(100)}},
+		{`[{"name":"resize","options":{"width":200.0,"upscale":"true"}}]`, &ResizeOperation{Width: IntPtr(200), Upscale: BoolPtr(true)}},
+		{`[{"name":"rotate","options":{"angle":"45.5"}}]`, &RotateOperation{Angle: Float64Ptr(45.5)}},
+		{`[{"name":"rotate","options":{"angle":90,"background_color":"000000"}}]`, &RotateOperation{Angle: Float64Ptr(90), BackgroundColor: StrPtr("000000")}},
+	}
+	for _, tt := range tests {
+		ops, warnings, err := decodeOperations([]byte(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("Expected no warnings for %s, got %v", tt.input, warnings)
+		}
+		if !reflect.DeepEqual(ops[0], tt.expected) {
+			t.Errorf("Expected %s to be decoded to %+v, got %+v", tt.input, tt.expected, ops[0])
+		}
+	}
+}
+
+func TestDecodeOperations_KeepUncoercible(t *testing.T) {
+	input := `[{"name":"resize","options":{"height":"auto","size":10,"width":"200"}},{"name":"grayscale","options":{}}]`
+
+	ops, warnings, err := decodeOperations([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ops[0].(*RawOperation); !ok {
+		t.Errorf("Expected operation of type '%T', got '%T'", new(RawOperation), ops[0])
+	}
+	if _, ok := ops[1].(*GrayscaleOperation); !ok {
+		t.Errorf("Expected operation of type '%T', got '%T'", new(GrayscaleOperation), ops[1])
+	}
+
+	expected := []DecodeWarning{
+		{Index: 0, Operation: "resize", Option: "height", Value: json.RawMessage(`"auto"`), Message: "can't convert to int"},
+		{Index: 0, Operation: "resize", Option: "size", Value: json.RawMessage(`10`), Message: "unknown option"},
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, warnings)
+	}
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != input {
+		t.Errorf("Expected operations to be kept as '%s', got '%s'", input, b)
+	}
+}
+
+func TestListStacks_Warnings(t *testing.T) {
+	org := "test-org"
+	r := test.NewResponse(http.StatusOK, "./fixtures/ListStacksWithLegacyOptions.json")
+	ts := test.NewMockAPI(t, test.Routes{"GET /stacks/" + org: r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL, StrictOperations: true})
+	res, err := c.ListStacks(org)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops := res.Items[0].StackOperations
+	if op, ok := ops[0].(*ResizeOperation); !ok || *op.Width != 200 || *op.Upscale {
+		t.Errorf("Expected legacy options of resize to be converted, got %+v", ops[0])
+	}
+	if _, ok := ops[1].(*RawOperation); !ok {
+		t.Errorf("Expected operation of type '%T', got '%T'", new(RawOperation), ops[1])
+	}
+
+	if len(res.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", res.Warnings)
+	}
+	expected := `stack legacy: operation 1 (rotate): option "angle" kept as "ninety": can't convert to float64`
+	if res.Warnings[0].String() != expected {
+		t.Errorf("Expected warning '%s', got '%s'", expected, res.Warnings[0])
+	}
+	if !reflect.DeepEqual(res.Items[0].Warnings, res.Warnings) {
+		t.Errorf("Expected the warnings to be kept in the stack, got %v", res.Items[0].Warnings)
+	}
+}
+
+func TestStack_UnmarshalJSONError(t *testing.T) {
+	var res ListStacksResponse
+	if err := json.Unmarshal([]byte(`{"items": [{"name": "invalid", "stack_operations": {"name": "resize"}}]}`), &res); err == nil {
+		t.Errorf("Expected invalid operations to return an error, got %+v", res)
+	}
+}
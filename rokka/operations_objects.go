@@ -1,12 +1,14 @@
 package rokka
 
 // Code generated by go generate; DO NOT EDIT.
-// This file was generated at 2019-03-22 11:38:02.046294 +0100 CET m=+0.270711504
+// This file was generated from the operations schema version reconstructed (sha256 2b902ea20c1bca39f3bab24d7ec8b6d413c997ef6c44f117c4570ef321467f5a).
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -14,8 +16,8 @@ import (
 type Operation interface {
 	// Name returns the operation's name known by the API.
 	Name() string
-	// Validate checks if required properties are set.
-	// Otherwise it returns false with an error indicating the missing property.
+	// Validate checks if required properties are set and the values of the options are allowed.
+	// Otherwise it returns false with an *OperationValidationError listing every invalid option.
 	Validate() (bool, error)
 	// toURLPath generates a part of the URL used for dynamic rendering of a stack.
 	toURLPath() string
@@ -30,37 +32,61 @@ type rawStack struct {
 // the correct operation types for JSON.
 type Operations []Operation
 
-// UnmarshalJSON implements json.Unmarshaler.
+// UnmarshalJSON implements json.Unmarshaler. Unknown operations as well as operations containing options which
+// can't be decoded are kept as *RawOperation. Options using legacy representations, e.g. numbers as string, are
+// converted to the type of their field.
 func (o *Operations) UnmarshalJSON(data []byte) error {
-	ops := make([]rawStack, 0)
-	if err := json.Unmarshal(data, &ops); err != nil {
+	ops, _, err := decodeOperations(data)
+	if err != nil {
 		return err
 	}
-	for _, v := range ops {
-		op, err := NewOperationByName(v.Name)
-		if err != nil {
-			return err
-		}
-		*o = append(*o, op.(Operation))
-		if err := json.Unmarshal(v.Options, op); err != nil {
-			// BUG(mweibel): We continue here when such an error is reached because rokka sometimes (legacy reasons)
-			//               has options on an operation which are not of the correct type. Should we write something to stdout? also not nice though..
-			continue
-		}
-	}
+	*o = append(*o, ops...)
 	return nil
 }
 
-// MarshalJSON implements json.Marshaler
+// MarshalJSON implements json.Marshaler. The result is canonical: every operation is an object containing
+// the name followed by the options, whose keys are sorted. Marshalling equal operations therefore always results
+// in identical bytes.
 func (o Operations) MarshalJSON() ([]byte, error) {
-	ops := make([]map[string]interface{}, len(o))
+	var b bytes.Buffer
+	b.WriteByte('[')
 	for i, v := range o {
-		ops[i] = make(map[string]interface{})
-		ops[i]["name"] = v.Name()
-		ops[i]["options"] = v
+		if v == nil {
+			return nil, fmt.Errorf("rokka: operation %d is nil", i)
+		}
+		name, err := json.Marshal(v.Name())
+		if err != nil {
+			return nil, err
+		}
+		options, err := canonicalOptions(v)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(`{"name":`)
+		b.Write(name)
+		b.WriteString(`,"options":`)
+		b.Write(options)
+		b.WriteByte('}')
 	}
+	b.WriteByte(']')
+
+	return b.Bytes(), nil
+}
 
-	return json.Marshal(ops)
+// canonicalOptions marshals the options of an operation with sorted keys. Numbers are kept as they are.
+func canonicalOptions(op Operation) ([]byte, error) {
+	raw, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return nil, err
+	}
+	return json.Marshal(options)
 }
 
 var errOperationNotImplemented = errors.New("Operation not implemented")
@@ -120,6 +146,14 @@ func NewOperationByName(name string) (Operation, error) {
 	return nil, errOperationNotImplemented
 }
 
+// DeprecatedOperations returns the operations deprecated by rokka by name with the note of the operations schema,
+// e.g. what to use instead.
+func DeprecatedOperations() map[string]string {
+	return map[string]string{
+		"noop": "Use an empty stack operations collection to get the same behaviour",
+	}
+}
+
 // AddframesOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -156,6 +190,44 @@ func (o AddframesOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// AddframesBuilder builds AddframesOperation values without the need of pointers, see Addframes.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type AddframesBuilder struct {
+	op AddframesOperation
+}
+
+// Addframes returns a new AddframesBuilder to build AddframesOperation values.
+func Addframes() AddframesBuilder { return AddframesBuilder{} }
+
+// Delay sets AddframesOperation.Delay.
+func (b AddframesBuilder) Delay(v float64) AddframesBuilder {
+	b.op.Delay = &v
+	return b
+}
+
+// Enabled sets AddframesOperation.Enabled.
+func (b AddframesBuilder) Enabled(v bool) AddframesBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Frames sets AddframesOperation.Frames.
+func (b AddframesBuilder) Frames(v string) AddframesBuilder {
+	b.op.Frames = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b AddframesBuilder) Build() (AddframesOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b AddframesBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // AlphaOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -168,9 +240,23 @@ type AlphaOperation struct {
 // Name implements rokka.Operation.Name
 func (o AlphaOperation) Name() string { return "alpha" }
 
+// Values of AlphaOperation.Mode.
+const (
+	AlphaModeMask    = "mask"
+	AlphaModeRemove  = "remove"
+	AlphaModeExtract = "extract"
+)
+
+// AlphaModeValues lists the allowed values of AlphaOperation.Mode.
+var AlphaModeValues = []string{"mask", "remove", "extract"}
+
 // Validate implements rokka.Operation.Validate.
 func (o AlphaOperation) Validate() (bool, error) {
-	return true, nil
+	v := newOperationValidator(o.Name())
+	if o.Mode != nil {
+		v.enum("Mode", *o.Mode, AlphaModeValues)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -192,6 +278,44 @@ func (o AlphaOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// AlphaBuilder builds AlphaOperation values without the need of pointers, see Alpha.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type AlphaBuilder struct {
+	op AlphaOperation
+}
+
+// Alpha returns a new AlphaBuilder to build AlphaOperation values.
+func Alpha() AlphaBuilder { return AlphaBuilder{} }
+
+// Enabled sets AlphaOperation.Enabled.
+func (b AlphaBuilder) Enabled(v bool) AlphaBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Mode sets AlphaOperation.Mode.
+func (b AlphaBuilder) Mode(v string) AlphaBuilder {
+	b.op.Mode = &v
+	return b
+}
+
+// Opacity sets AlphaOperation.Opacity.
+func (b AlphaBuilder) Opacity(v int) AlphaBuilder {
+	b.op.Opacity = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b AlphaBuilder) Build() (AlphaOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b AlphaBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // AutorotateOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -205,9 +329,30 @@ type AutorotateOperation struct {
 // Name implements rokka.Operation.Name
 func (o AutorotateOperation) Name() string { return "autorotate" }
 
+// Values of AutorotateOperation.RotationDirection.
+const (
+	AutorotateRotationDirectionClockwise        = "clockwise"
+	AutorotateRotationDirectionCounterclockwise = "counterclockwise"
+)
+
+// AutorotateRotationDirectionValues lists the allowed values of AutorotateOperation.RotationDirection.
+var AutorotateRotationDirectionValues = []string{"clockwise", "counterclockwise"}
+
 // Validate implements rokka.Operation.Validate.
 func (o AutorotateOperation) Validate() (bool, error) {
-	return true, nil
+	v := newOperationValidator(o.Name())
+	if o.Height != nil {
+		v.minimum("Height", float64(*o.Height), 1)
+		v.maximum("Height", float64(*o.Height), 10000)
+	}
+	if o.RotationDirection != nil {
+		v.enum("RotationDirection", *o.RotationDirection, AutorotateRotationDirectionValues)
+	}
+	if o.Width != nil {
+		v.minimum("Width", float64(*o.Width), 1)
+		v.maximum("Width", float64(*o.Width), 10000)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -232,6 +377,50 @@ func (o AutorotateOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// AutorotateBuilder builds AutorotateOperation values without the need of pointers, see Autorotate.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type AutorotateBuilder struct {
+	op AutorotateOperation
+}
+
+// Autorotate returns a new AutorotateBuilder to build AutorotateOperation values.
+func Autorotate() AutorotateBuilder { return AutorotateBuilder{} }
+
+// Enabled sets AutorotateOperation.Enabled.
+func (b AutorotateBuilder) Enabled(v bool) AutorotateBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Height sets AutorotateOperation.Height.
+func (b AutorotateBuilder) Height(v int) AutorotateBuilder {
+	b.op.Height = &v
+	return b
+}
+
+// RotationDirection sets AutorotateOperation.RotationDirection.
+func (b AutorotateBuilder) RotationDirection(v string) AutorotateBuilder {
+	b.op.RotationDirection = &v
+	return b
+}
+
+// Width sets AutorotateOperation.Width.
+func (b AutorotateBuilder) Width(v int) AutorotateBuilder {
+	b.op.Width = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b AutorotateBuilder) Build() (AutorotateOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b AutorotateBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // BlurOperation is an auto-generated Operation as specified by the rokka API.
 // Calling .Validate() will return false if required properties are missing.
 //
@@ -246,10 +435,13 @@ func (o BlurOperation) Name() string { return "blur" }
 
 // Validate implements rokka.Operation.Validate.
 func (o BlurOperation) Validate() (bool, error) {
-	if o.Sigma == nil {
-		return false, errors.New("option \"Sigma\" is required")
+	v := newOperationValidator(o.Name())
+	v.required("Sigma", o.Sigma != nil)
+	if o.Sigma != nil {
+		v.minimum("Sigma", *o.Sigma, 0)
+		v.maximum("Sigma", *o.Sigma, 100)
 	}
-	return true, nil
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -268,6 +460,38 @@ func (o BlurOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// BlurBuilder builds BlurOperation values without the need of pointers, see Blur.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type BlurBuilder struct {
+	op BlurOperation
+}
+
+// Blur returns a new BlurBuilder to build BlurOperation values.
+func Blur() BlurBuilder { return BlurBuilder{} }
+
+// Enabled sets BlurOperation.Enabled.
+func (b BlurBuilder) Enabled(v bool) BlurBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Sigma sets BlurOperation.Sigma.
+func (b BlurBuilder) Sigma(v float64) BlurBuilder {
+	b.op.Sigma = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b BlurBuilder) Build() (BlurOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b BlurBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // CompositionOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -287,9 +511,43 @@ type CompositionOperation struct {
 // Name implements rokka.Operation.Name
 func (o CompositionOperation) Name() string { return "composition" }
 
+var compositionAnchorPattern = regexp.MustCompile("^(?:\\b(subjectarea|smart|face|auto|((center|left|right|(0|[1-9][0-9]{0,3}|10000))[_-](center|top|bottom|(0|[1-9][0-9]{0,3}|10000))))\\b)$")
+
+// Values of CompositionOperation.Mode.
+const (
+	CompositionModeForeground = "foreground"
+)
+
+// CompositionModeValues lists the allowed values of CompositionOperation.Mode.
+var CompositionModeValues = []string{"foreground"}
+
+var compositionSecondaryColorPattern = regexp.MustCompile("^(?:[0-9a-fA-F]{6})$")
+
 // Validate implements rokka.Operation.Validate.
 func (o CompositionOperation) Validate() (bool, error) {
-	return true, nil
+	v := newOperationValidator(o.Name())
+	if o.Anchor != nil {
+		v.pattern("Anchor", *o.Anchor, compositionAnchorPattern)
+	}
+	if o.Height != nil {
+		v.minimum("Height", float64(*o.Height), 1)
+		v.maximum("Height", float64(*o.Height), 10000)
+	}
+	if o.Mode != nil {
+		v.enum("Mode", *o.Mode, CompositionModeValues)
+	}
+	if o.SecondaryColor != nil {
+		v.pattern("SecondaryColor", *o.SecondaryColor, compositionSecondaryColorPattern)
+	}
+	if o.SecondaryOpacity != nil {
+		v.minimum("SecondaryOpacity", float64(*o.SecondaryOpacity), 0)
+		v.maximum("SecondaryOpacity", float64(*o.SecondaryOpacity), 100)
+	}
+	if o.Width != nil {
+		v.minimum("Width", float64(*o.Width), 1)
+		v.maximum("Width", float64(*o.Width), 10000)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -332,6 +590,86 @@ func (o CompositionOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// CompositionBuilder builds CompositionOperation values without the need of pointers, see Composition.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type CompositionBuilder struct {
+	op CompositionOperation
+}
+
+// Composition returns a new CompositionBuilder to build CompositionOperation values.
+func Composition() CompositionBuilder { return CompositionBuilder{} }
+
+// Anchor sets CompositionOperation.Anchor.
+func (b CompositionBuilder) Anchor(v string) CompositionBuilder {
+	b.op.Anchor = &v
+	return b
+}
+
+// Enabled sets CompositionOperation.Enabled.
+func (b CompositionBuilder) Enabled(v bool) CompositionBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Height sets CompositionOperation.Height.
+func (b CompositionBuilder) Height(v int) CompositionBuilder {
+	b.op.Height = &v
+	return b
+}
+
+// Mode sets CompositionOperation.Mode.
+func (b CompositionBuilder) Mode(v string) CompositionBuilder {
+	b.op.Mode = &v
+	return b
+}
+
+// ResizeMode sets CompositionOperation.ResizeMode.
+func (b CompositionBuilder) ResizeMode(v string) CompositionBuilder {
+	b.op.ResizeMode = &v
+	return b
+}
+
+// ResizeToPrimary sets CompositionOperation.ResizeToPrimary.
+func (b CompositionBuilder) ResizeToPrimary(v bool) CompositionBuilder {
+	b.op.ResizeToPrimary = &v
+	return b
+}
+
+// SecondaryColor sets CompositionOperation.SecondaryColor.
+func (b CompositionBuilder) SecondaryColor(v string) CompositionBuilder {
+	b.op.SecondaryColor = &v
+	return b
+}
+
+// SecondaryImage sets CompositionOperation.SecondaryImage.
+func (b CompositionBuilder) SecondaryImage(v string) CompositionBuilder {
+	b.op.SecondaryImage = &v
+	return b
+}
+
+// SecondaryOpacity sets CompositionOperation.SecondaryOpacity.
+func (b CompositionBuilder) SecondaryOpacity(v int) CompositionBuilder {
+	b.op.SecondaryOpacity = &v
+	return b
+}
+
+// Width sets CompositionOperation.Width.
+func (b CompositionBuilder) Width(v int) CompositionBuilder {
+	b.op.Width = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b CompositionBuilder) Build() (CompositionOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b CompositionBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // CropOperation is an auto-generated Operation as specified by the rokka API.
 // Calling .Validate() will return false if required properties are missing.
 //
@@ -350,15 +688,41 @@ type CropOperation struct {
 // Name implements rokka.Operation.Name
 func (o CropOperation) Name() string { return "crop" }
 
+var cropAnchorPattern = regexp.MustCompile("^(?:\\b(subjectarea|smart|face|auto|((center|left|right|(0|[1-9][0-9]{0,3}|10000))[_-](center|top|bottom|(0|[1-9][0-9]{0,3}|10000))))\\b)$")
+
+// Values of CropOperation.Mode.
+const (
+	CropModeAbsolute = "absolute"
+	CropModeRatio    = "ratio"
+)
+
+// CropModeValues lists the allowed values of CropOperation.Mode.
+var CropModeValues = []string{"absolute", "ratio"}
+
 // Validate implements rokka.Operation.Validate.
 func (o CropOperation) Validate() (bool, error) {
-	if o.Width == nil {
-		return false, errors.New("option \"Width\" is required")
+	v := newOperationValidator(o.Name())
+	v.required("Width", o.Width != nil)
+	v.required("Height", o.Height != nil)
+	if o.Anchor != nil {
+		v.pattern("Anchor", *o.Anchor, cropAnchorPattern)
 	}
-	if o.Height == nil {
-		return false, errors.New("option \"Height\" is required")
+	if o.Height != nil {
+		v.minimum("Height", float64(*o.Height), 1)
+		v.maximum("Height", float64(*o.Height), 10000)
 	}
-	return true, nil
+	if o.Mode != nil {
+		v.enum("Mode", *o.Mode, CropModeValues)
+	}
+	if o.Scale != nil {
+		v.minimum("Scale", *o.Scale, 0)
+		v.maximum("Scale", *o.Scale, 100)
+	}
+	if o.Width != nil {
+		v.minimum("Width", float64(*o.Width), 1)
+		v.maximum("Width", float64(*o.Width), 10000)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -395,6 +759,74 @@ func (o CropOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// CropBuilder builds CropOperation values without the need of pointers, see Crop.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type CropBuilder struct {
+	op CropOperation
+}
+
+// Crop returns a new CropBuilder to build CropOperation values.
+func Crop() CropBuilder { return CropBuilder{} }
+
+// Anchor sets CropOperation.Anchor.
+func (b CropBuilder) Anchor(v string) CropBuilder {
+	b.op.Anchor = &v
+	return b
+}
+
+// Area sets CropOperation.Area.
+func (b CropBuilder) Area(v string) CropBuilder {
+	b.op.Area = &v
+	return b
+}
+
+// Enabled sets CropOperation.Enabled.
+func (b CropBuilder) Enabled(v bool) CropBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Fallback sets CropOperation.Fallback.
+func (b CropBuilder) Fallback(v string) CropBuilder {
+	b.op.Fallback = &v
+	return b
+}
+
+// Height sets CropOperation.Height.
+func (b CropBuilder) Height(v int) CropBuilder {
+	b.op.Height = &v
+	return b
+}
+
+// Mode sets CropOperation.Mode.
+func (b CropBuilder) Mode(v string) CropBuilder {
+	b.op.Mode = &v
+	return b
+}
+
+// Scale sets CropOperation.Scale.
+func (b CropBuilder) Scale(v float64) CropBuilder {
+	b.op.Scale = &v
+	return b
+}
+
+// Width sets CropOperation.Width.
+func (b CropBuilder) Width(v int) CropBuilder {
+	b.op.Width = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b CropBuilder) Build() (CropOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b CropBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // DropshadowOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -411,9 +843,35 @@ type DropshadowOperation struct {
 // Name implements rokka.Operation.Name
 func (o DropshadowOperation) Name() string { return "dropshadow" }
 
+var dropshadowColorPattern = regexp.MustCompile("^(?:[0-9a-fA-F]{6})$")
+
 // Validate implements rokka.Operation.Validate.
 func (o DropshadowOperation) Validate() (bool, error) {
-	return true, nil
+	v := newOperationValidator(o.Name())
+	if o.BlurRadius != nil {
+		v.minimum("BlurRadius", *o.BlurRadius, 0)
+		v.maximum("BlurRadius", *o.BlurRadius, 10000)
+	}
+	if o.Color != nil {
+		v.pattern("Color", *o.Color, dropshadowColorPattern)
+	}
+	if o.Horizontal != nil {
+		v.minimum("Horizontal", float64(*o.Horizontal), -100)
+		v.maximum("Horizontal", float64(*o.Horizontal), 100)
+	}
+	if o.Opacity != nil {
+		v.minimum("Opacity", float64(*o.Opacity), 0)
+		v.maximum("Opacity", float64(*o.Opacity), 100)
+	}
+	if o.Sigma != nil {
+		v.minimum("Sigma", *o.Sigma, 0)
+		v.maximum("Sigma", *o.Sigma, 10000)
+	}
+	if o.Vertical != nil {
+		v.minimum("Vertical", float64(*o.Vertical), -100)
+		v.maximum("Vertical", float64(*o.Vertical), 100)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -447,6 +905,68 @@ func (o DropshadowOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// DropshadowBuilder builds DropshadowOperation values without the need of pointers, see Dropshadow.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type DropshadowBuilder struct {
+	op DropshadowOperation
+}
+
+// Dropshadow returns a new DropshadowBuilder to build DropshadowOperation values.
+func Dropshadow() DropshadowBuilder { return DropshadowBuilder{} }
+
+// BlurRadius sets DropshadowOperation.BlurRadius.
+func (b DropshadowBuilder) BlurRadius(v float64) DropshadowBuilder {
+	b.op.BlurRadius = &v
+	return b
+}
+
+// Color sets DropshadowOperation.Color.
+func (b DropshadowBuilder) Color(v string) DropshadowBuilder {
+	b.op.Color = &v
+	return b
+}
+
+// Enabled sets DropshadowOperation.Enabled.
+func (b DropshadowBuilder) Enabled(v bool) DropshadowBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Horizontal sets DropshadowOperation.Horizontal.
+func (b DropshadowBuilder) Horizontal(v int) DropshadowBuilder {
+	b.op.Horizontal = &v
+	return b
+}
+
+// Opacity sets DropshadowOperation.Opacity.
+func (b DropshadowBuilder) Opacity(v int) DropshadowBuilder {
+	b.op.Opacity = &v
+	return b
+}
+
+// Sigma sets DropshadowOperation.Sigma.
+func (b DropshadowBuilder) Sigma(v float64) DropshadowBuilder {
+	b.op.Sigma = &v
+	return b
+}
+
+// Vertical sets DropshadowOperation.Vertical.
+func (b DropshadowBuilder) Vertical(v int) DropshadowBuilder {
+	b.op.Vertical = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b DropshadowBuilder) Build() (DropshadowOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b DropshadowBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // GlitchOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -483,6 +1003,44 @@ func (o GlitchOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// GlitchBuilder builds GlitchOperation values without the need of pointers, see Glitch.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type GlitchBuilder struct {
+	op GlitchOperation
+}
+
+// Glitch returns a new GlitchBuilder to build GlitchOperation values.
+func Glitch() GlitchBuilder { return GlitchBuilder{} }
+
+// Amount sets GlitchOperation.Amount.
+func (b GlitchBuilder) Amount(v int) GlitchBuilder {
+	b.op.Amount = &v
+	return b
+}
+
+// Enabled sets GlitchOperation.Enabled.
+func (b GlitchBuilder) Enabled(v bool) GlitchBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Random sets GlitchOperation.Random.
+func (b GlitchBuilder) Random(v string) GlitchBuilder {
+	b.op.Random = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b GlitchBuilder) Build() (GlitchOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b GlitchBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // GrayscaleOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -511,6 +1069,32 @@ func (o GrayscaleOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// GrayscaleBuilder builds GrayscaleOperation values without the need of pointers, see Grayscale.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type GrayscaleBuilder struct {
+	op GrayscaleOperation
+}
+
+// Grayscale returns a new GrayscaleBuilder to build GrayscaleOperation values.
+func Grayscale() GrayscaleBuilder { return GrayscaleBuilder{} }
+
+// Enabled sets GrayscaleOperation.Enabled.
+func (b GrayscaleBuilder) Enabled(v bool) GrayscaleBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b GrayscaleBuilder) Build() (GrayscaleOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b GrayscaleBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // ModulateOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -551,6 +1135,50 @@ func (o ModulateOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// ModulateBuilder builds ModulateOperation values without the need of pointers, see Modulate.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type ModulateBuilder struct {
+	op ModulateOperation
+}
+
+// Modulate returns a new ModulateBuilder to build ModulateOperation values.
+func Modulate() ModulateBuilder { return ModulateBuilder{} }
+
+// Brightness sets ModulateOperation.Brightness.
+func (b ModulateBuilder) Brightness(v int) ModulateBuilder {
+	b.op.Brightness = &v
+	return b
+}
+
+// Enabled sets ModulateOperation.Enabled.
+func (b ModulateBuilder) Enabled(v bool) ModulateBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Hue sets ModulateOperation.Hue.
+func (b ModulateBuilder) Hue(v int) ModulateBuilder {
+	b.op.Hue = &v
+	return b
+}
+
+// Saturation sets ModulateOperation.Saturation.
+func (b ModulateBuilder) Saturation(v int) ModulateBuilder {
+	b.op.Saturation = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b ModulateBuilder) Build() (ModulateOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b ModulateBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // NoopOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -579,6 +1207,32 @@ func (o NoopOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// NoopBuilder builds NoopOperation values without the need of pointers, see Noop.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type NoopBuilder struct {
+	op NoopOperation
+}
+
+// Noop returns a new NoopBuilder to build NoopOperation values.
+func Noop() NoopBuilder { return NoopBuilder{} }
+
+// Enabled sets NoopOperation.Enabled.
+func (b NoopBuilder) Enabled(v bool) NoopBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b NoopBuilder) Build() (NoopOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b NoopBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // PrimitiveOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -593,7 +1247,16 @@ func (o PrimitiveOperation) Name() string { return "primitive" }
 
 // Validate implements rokka.Operation.Validate.
 func (o PrimitiveOperation) Validate() (bool, error) {
-	return true, nil
+	v := newOperationValidator(o.Name())
+	if o.Count != nil {
+		v.minimum("Count", float64(*o.Count), 0)
+		v.maximum("Count", float64(*o.Count), 100)
+	}
+	if o.Mode != nil {
+		v.minimum("Mode", float64(*o.Mode), 0)
+		v.maximum("Mode", float64(*o.Mode), 8)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -615,6 +1278,44 @@ func (o PrimitiveOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// PrimitiveBuilder builds PrimitiveOperation values without the need of pointers, see Primitive.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type PrimitiveBuilder struct {
+	op PrimitiveOperation
+}
+
+// Primitive returns a new PrimitiveBuilder to build PrimitiveOperation values.
+func Primitive() PrimitiveBuilder { return PrimitiveBuilder{} }
+
+// Count sets PrimitiveOperation.Count.
+func (b PrimitiveBuilder) Count(v int) PrimitiveBuilder {
+	b.op.Count = &v
+	return b
+}
+
+// Enabled sets PrimitiveOperation.Enabled.
+func (b PrimitiveBuilder) Enabled(v bool) PrimitiveBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Mode sets PrimitiveOperation.Mode.
+func (b PrimitiveBuilder) Mode(v int) PrimitiveBuilder {
+	b.op.Mode = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b PrimitiveBuilder) Build() (PrimitiveOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b PrimitiveBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // ResizeOperation is an auto-generated Operation as specified by the rokka API.
 // Calling .Validate() will return false if required properties are missing.
 //
@@ -631,19 +1332,32 @@ type ResizeOperation struct {
 // Name implements rokka.Operation.Name
 func (o ResizeOperation) Name() string { return "resize" }
 
+// Values of ResizeOperation.Mode.
+const (
+	ResizeModeBox      = "box"
+	ResizeModeAbsolute = "absolute"
+	ResizeModeFill     = "fill"
+)
+
+// ResizeModeValues lists the allowed values of ResizeOperation.Mode.
+var ResizeModeValues = []string{"box", "absolute", "fill"}
+
 // Validate implements rokka.Operation.Validate.
 func (o ResizeOperation) Validate() (bool, error) {
-	valid := false
-	if o.Width != nil {
-		valid = true
-	}
+	v := newOperationValidator(o.Name())
+	v.oneOf([]string{"width", "height"}, o.Width != nil, o.Height != nil)
 	if o.Height != nil {
-		valid = true
+		v.minimum("Height", float64(*o.Height), 1)
+		v.maximum("Height", float64(*o.Height), 10000)
 	}
-	if !valid {
-		return false, errors.New("one of \"[width height]\" is required")
+	if o.Mode != nil {
+		v.enum("Mode", *o.Mode, ResizeModeValues)
 	}
-	return true, nil
+	if o.Width != nil {
+		v.minimum("Width", float64(*o.Width), 1)
+		v.maximum("Width", float64(*o.Width), 10000)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -674,6 +1388,62 @@ func (o ResizeOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// ResizeBuilder builds ResizeOperation values without the need of pointers, see Resize.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type ResizeBuilder struct {
+	op ResizeOperation
+}
+
+// Resize returns a new ResizeBuilder to build ResizeOperation values.
+func Resize() ResizeBuilder { return ResizeBuilder{} }
+
+// Enabled sets ResizeOperation.Enabled.
+func (b ResizeBuilder) Enabled(v bool) ResizeBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Height sets ResizeOperation.Height.
+func (b ResizeBuilder) Height(v int) ResizeBuilder {
+	b.op.Height = &v
+	return b
+}
+
+// Mode sets ResizeOperation.Mode.
+func (b ResizeBuilder) Mode(v string) ResizeBuilder {
+	b.op.Mode = &v
+	return b
+}
+
+// Upscale sets ResizeOperation.Upscale.
+func (b ResizeBuilder) Upscale(v bool) ResizeBuilder {
+	b.op.Upscale = &v
+	return b
+}
+
+// UpscaleDpr sets ResizeOperation.UpscaleDpr.
+func (b ResizeBuilder) UpscaleDpr(v bool) ResizeBuilder {
+	b.op.UpscaleDpr = &v
+	return b
+}
+
+// Width sets ResizeOperation.Width.
+func (b ResizeBuilder) Width(v int) ResizeBuilder {
+	b.op.Width = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b ResizeBuilder) Build() (ResizeOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b ResizeBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // RotateOperation is an auto-generated Operation as specified by the rokka API.
 // Calling .Validate() will return false if required properties are missing.
 //
@@ -688,12 +1458,24 @@ type RotateOperation struct {
 // Name implements rokka.Operation.Name
 func (o RotateOperation) Name() string { return "rotate" }
 
+var rotateBackgroundColorPattern = regexp.MustCompile("^(?:[0-9a-fA-F]{6})$")
+
 // Validate implements rokka.Operation.Validate.
 func (o RotateOperation) Validate() (bool, error) {
-	if o.Angle == nil {
-		return false, errors.New("option \"Angle\" is required")
+	v := newOperationValidator(o.Name())
+	v.required("Angle", o.Angle != nil)
+	if o.Angle != nil {
+		v.minimum("Angle", *o.Angle, 0)
+		v.maximum("Angle", *o.Angle, 360)
 	}
-	return true, nil
+	if o.BackgroundColor != nil {
+		v.pattern("BackgroundColor", *o.BackgroundColor, rotateBackgroundColorPattern)
+	}
+	if o.BackgroundOpacity != nil {
+		v.minimum("BackgroundOpacity", *o.BackgroundOpacity, 0)
+		v.maximum("BackgroundOpacity", *o.BackgroundOpacity, 100)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -718,6 +1500,50 @@ func (o RotateOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// RotateBuilder builds RotateOperation values without the need of pointers, see Rotate.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type RotateBuilder struct {
+	op RotateOperation
+}
+
+// Rotate returns a new RotateBuilder to build RotateOperation values.
+func Rotate() RotateBuilder { return RotateBuilder{} }
+
+// Angle sets RotateOperation.Angle.
+func (b RotateBuilder) Angle(v float64) RotateBuilder {
+	b.op.Angle = &v
+	return b
+}
+
+// BackgroundColor sets RotateOperation.BackgroundColor.
+func (b RotateBuilder) BackgroundColor(v string) RotateBuilder {
+	b.op.BackgroundColor = &v
+	return b
+}
+
+// BackgroundOpacity sets RotateOperation.BackgroundOpacity.
+func (b RotateBuilder) BackgroundOpacity(v float64) RotateBuilder {
+	b.op.BackgroundOpacity = &v
+	return b
+}
+
+// Enabled sets RotateOperation.Enabled.
+func (b RotateBuilder) Enabled(v bool) RotateBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b RotateBuilder) Build() (RotateOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b RotateBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // SepiaOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -746,6 +1572,32 @@ func (o SepiaOperation) toURLPath() string {
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
 
+// SepiaBuilder builds SepiaOperation values without the need of pointers, see Sepia.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type SepiaBuilder struct {
+	op SepiaOperation
+}
+
+// Sepia returns a new SepiaBuilder to build SepiaOperation values.
+func Sepia() SepiaBuilder { return SepiaBuilder{} }
+
+// Enabled sets SepiaOperation.Enabled.
+func (b SepiaBuilder) Enabled(v bool) SepiaBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b SepiaBuilder) Build() (SepiaOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b SepiaBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
+
 // TrimOperation is an auto-generated Operation as specified by the rokka API.
 //
 // See: https://rokka.io/documentation/references/operations.html
@@ -759,7 +1611,12 @@ func (o TrimOperation) Name() string { return "trim" }
 
 // Validate implements rokka.Operation.Validate.
 func (o TrimOperation) Validate() (bool, error) {
-	return true, nil
+	v := newOperationValidator(o.Name())
+	if o.Fuzzy != nil {
+		v.minimum("Fuzzy", *o.Fuzzy, 0)
+		v.maximum("Fuzzy", *o.Fuzzy, 100)
+	}
+	return v.result()
 }
 
 // toURLPath implements rokka.Operation.toURLPath.
@@ -777,3 +1634,35 @@ func (o TrimOperation) toURLPath() string {
 	}
 	return fmt.Sprintf("%s-%s", o.Name(), strings.Join(options, "-"))
 }
+
+// TrimBuilder builds TrimOperation values without the need of pointers, see Trim.
+// Every method returns a copy, a builder can therefore be used as base of multiple operations.
+type TrimBuilder struct {
+	op TrimOperation
+}
+
+// Trim returns a new TrimBuilder to build TrimOperation values.
+func Trim() TrimBuilder { return TrimBuilder{} }
+
+// Enabled sets TrimOperation.Enabled.
+func (b TrimBuilder) Enabled(v bool) TrimBuilder {
+	b.op.Enabled = &v
+	return b
+}
+
+// Fuzzy sets TrimOperation.Fuzzy.
+func (b TrimBuilder) Fuzzy(v float64) TrimBuilder {
+	b.op.Fuzzy = &v
+	return b
+}
+
+// Build validates the operation and returns it.
+func (b TrimBuilder) Build() (TrimOperation, error) {
+	_, err := b.op.Validate()
+	return b.op, err
+}
+
+// BuildOperation implements rokka.OperationBuilder.
+func (b TrimBuilder) BuildOperation() (Operation, error) {
+	return b.Build()
+}
@@ -3,6 +3,7 @@ package rokka
 import (
 	"encoding/json"
 	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -23,16 +24,16 @@ func TestUnmarshalJSON_ContinueOnError(t *testing.T) {
 		t.Errorf("Expected operation of type '%T', got '%T'", new(ResizeOperation), ops[0])
 	}
 	expectedOp := ResizeOperation{
-		Height:  IntPtr(0),
-		Width:   IntPtr(0),
+		Height:  IntPtr(10000),
+		Width:   IntPtr(810),
 		Upscale: BoolPtr(false),
 	}
 
 	if *resizeOp.Height != *expectedOp.Height {
-		t.Errorf("Expected height to be '%d', got '%d'", expectedOp.Height, resizeOp.Height)
+		t.Errorf("Expected height to be '%d', got '%d'", *expectedOp.Height, *resizeOp.Height)
 	}
 	if *resizeOp.Width != *expectedOp.Width {
-		t.Errorf("Expected width to be '%d', got '%d'", expectedOp.Width, resizeOp.Width)
+		t.Errorf("Expected width to be '%d', got '%d'", *expectedOp.Width, *resizeOp.Width)
 	}
 	if *resizeOp.Upscale != *expectedOp.Upscale {
 		t.Errorf("Expected Upscale to be '%t', got '%t'", *expectedOp.Upscale, *resizeOp.Upscale)
@@ -85,18 +86,23 @@ var operationObjectsTests = []struct {
 	urlPath string
 }{
 	{"AlphaOperation without args", AlphaOperation{}, true, "alpha"},
-	{"AlphaOperation with valid args", AlphaOperation{Mode: StrPtr("x")}, true, "alpha-mode-x"},
+	{"AlphaOperation with valid args", AlphaOperation{Mode: StrPtr(AlphaModeMask)}, true, "alpha-mode-mask"},
+	{"AlphaOperation with invalid mode", AlphaOperation{Mode: StrPtr("x")}, false, "alpha-mode-x"},
 	{"AutorotateOperation without args", AutorotateOperation{}, true, "autorotate"},
 	{"AutorotateOperation with single arg", AutorotateOperation{Width: IntPtr(10)}, true, "autorotate-width-10"},
 	{"AutorotateOperation with multiple args", AutorotateOperation{Width: IntPtr(10), Height: IntPtr(20), RotationDirection: StrPtr("clockwise")}, true, "autorotate-height-20-rotation_direction-clockwise-width-10"},
 	{"BlurOperation with missing args", BlurOperation{}, false, "blur"},
 	{"BlurOperation with valid args", BlurOperation{Sigma: Float64Ptr(1.337)}, true, "blur-sigma-1.337"},
+	{"BlurOperation with negative sigma", BlurOperation{Sigma: Float64Ptr(-1)}, false, "blur-sigma--1"},
 	{"CompositionOperation missing args", CompositionOperation{}, true, "composition"},
-	{"CompositionOperation with valid args", CompositionOperation{Anchor: StrPtr("top"), Height: IntPtr(10), Width: IntPtr(20), Mode: StrPtr("test")}, true, "composition-anchor-top-height-10-mode-test-width-20"},
+	{"CompositionOperation with valid args", CompositionOperation{Anchor: StrPtr("center_top"), Height: IntPtr(10), Width: IntPtr(20), Mode: StrPtr("foreground")}, true, "composition-anchor-center_top-height-10-mode-foreground-width-20"},
+	{"CompositionOperation with invalid anchor", CompositionOperation{Anchor: StrPtr("top")}, false, "composition-anchor-top"},
+	{"CompositionOperation with anchor within other text", CompositionOperation{Anchor: StrPtr("x center_top")}, false, "composition-anchor-x center_top"},
 	{"CropOperation with missing args", CropOperation{}, false, "crop"},
 	{"CropOperation with valid args", CropOperation{Height: IntPtr(100), Width: IntPtr(200)}, true, "crop-height-100-width-200"},
 	{"DropshadowOperation without args", DropshadowOperation{}, true, "dropshadow"},
 	{"DropshadowOperation with valid args", DropshadowOperation{Color: StrPtr("ffffff"), Vertical: IntPtr(10)}, true, "dropshadow-color-ffffff-vertical-10"},
+	{"DropshadowOperation with hex digits within color", DropshadowOperation{Color: StrPtr("zzz0F0F0Fzzz")}, false, "dropshadow-color-zzz0F0F0Fzzz"},
 	{"GrayscaleOperation without args", GrayscaleOperation{}, true, "grayscale"},
 	{"NoopOperation without args", NoopOperation{}, true, "noop"},
 	{"PrimitiveOperation without args", PrimitiveOperation{}, true, "primitive"},
@@ -105,10 +111,14 @@ var operationObjectsTests = []struct {
 	{"ResizeOperation with arg (one-of #1)", ResizeOperation{Height: IntPtr(10)}, true, "resize-height-10"},
 	{"ResizeOperation with arg (one-of #2)", ResizeOperation{Width: IntPtr(10)}, true, "resize-width-10"},
 	{"ResizeOperation with args", ResizeOperation{Height: IntPtr(10), Width: IntPtr(10)}, true, "resize-height-10-width-10"},
+	{"ResizeOperation with invalid mode", ResizeOperation{Width: IntPtr(10), Mode: StrPtr("sideways")}, false, "resize-mode-sideways-width-10"},
+	{"ResizeOperation with width out of range", ResizeOperation{Width: IntPtr(0)}, false, "resize-width-0"},
 	{"ResizeOperation with args and bool arg", ResizeOperation{Height: IntPtr(10), Width: IntPtr(10), Upscale: BoolPtr(true)}, true, "resize-height-10-upscale-true-width-10"},
 	{"RotateOperation without args", RotateOperation{}, false, "rotate"},
 	{"RotateOperation without required arg", RotateOperation{BackgroundColor: StrPtr("aa9374")}, false, "rotate-background_color-aa9374"},
 	{"RotateOperation with args", RotateOperation{Angle: Float64Ptr(45)}, true, "rotate-angle-45"},
+	{"RotateOperation with invalid background color", RotateOperation{Angle: Float64Ptr(45), BackgroundColor: StrPtr("red")}, false, "rotate-angle-45-background_color-red"},
+	{"RotateOperation with too long background color", RotateOperation{Angle: Float64Ptr(45), BackgroundColor: StrPtr("aa9374ff")}, false, "rotate-angle-45-background_color-aa9374ff"},
 	{"SepiaOperation without args", SepiaOperation{}, true, "sepia"},
 	{"TrimOperation without args", TrimOperation{}, true, "trim"},
 	{"TrimOperation with args", TrimOperation{Fuzzy: Float64Ptr(15)}, true, "trim-fuzzy-15"},
@@ -129,3 +139,73 @@ func TestOperationsObjects(t *testing.T) {
 		})
 	}
 }
+
+func TestOperations_MarshalJSON(t *testing.T) {
+	ops := Operations{
+		ResizeOperation{Width: IntPtr(200), Height: IntPtr(100), Mode: StrPtr("fill")},
+		&RotateOperation{Angle: Float64Ptr(90.5)},
+		GrayscaleOperation{},
+	}
+	expected := `[{"name":"resize","options":{"height":100,"mode":"fill","width":200}},{"name":"rotate","options":{"angle":90.5}},{"name":"grayscale","options":{}}]`
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, b)
+	}
+
+	decoded := make(Operations, 0)
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	b, err = json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Errorf("Expected '%s' after decoding, got '%s'", expected, b)
+	}
+
+	if _, err := json.Marshal(Operations{nil}); err == nil {
+		t.Error("Expected an error marshalling a nil operation")
+	}
+}
+
+func TestValidate_AllViolations(t *testing.T) {
+	ok, err := CropOperation{Width: IntPtr(0), Mode: StrPtr("square"), Scale: Float64Ptr(150)}.Validate()
+	if ok {
+		t.Fatal("Expected operation to be invalid")
+	}
+	vErr, isValidationErr := err.(*OperationValidationError)
+	if !isValidationErr {
+		t.Fatalf("Expected error of type '%T', got '%T'", &OperationValidationError{}, err)
+	}
+	if vErr.Operation != "crop" {
+		t.Errorf("Expected operation 'crop', got '%s'", vErr.Operation)
+	}
+
+	expected := []OptionError{
+		{"Height", "is required"},
+		{"Mode", `must be one of absolute, ratio, got "square"`},
+		{"Scale", "must be at most 100, got 150"},
+		{"Width", "must be at least 1, got 0"},
+	}
+	if len(vErr.Errors) != len(expected) {
+		t.Fatalf("Expected errors '%v', got '%v'", expected, vErr.Errors)
+	}
+	for i, e := range expected {
+		if vErr.Errors[i] != e {
+			t.Errorf("Expected error '%v', got '%v'", e, vErr.Errors[i])
+		}
+	}
+	if !strings.HasPrefix(err.Error(), `rokka: invalid operation crop: option "Height" is required; option "Mode" must be`) {
+		t.Errorf("Unexpected error message '%s'", err)
+	}
+
+	c := NewClient(&Config{})
+	if _, err := c.GetURL("test", "c1b110", "jpg", []Operation{ResizeOperation{Width: IntPtr(100), Mode: StrPtr("sideways")}}); err == nil {
+		t.Error("Expected GetURL to refuse an invalid operation")
+	}
+}
@@ -0,0 +1,115 @@
+package rokka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RawOperation is an operation unknown to this version of the client, e.g. because it has been added to rokka
+// afterwards. It keeps the name and the options as they are, which allows to read and write stacks containing such
+// operations without losing anything.
+//
+// Operations.UnmarshalJSON returns a *RawOperation for every unknown operation. Set Config.StrictOperations to make
+// requests returning stacks with unknown operations fail instead.
+type RawOperation struct {
+	OperationName string
+	// Options contains the options as JSON object.
+	Options json.RawMessage
+}
+
+// Name implements rokka.Operation.Name
+func (o RawOperation) Name() string { return o.OperationName }
+
+// Validate implements rokka.Operation.Validate. As the options of an unknown operation are unknown as well, only
+// the name is checked.
+func (o RawOperation) Validate() (bool, error) {
+	if o.OperationName == "" {
+		return false, &OperationValidationError{Operation: "raw", Errors: []OptionError{{Message: "name is required"}}}
+	}
+	return true, nil
+}
+
+// MarshalJSON implements json.Marshaler by returning the options as they are.
+func (o RawOperation) MarshalJSON() ([]byte, error) {
+	if len(bytes.TrimSpace(o.Options)) == 0 || bytes.Equal(bytes.TrimSpace(o.Options), []byte("null")) {
+		return []byte("{}"), nil
+	}
+	return o.Options, nil
+}
+
+// options decodes the options keeping numbers as they are.
+func (o RawOperation) options() (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+	b, err := o.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// toURLPath implements rokka.Operation.toURLPath. The options are sorted by name like the ones of the generated
+// operations. Options which can't be decoded are omitted.
+func (o RawOperation) toURLPath() string {
+	options, err := o.options()
+	if err != nil || len(options) == 0 {
+		return o.Name()
+	}
+
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{o.Name()}
+	for _, k := range keys {
+		switch v := options[k].(type) {
+		case string, json.Number, bool:
+			parts = append(parts, k, fmt.Sprintf("%v", v))
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			parts = append(parts, k, string(b))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// unknownOperations returns the names of the operations unknown to this version of the client.
+func (o Operations) unknownOperations() []string {
+	names := make([]string, 0)
+	for _, op := range o {
+		switch op.(type) {
+		case RawOperation, *RawOperation:
+			// operations kept as they are because of options which couldn't be decoded aren't unknown.
+			if _, err := NewOperationByName(op.Name()); err != nil {
+				names = append(names, op.Name())
+			}
+		}
+	}
+	return names
+}
+
+// checkStrictOperations returns an error if strict operations are enabled and any of the stacks contains an
+// unknown operation.
+func (c *Client) checkStrictOperations(stacks ...Stack) error {
+	if !c.config.StrictOperations {
+		return nil
+	}
+	for _, s := range stacks {
+		if names := s.StackOperations.unknownOperations(); len(names) > 0 {
+			return fmt.Errorf("%s: stack %s contains unknown operations %s", errOperationNotImplemented, s.Name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package rokka
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestUnmarshalJSON_UnknownOperation(t *testing.T) {
+	input := `[{"name":"resize","options":{"width":200}},{"name":"vignette","options":{"color":"000000","soft":true,"strength":0.5}}]`
+
+	ops := make(Operations, 0)
+	if err := json.Unmarshal([]byte(input), &ops); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(ops))
+	}
+	raw, ok := ops[1].(*RawOperation)
+	if !ok {
+		t.Fatalf("Expected operation of type '%T', got '%T'", new(RawOperation), ops[1])
+	}
+	if raw.Name() != "vignette" {
+		t.Errorf("Expected name to be 'vignette', got '%s'", raw.Name())
+	}
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != input {
+		t.Errorf("Expected round trip to return '%s', got '%s'", input, b)
+	}
+}
+
+func TestRawOperation_toURLPath(t *testing.T) {
+	tests := []struct {
+		op       RawOperation
+		expected string
+	}{
+		{RawOperation{OperationName: "vignette"}, "vignette"},
+		{RawOperation{OperationName: "vignette", Options: json.RawMessage(`null`)}, "vignette"},
+		{RawOperation{OperationName: "vignette", Options: json.RawMessage(`{"strength":0.5,"color":"000000","soft":true}`)}, "vignette-color-000000-soft-true-strength-0.5"},
+		{RawOperation{OperationName: "vignette", Options: json.RawMessage(`{"sizes":[1,2]}`)}, "vignette-sizes-[1,2]"},
+	}
+	for _, tt := range tests {
+		if path := tt.op.toURLPath(); path != tt.expected {
+			t.Errorf("Expected '%s', got '%s'", tt.expected, path)
+		}
+	}
+}
+
+func TestRawOperation_Validate(t *testing.T) {
+	if ok, err := (RawOperation{OperationName: "vignette"}).Validate(); !ok || err != nil {
+		t.Errorf("Expected operation to be valid, got %v", err)
+	}
+	if ok, _ := (RawOperation{}).Validate(); ok {
+		t.Error("Expected operation without name to be invalid")
+	}
+}
+
+func TestListStacks_UnknownOperation(t *testing.T) {
+	org := "test-org"
+	r := test.NewResponse(http.StatusOK, "./fixtures/ListStacksWithUnknownOperation.json")
+	ts := test.NewMockAPI(t, test.Routes{"GET /stacks/" + org: r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL})
+	res, err := c.ListStacks(org)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := res.Items[0].StackOperations.unknownOperations(); len(names) != 1 || names[0] != "vignette" {
+		t.Errorf("Expected unknown operation 'vignette', got %v", names)
+	}
+
+	c = NewClient(&Config{APIAddress: ts.URL, StrictOperations: true})
+	_, err = c.ListStacks(org)
+	if err == nil || !strings.Contains(err.Error(), "vignette") {
+		t.Errorf("Expected error about unknown operation 'vignette', got %v", err)
+	}
+}
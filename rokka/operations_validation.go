@@ -0,0 +1,112 @@
+package rokka
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OptionError describes a single invalid option of an operation.
+type OptionError struct {
+	// Field is the name of the field of the operation struct, e.g. `Width`. It's empty if the error concerns
+	// multiple options.
+	Field   string
+	Message string
+}
+
+func (e OptionError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("option \"%s\" %s", e.Field, e.Message)
+}
+
+// OperationValidationError is returned by Operation.Validate and lists every invalid option of the operation.
+type OperationValidationError struct {
+	Operation string
+	Errors    []OptionError
+}
+
+func (e *OperationValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("rokka: invalid operation %s: %s", e.Operation, strings.Join(msgs, "; "))
+}
+
+// operationValidator collects the errors of the options of an operation. It's used by the generated operations
+// and stack options.
+type operationValidator struct {
+	operation string
+	errors    []OptionError
+}
+
+func newOperationValidator(operation string) *operationValidator {
+	return &operationValidator{operation: operation}
+}
+
+func (v *operationValidator) add(field, format string, a ...interface{}) {
+	v.errors = append(v.errors, OptionError{Field: field, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *operationValidator) required(field string, set bool) {
+	if !set {
+		v.add(field, "is required")
+	}
+}
+
+func (v *operationValidator) oneOf(names []string, set ...bool) {
+	for _, s := range set {
+		if s {
+			return
+		}
+	}
+	v.add("", "one of \"%v\" is required", names)
+}
+
+func (v *operationValidator) enum(field, value string, values []string) {
+	for _, e := range values {
+		if e == value {
+			return
+		}
+	}
+	v.add(field, "must be one of %s, got \"%s\"", strings.Join(values, ", "), value)
+}
+
+func (v *operationValidator) minimum(field string, value, min float64) {
+	if value < min {
+		v.add(field, "must be at least %s, got %s", formatNumber(min), formatNumber(value))
+	}
+}
+
+func (v *operationValidator) maximum(field string, value, max float64) {
+	if value > max {
+		v.add(field, "must be at most %s, got %s", formatNumber(max), formatNumber(value))
+	}
+}
+
+func (v *operationValidator) minLength(field, value string, min int) {
+	if len(value) < min {
+		v.add(field, "must be at least %d characters long, got \"%s\"", min, value)
+	}
+}
+
+func (v *operationValidator) pattern(field, value string, pattern *regexp.Regexp) {
+	if !pattern.MatchString(value) {
+		v.add(field, "must match the pattern %s, got \"%s\"", pattern, value)
+	}
+}
+
+// result returns the outcome in the form of Operation.Validate.
+func (v *operationValidator) result() (bool, error) {
+	if len(v.errors) == 0 {
+		return true, nil
+	}
+	return false, &OperationValidationError{Operation: v.operation, Errors: v.errors}
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
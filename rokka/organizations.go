@@ -2,6 +2,7 @@ package rokka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -30,9 +31,14 @@ type createOrganizationRequest struct {
 //
 // See: https://rokka.io/documentation/references/organizations.html#read-data-of-one-organization
 func (c *Client) GetOrganization(name string) (OrganizationResponse, error) {
+	return c.GetOrganizationWithContext(context.Background(), name)
+}
+
+// GetOrganizationWithContext is the same as GetOrganization with the addition of passing a context.
+func (c *Client) GetOrganizationWithContext(ctx context.Context, name string) (OrganizationResponse, error) {
 	result := OrganizationResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/organizations/"+name, nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/organizations/"+name, nil, nil)
 	if err != nil {
 		return result, err
 	}
@@ -45,6 +51,11 @@ func (c *Client) GetOrganization(name string) (OrganizationResponse, error) {
 //
 // See: https://rokka.io/documentation/references/organizations.html#create-an-organization
 func (c *Client) CreateOrganization(name, billingEmail, displayName string) (OrganizationResponse, error) {
+	return c.CreateOrganizationWithContext(context.Background(), name, billingEmail, displayName)
+}
+
+// CreateOrganizationWithContext is the same as CreateOrganization with the addition of passing a context.
+func (c *Client) CreateOrganizationWithContext(ctx context.Context, name, billingEmail, displayName string) (OrganizationResponse, error) {
 	result := OrganizationResponse{}
 
 	b := new(bytes.Buffer)
@@ -56,7 +67,7 @@ func (c *Client) CreateOrganization(name, billingEmail, displayName string) (Org
 		return result, err
 	}
 
-	req, err := c.NewRequest(http.MethodPut, "/organizations/"+name, b, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPut, "/organizations/"+name, b, nil)
 	if err != nil {
 		return result, err
 	}
@@ -1,10 +1,37 @@
 package rokka
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
+// Headers of a render response containing metadata about the rendered image.
+const (
+	renderWidthHeader  = "X-Rokka-Width"
+	renderHeightHeader = "X-Rokka-Height"
+	renderCacheHeader  = "X-Cache"
+)
+
+// RenderResponse contains the rendered image and its metadata. The Body is streamed and needs to be closed by the
+// caller.
+type RenderResponse struct {
+	Body          io.ReadCloser
+	URL           string
+	ContentType   string
+	ContentLength int64
+	// Width and Height are the dimensions of the rendered image. They are zero if not sent by rokka.
+	Width  int
+	Height int
+	// CacheStatus is the cache status of the CDN, e.g. `HIT` or `MISS`.
+	CacheStatus string
+	Header      http.Header
+}
+
 // GetURL generates an URL for the given organization, image hash, and format based on the list of operations
 // given to it.
 // If the operation slice is empty it generates a default noop operation.
@@ -17,6 +44,7 @@ func (c *Client) GetURL(organization, hash, format string, ops []Operation) (str
 // The operations are added on top of the stack.
 // If the operation slice is empty it generates a default noop operation.
 // The URL returned has the format: `https://{imageHost for org}/{stack}/{stacks-applied-with-options}/{hash}.{format}`.
+// If signing of URLs is enabled in the config, the URL is signed using Client.SignURL.
 func (c *Client) GetURLForStack(organization, hash, format, stack string, ops []Operation) (string, error) {
 	host := strings.Replace(c.config.ImageHost, "{{organization}}", organization, -1)
 
@@ -32,5 +60,58 @@ func (c *Client) GetURLForStack(organization, hash, format, stack string, ops []
 		opURL[i] = o.toURLPath()
 	}
 
-	return fmt.Sprintf("%s/%s/%s/%s.%s", host, stack, strings.Join(opURL, "--"), hash, format), nil
+	u := fmt.Sprintf("%s/%s/%s/%s.%s", host, stack, strings.Join(opURL, "--"), hash, format)
+	if c.config.SignURLs {
+		return c.SignURL(u)
+	}
+	return u, nil
+}
+
+// Render requests the image rendered by the stack with the operations added on top of it. The URL is generated using
+// GetURLForStack, the request is retried in case of failures using the configured RetryingHTTPClient.
+//
+// See: https://rokka.io/documentation/references/render.html
+func (c *Client) Render(organization, hash, format, stack string, ops []Operation) (RenderResponse, error) {
+	return c.RenderWithContext(context.Background(), organization, hash, format, stack, ops)
+}
+
+// RenderWithContext is the same as Render with the addition of passing a context.
+func (c *Client) RenderWithContext(ctx context.Context, organization, hash, format, stack string, ops []Operation) (RenderResponse, error) {
+	u, err := c.GetURLForStack(organization, hash, format, stack, ops)
+	if err != nil {
+		return RenderResponse{}, err
+	}
+	return c.RenderURLWithContext(ctx, u)
+}
+
+// RenderURL requests the image of a render URL, e.g. one built using NewURLBuilder.
+func (c *Client) RenderURL(rawURL string) (RenderResponse, error) {
+	return c.RenderURLWithContext(context.Background(), rawURL)
+}
+
+// RenderURLWithContext is the same as RenderURL with the addition of passing a context.
+func (c *Client) RenderURLWithContext(ctx context.Context, rawURL string) (RenderResponse, error) {
+	result := RenderResponse{URL: rawURL}
+
+	if ctx == nil {
+		return result, errors.New("rokka: nil context")
+	}
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return result, err
+	}
+
+	resp, err := do(c.config.RetryingHTTPClient, req.WithContext(ctx))
+	if err != nil {
+		return result, err
+	}
+
+	result.Body = resp.Body
+	result.ContentType = resp.Header.Get("Content-Type")
+	result.ContentLength = resp.ContentLength
+	result.Width, _ = strconv.Atoi(resp.Header.Get(renderWidthHeader))
+	result.Height, _ = strconv.Atoi(resp.Header.Get(renderHeightHeader))
+	result.CacheStatus = resp.Header.Get(renderCacheHeader)
+	result.Header = resp.Header
+	return result, nil
 }
@@ -1,7 +1,13 @@
 package rokka
 
 import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
 	"testing"
+
+	"github.com/rokka-io/rokka-go/test"
 )
 
 func TestGetURLWithoutStackOperations(t *testing.T) {
@@ -19,9 +25,9 @@ func TestGetURLWithoutStackOperations(t *testing.T) {
 }
 
 func TestGetURLWithValidStackOperations(t *testing.T) {
-	expectedURL := "https://test.rokka.io/dynamic/composition-height-200-mode-test-width-100--trim--primitive-count-10/8bbff49a384a4682fd05144ffe77a84f29f112ff.png"
+	expectedURL := "https://test.rokka.io/dynamic/composition-height-200-mode-foreground-width-100--trim--primitive-count-10/8bbff49a384a4682fd05144ffe77a84f29f112ff.png"
 	operations := []Operation{
-		CompositionOperation{Mode: StrPtr("test"), Width: IntPtr(100), Height: IntPtr(200)},
+		CompositionOperation{Mode: StrPtr(CompositionModeForeground), Width: IntPtr(100), Height: IntPtr(200)},
 		TrimOperation{},
 		PrimitiveOperation{Count: IntPtr(10)},
 	}
@@ -63,9 +69,9 @@ func TestGetURLWithInvalidStackOperation(t *testing.T) {
 }
 
 func TestGetURLForStackWithValidStackOperations(t *testing.T) {
-	expectedURL := "https://test.rokka.io/stack-name/composition-height-200-mode-test-width-100--trim--primitive-count-10/8bbff49a384a4682fd05144ffe77a84f29f112ff.png"
+	expectedURL := "https://test.rokka.io/stack-name/composition-height-200-mode-foreground-width-100--trim--primitive-count-10/8bbff49a384a4682fd05144ffe77a84f29f112ff.png"
 	operations := []Operation{
-		CompositionOperation{Mode: StrPtr("test"), Width: IntPtr(100), Height: IntPtr(200)},
+		CompositionOperation{Mode: StrPtr(CompositionModeForeground), Width: IntPtr(100), Height: IntPtr(200)},
 		TrimOperation{},
 		PrimitiveOperation{Count: IntPtr(10)},
 	}
@@ -79,3 +85,60 @@ func TestGetURLForStackWithValidStackOperations(t *testing.T) {
 		t.Errorf("Result doesn't match expected value. Got: \"%s\"; Expected: \"%s\"", url, expectedURL)
 	}
 }
+
+func TestRender(t *testing.T) {
+	hash := "8bbff49a384a4682fd05144ffe77a84f29f112ff"
+	r := test.NewResponse(http.StatusOK, "./fixtures/image.png")
+	r.Headers["Content-Type"] = "image/png"
+	r.Headers["X-Rokka-Width"] = "100"
+	r.Headers["X-Rokka-Height"] = "50"
+	r.Headers["X-Cache"] = "MISS"
+	r.Assertion = func(t *testing.T, r *http.Request) {
+		if r.Header.Get("Api-Key") != "" {
+			t.Error("Expected no API key to be sent to the image host")
+		}
+	}
+	ts := test.NewMockAPI(t, test.Routes{"GET /test/dynamic/resize-width-100/" + hash + ".png": flakyResponse(r, 2)})
+	defer ts.Close()
+
+	c := NewClient(&Config{
+		APIKey:             "secret",
+		ImageHost:          ts.URL + "/{{organization}}",
+		RetryingHTTPClient: NewRetryingHTTPClient(DefaultConfig().HTTPClient, 10, 1),
+	})
+
+	res, err := c.Render("test", hash, "png", "dynamic", []Operation{ResizeOperation{Width: IntPtr(100)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.ContentType != "image/png" || res.Width != 100 || res.Height != 50 || res.CacheStatus != "MISS" {
+		t.Errorf("Unexpected metadata '%+v'", res)
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile("./fixtures/image.png")
+	if err != nil {
+		panic(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Error("Expected the body to be the rendered image")
+	}
+	if res.ContentLength != int64(len(expected)) {
+		t.Errorf("Expected content length %d, got %d", len(expected), res.ContentLength)
+	}
+}
+
+func TestRender_NotFound(t *testing.T) {
+	r := test.NewResponse(http.StatusNotFound, "")
+	ts := test.NewMockAPI(t, test.Routes{"GET /dynamic/noop/c1b110.png": r})
+	defer ts.Close()
+
+	c := NewClient(&Config{ImageHost: ts.URL})
+	if _, err := c.Render("test", "c1b110", "png", "dynamic", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected error to be '%v', got '%v'", ErrNotFound, err)
+	}
+}
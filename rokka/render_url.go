@@ -0,0 +1,387 @@
+package rokka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RenderURL is the parsed form of a render URL as generated by GetURLForStack.
+//
+// See: https://rokka.io/documentation/references/render.html
+type RenderURL struct {
+	// Host is the scheme and host of the URL, e.g. `https://test.rokka.io`.
+	Host         string
+	Organization string
+	Stack        string
+	// Operations are the dynamic operations added on top of the stack.
+	Operations Operations
+	// Options contains the stack options overridden by the URL (`options-...`).
+	Options StackOptions
+	// Variables contains the stack variables set by the URL, either in the path (`v-...`) or as JSON in the `v`
+	// query parameter.
+	Variables StackVariables
+	Hash      string
+	// Filename is the optional SEO filename, e.g. `image` of `/stack/hash/image.jpg`.
+	Filename string
+	Format   string
+}
+
+// hashPattern matches a full or short hash of a source image.
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{6,40}$`)
+
+// errInvalidRenderURL is returned if an URL doesn't have the structure of a render URL.
+var errInvalidRenderURL = errors.New("rokka: invalid render URL")
+
+// ParseURL parses a render URL. The organization is taken from the host in case it's the default image host
+// `https://{organization}.rokka.io`. Use Client.ParseURL for URLs of a custom image host.
+func ParseURL(rawURL string) (RenderURL, error) {
+	return parseRenderURL(rawURL, DefaultConfig().ImageHost)
+}
+
+// ParseURL parses a render URL generated using the image host of the client.
+func (c *Client) ParseURL(rawURL string) (RenderURL, error) {
+	return parseRenderURL(rawURL, c.config.ImageHost)
+}
+
+func parseRenderURL(rawURL, imageHost string) (RenderURL, error) {
+	res := RenderURL{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return res, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return res, fmt.Errorf("%s: missing scheme or host", errInvalidRenderURL)
+	}
+	base := u.Scheme + "://" + u.Host
+	res.Host = base
+
+	path := u.EscapedPath()
+	hostPattern := regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(imageHost), regexp.QuoteMeta("{{organization}}"), "([^./]+)", 1) + "(/.*)$")
+	if m := hostPattern.FindStringSubmatch(base + path); m != nil && strings.Contains(imageHost, "{{organization}}") {
+		res.Organization = m[1]
+		res.Host = strings.TrimSuffix(base+path, m[2])
+		path = m[2]
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if segments[i], err = url.PathUnescape(s); err != nil {
+			return res, err
+		}
+	}
+	if len(segments) < 2 {
+		return res, fmt.Errorf("%s: expected at least a stack and a hash", errInvalidRenderURL)
+	}
+	res.Stack = segments[0]
+
+	last := segments[len(segments)-1]
+	dot := strings.LastIndex(last, ".")
+	if dot <= 0 || dot == len(last)-1 {
+		return res, fmt.Errorf("%s: missing format", errInvalidRenderURL)
+	}
+	res.Format = last[dot+1:]
+	name := last[:dot]
+
+	rest := segments[1 : len(segments)-1]
+	if len(rest) > 0 && hashPattern.MatchString(rest[len(rest)-1]) {
+		res.Hash = rest[len(rest)-1]
+		res.Filename = name
+		rest = rest[:len(rest)-1]
+	} else {
+		res.Hash = name
+	}
+	if !hashPattern.MatchString(res.Hash) {
+		return res, fmt.Errorf("%s: invalid hash '%s'", errInvalidRenderURL, res.Hash)
+	}
+
+	for _, s := range rest {
+		for _, part := range strings.Split(s, "--") {
+			if err := res.parsePart(part); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	if v := u.Query().Get("v"); v != "" {
+		vars := make(StackVariables)
+		if err := json.Unmarshal([]byte(v), &vars); err != nil {
+			return res, fmt.Errorf("%s: invalid variables '%s'", errInvalidRenderURL, v)
+		}
+		if res.Variables == nil {
+			res.Variables = make(StackVariables)
+		}
+		for k, e := range vars {
+			res.Variables[k] = e
+		}
+	}
+	return res, nil
+}
+
+// parsePart parses a single part of the URL path separated by `--`, which is either an operation or stack options.
+func (u *RenderURL) parsePart(part string) error {
+	tokens := strings.Split(part, "-")
+	switch tokens[0] {
+	case "options":
+		if len(tokens)%2 != 1 {
+			return fmt.Errorf("%s: options '%s' need to consist of name and value pairs", errInvalidRenderURL, part)
+		}
+		if u.Options == nil {
+			u.Options = make(StackOptions)
+		}
+		for i := 1; i < len(tokens); i += 2 {
+			u.Options[tokens[i]] = parseURLValue(tokens[i+1])
+		}
+		return nil
+	case "v":
+		if len(tokens)%2 != 1 {
+			return fmt.Errorf("%s: variables '%s' need to consist of name and value pairs", errInvalidRenderURL, part)
+		}
+		if u.Variables == nil {
+			u.Variables = make(StackVariables)
+		}
+		for i := 1; i < len(tokens); i += 2 {
+			u.Variables[tokens[i]] = parseURLValue(tokens[i+1])
+		}
+		return nil
+	}
+
+	op, err := parseOperation(tokens)
+	if err != nil {
+		return fmt.Errorf("%s: %s", errInvalidRenderURL, err)
+	}
+	u.Operations = append(u.Operations, op)
+	return nil
+}
+
+// ParseOperations parses operations in the form used by render URLs, e.g. `resize-width-200--grayscale`.
+func ParseOperations(s string) (Operations, error) {
+	ops := make(Operations, 0)
+	if s == "" {
+		return ops, nil
+	}
+	for _, part := range strings.Split(s, "--") {
+		op, err := parseOperation(strings.Split(part, "-"))
+		if err != nil {
+			return nil, fmt.Errorf("rokka: %s", err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// parseOperation creates an operation from the tokens of an URL in the form name, option, value, option, value.
+// Operations unknown to the client are returned as *RawOperation.
+func parseOperation(tokens []string) (Operation, error) {
+	op, err := NewOperationByName(tokens[0])
+	if err != nil {
+		return parseRawOperation(tokens)
+	}
+	if err := setOperationOptions(op, tokens[1:]); err != nil {
+		return nil, fmt.Errorf("operation %s: %s", op.Name(), err)
+	}
+	return op, nil
+}
+
+// parseRawOperation creates a *RawOperation from the tokens of an URL. As the options of an unknown operation are
+// unknown as well, every second token is taken as value, which is kept as string. Values containing a dash can
+// therefore not be parsed.
+func parseRawOperation(tokens []string) (Operation, error) {
+	if tokens[0] == "" {
+		return nil, errors.New("missing operation name")
+	}
+	if len(tokens)%2 == 0 {
+		return nil, fmt.Errorf("operation %s: missing value of option '%s'", tokens[0], tokens[len(tokens)-1])
+	}
+	op := &RawOperation{OperationName: tokens[0]}
+	if len(tokens) == 1 {
+		return op, nil
+	}
+	options := make(map[string]string)
+	for i := 1; i < len(tokens); i += 2 {
+		options[tokens[i]] = tokens[i+1]
+	}
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	op.Options = b
+	return op, nil
+}
+
+// setOperationOptions sets the options of an operation given as tokens of an URL in the form name, value, name, value.
+// As values may contain a dash themselves, a value ends as soon as the next token is the name of an option.
+func setOperationOptions(op Operation, tokens []string) error {
+	s := reflect.ValueOf(op).Elem()
+	fields := make(map[string]int)
+	for i := 0; i < s.NumField(); i++ {
+		name := strings.Split(s.Type().Field(i).Tag.Get("json"), ",")[0]
+		fields[name] = i
+	}
+
+	for i := 0; i < len(tokens); {
+		field, ok := fields[tokens[i]]
+		if !ok {
+			return fmt.Errorf("unknown option '%s'", tokens[i])
+		}
+		j := i + 1
+		for j < len(tokens) {
+			if _, ok := fields[tokens[j]]; ok && j > i+1 {
+				break
+			}
+			j++
+		}
+		if j == i+1 {
+			return fmt.Errorf("missing value of option '%s'", tokens[i])
+		}
+		if err := setOptionValue(s.Field(field), strings.Join(tokens[i+1:j], "-")); err != nil {
+			return fmt.Errorf("option '%s': %s", tokens[i], err)
+		}
+		i = j
+	}
+	return nil
+}
+
+// setOptionValue parses the value according to the type of the field and sets it.
+func setOptionValue(f reflect.Value, value string) error {
+	var v interface{}
+	switch f.Type().Elem().Kind() {
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer '%s'", value)
+		}
+		v = n
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number '%s'", value)
+		}
+		v = n
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean '%s'", value)
+		}
+		v = b
+	case reflect.String:
+		v = value
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	p := reflect.New(f.Type().Elem())
+	p.Elem().Set(reflect.ValueOf(v))
+	f.Set(p)
+	return nil
+}
+
+// parseURLValue converts the value of a stack option to a bool or number if possible.
+func parseURLValue(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// pathValuePattern matches values of options and variables which can be written to the path of an URL as they are.
+var pathValuePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// seoFilenamePattern matches the characters which are not allowed in an SEO filename.
+var seoFilenamePattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// seoFilenameTransliteration replaces common Latin letters with diacritics by ASCII letters, German umlauts are
+// written the usual way, e.g. `ü` as `ue`.
+var seoFilenameTransliteration = strings.NewReplacer(
+	"Ä", "Ae", "Ö", "Oe", "Ü", "Ue", "ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"Æ", "Ae", "Œ", "Oe", "æ", "ae", "œ", "oe",
+	"À", "A", "Á", "A", "Â", "A", "Ã", "A", "Å", "A", "à", "a", "á", "a", "â", "a", "ã", "a", "å", "a",
+	"Ç", "C", "ç", "c",
+	"È", "E", "É", "E", "Ê", "E", "Ë", "E", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"Ì", "I", "Í", "I", "Î", "I", "Ï", "I", "ì", "i", "í", "i", "î", "i", "ï", "i",
+	"Ñ", "N", "ñ", "n",
+	"Ò", "O", "Ó", "O", "Ô", "O", "Õ", "O", "Ø", "O", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ø", "o",
+	"Ù", "U", "Ú", "U", "Û", "U", "ù", "u", "ú", "u", "û", "u",
+	"Ý", "Y", "Ÿ", "Y", "ý", "y", "ÿ", "y",
+)
+
+// SEOFilename converts a name to a filename suitable for render URLs, e.g. `Über uns!` to `Ueber-uns`.
+// Common Latin letters with diacritics are transliterated, e.g. `é` to `e` and `ü` to `ue`. Every remaining sequence
+// of characters other than ASCII letters, digits and underscores is replaced by a single dash.
+func SEOFilename(name string) string {
+	name = seoFilenameTransliteration.Replace(name)
+	return strings.Trim(seoFilenamePattern.ReplaceAllString(name, "-"), "-")
+}
+
+// String returns the render URL. It's the inverse of ParseURL.
+//
+// The URL is canonical: the operations are followed by the stack options and the variables, both sorted by name.
+// Variables whose value can't be written to the path are passed as JSON in the `v` query parameter.
+func (u RenderURL) String() string {
+	parts := make([]string, 0, len(u.Operations)+2)
+	for _, o := range u.Operations {
+		parts = append(parts, o.toURLPath())
+	}
+	if len(u.Options) > 0 {
+		parts = append(parts, joinURLValues("options", u.Options))
+	}
+
+	pathVars := make(map[string]interface{})
+	queryVars := make(map[string]interface{})
+	for k, v := range u.Variables {
+		if pathValuePattern.MatchString(fmt.Sprintf("%v", v)) {
+			pathVars[k] = v
+		} else {
+			queryVars[k] = v
+		}
+	}
+	if len(pathVars) > 0 {
+		parts = append(parts, joinURLValues("v", pathVars))
+	}
+
+	segments := []string{u.Host, url.PathEscape(u.Stack)}
+	if len(parts) > 0 {
+		segments = append(segments, strings.Join(parts, "--"))
+	}
+	if u.Filename != "" {
+		segments = append(segments, u.Hash, url.PathEscape(u.Filename)+"."+u.Format)
+	} else {
+		segments = append(segments, u.Hash+"."+u.Format)
+	}
+
+	res := strings.Join(segments, "/")
+	if len(queryVars) > 0 {
+		// maps are encoded with sorted keys
+		b, err := json.Marshal(queryVars)
+		if err == nil {
+			res += "?v=" + url.QueryEscape(string(b))
+		}
+	}
+	return res
+}
+
+// joinURLValues returns the values sorted by name as `prefix-name-value-name-value`.
+func joinURLValues(prefix string, values map[string]interface{}) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := []string{prefix}
+	for _, k := range keys {
+		res = append(res, url.PathEscape(k), url.PathEscape(fmt.Sprintf("%v", values[k])))
+	}
+	return strings.Join(res, "-")
+}
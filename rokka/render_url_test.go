@@ -0,0 +1,133 @@
+package rokka
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	hash := "8bbff49a384a4682fd05144ffe77a84f29f112ff"
+	u, err := ParseURL("https://test.rokka.io/dynamic/resize-mode-fill-width-200--rotate-angle-90.5--options-autoformat-true-jpg.quality-80/" + hash + "/my-image.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := RenderURL{
+		Host:         "https://test.rokka.io",
+		Organization: "test",
+		Stack:        "dynamic",
+		Operations: Operations{
+			&ResizeOperation{Mode: StrPtr("fill"), Width: IntPtr(200)},
+			&RotateOperation{Angle: Float64Ptr(90.5)},
+		},
+		Options:  StackOptions{"autoformat": true, "jpg.quality": 80},
+		Hash:     hash,
+		Filename: "my-image",
+		Format:   "jpg",
+	}
+	if !reflect.DeepEqual(u, expected) {
+		t.Errorf("Expected '%#v', got '%#v'", expected, u)
+	}
+}
+
+func TestParseURL_RoundTrip(t *testing.T) {
+	hash := "8bbff49a384a4682fd05144ffe77a84f29f112ff"
+	table := []struct {
+		stack string
+		ops   []Operation
+	}{
+		{"dynamic", []Operation{}},
+		{"dynamic", []Operation{CompositionOperation{Mode: StrPtr(CompositionModeForeground), Width: IntPtr(100), Height: IntPtr(200)}, TrimOperation{}, PrimitiveOperation{Count: IntPtr(10)}}},
+		{"stack-name", []Operation{ResizeOperation{Width: IntPtr(100), Upscale: BoolPtr(false), UpscaleDpr: BoolPtr(true)}}},
+		{"stack-name", []Operation{BlurOperation{Sigma: Float64Ptr(0.5)}, CropOperation{Width: IntPtr(10), Height: IntPtr(20), Anchor: StrPtr("auto")}}},
+		{"dynamic", []Operation{&RawOperation{OperationName: "vignette", Options: json.RawMessage(`{"color":"000000","strength":0.5}`)}, GrayscaleOperation{}}},
+	}
+
+	c := NewClient(&Config{})
+	for _, v := range table {
+		rawURL, err := c.GetURLForStack("test", hash, "png", v.stack, v.ops)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := ParseURL(rawURL)
+		if err != nil {
+			t.Errorf("Unexpected error parsing '%s': %s", rawURL, err)
+			continue
+		}
+		if u.String() != rawURL {
+			t.Errorf("Expected '%s', got '%s'", rawURL, u)
+		}
+		if u.Organization != "test" || u.Stack != v.stack || u.Hash != hash || u.Format != "png" {
+			t.Errorf("Unexpected result parsing '%s': %#v", rawURL, u)
+		}
+	}
+}
+
+func TestParseURL_CustomImageHost(t *testing.T) {
+	c := NewClient(&Config{ImageHost: "https://cdn.example.com/{{organization}}"})
+	rawURL := "https://cdn.example.com/test/stack-name/8bbff49a384a4682fd05144ffe77a84f29f112ff.png"
+	u, err := c.ParseURL(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Organization != "test" || u.Stack != "stack-name" || u.Host != "https://cdn.example.com/test" {
+		t.Errorf("Unexpected result parsing '%s': %#v", rawURL, u)
+	}
+	if u.String() != rawURL {
+		t.Errorf("Expected '%s', got '%s'", rawURL, u)
+	}
+}
+
+func TestParseURL_RawOperation(t *testing.T) {
+	u, err := ParseURL("https://test.rokka.io/dynamic/vignette-color-000000-strength-0.5--resize-width-100/8bbff49a384a4682fd05144ffe77a84f29f112ff.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Operations{
+		&RawOperation{OperationName: "vignette", Options: json.RawMessage(`{"color":"000000","strength":"0.5"}`)},
+		&ResizeOperation{Width: IntPtr(100)},
+	}
+	if !reflect.DeepEqual(u.Operations, expected) {
+		t.Errorf("Expected '%#v', got '%#v'", expected, u.Operations)
+	}
+}
+
+func TestParseURL_Invalid(t *testing.T) {
+	table := []string{
+		"/dynamic/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+		"https://test.rokka.io/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+		"https://test.rokka.io/dynamic/8bbff49a384a4682fd05144ffe77a84f29f112ff",
+		"https://test.rokka.io/dynamic/not-a-hash.png",
+		"https://test.rokka.io/dynamic/unknown-width/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+		"https://test.rokka.io/dynamic/resize-depth-100/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+		"https://test.rokka.io/dynamic/resize-width-abc/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+		"https://test.rokka.io/dynamic/resize-width/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+		"https://test.rokka.io/dynamic/options-autoformat/8bbff49a384a4682fd05144ffe77a84f29f112ff.png",
+	}
+
+	for _, v := range table {
+		if u, err := ParseURL(v); err == nil {
+			t.Errorf("Expected an error parsing '%s', got '%#v'", v, u)
+		}
+	}
+}
+
+func TestParseOperations(t *testing.T) {
+	ops, err := ParseOperations("resize-width-200-mode-fill--grayscale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Operations{&ResizeOperation{Width: IntPtr(200), Mode: StrPtr("fill")}, &GrayscaleOperation{}}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("Expected '%#v', got '%#v'", expected, ops)
+	}
+
+	ops, err = ParseOperations("resize-width-100--vignette")
+	if err != nil || !reflect.DeepEqual(ops[1], &RawOperation{OperationName: "vignette"}) {
+		t.Errorf("Expected an unknown operation to be kept as raw operation, got '%#v' (%v)", ops, err)
+	}
+	if _, err := ParseOperations("resize--vignette-strength"); err == nil {
+		t.Error("Expected an error for an option of an unknown operation without value")
+	}
+}
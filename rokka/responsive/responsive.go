@@ -0,0 +1,307 @@
+// Package responsive generates srcset and sizes attributes and <picture> markup for images rendered by rokka.
+//
+// A Policy describes the candidates of an image, either as a list of widths for different breakpoints
+// (`300w, 600w`) or as pixel densities of a fixed width (`1x, 2x`):
+//
+//    img := responsive.FromSourceImage(sourceImage)
+//    html, err := responsive.Picture(c, img, "product", responsive.Breakpoints("(min-width: 800px) 50vw, 100vw", 400, 800, 1600).WithFormats("avif", "webp"), "Red shoes")
+//
+// Candidates wider than the source image are omitted unless upscaling is allowed.
+package responsive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+var errNoCandidates = errors.New("responsive: policy doesn't contain any width or pixel density")
+
+// Image is the source image to render.
+type Image struct {
+	Organization string
+	Hash         string
+	// Format is the format of the fallback image, alternative formats are defined by the Policy.
+	Format string
+	// Width and Height are the dimensions of the source image. If they're unknown, candidates aren't limited to the
+	// width of the source image.
+	Width  int
+	Height int
+	// Name is used as SEO filename of the URLs if set.
+	Name string
+}
+
+// FromSourceImage returns the Image of a source image as returned by GetSourceImage. The name of the source image
+// without its extension is used as SEO filename.
+func FromSourceImage(img rokka.GetSourceImageResponse) Image {
+	return Image{
+		Organization: img.Organization,
+		Hash:         img.Hash,
+		Format:       img.Format,
+		Width:        img.Width,
+		Height:       img.Height,
+		Name:         rokka.SEOFilename(strings.TrimSuffix(img.Name, path.Ext(img.Name))),
+	}
+}
+
+// Policy defines the candidates of a srcset.
+type Policy struct {
+	// Widths lists the widths of the candidates, resulting in width descriptors, e.g. `400w`.
+	Widths []int
+	// Sizes is the value of the sizes attribute used together with Widths, e.g. `(min-width: 800px) 50vw, 100vw`.
+	Sizes string
+
+	// Width is the width of the image used together with DPRs.
+	Width int
+	// DPRs lists the pixel densities of the candidates, resulting in pixel density descriptors, e.g. `2x`.
+	DPRs []float64
+
+	// AllowUpscaling allows candidates wider than the source image.
+	AllowUpscaling bool
+	// Formats are alternative formats offered as <source> of a <picture> in the given order, e.g. avif and webp.
+	Formats []string
+	// Operations are added on top of the stack before the image is resized.
+	Operations []rokka.Operation
+}
+
+// Breakpoints returns a Policy with candidates of the given widths.
+func Breakpoints(sizes string, widths ...int) Policy {
+	return Policy{Sizes: sizes, Widths: widths}
+}
+
+// DPR returns a Policy with candidates of the given pixel densities of an image with a fixed width.
+func DPR(width int, dprs ...float64) Policy {
+	return Policy{Width: width, DPRs: dprs}
+}
+
+// WithFormats returns a copy of the policy offering the given alternative formats.
+func (p Policy) WithFormats(formats ...string) Policy {
+	p.Formats = formats
+	return p
+}
+
+// WithUpscaling returns a copy of the policy allowing candidates wider than the source image.
+func (p Policy) WithUpscaling() Policy {
+	p.AllowUpscaling = true
+	return p
+}
+
+// Candidate is a single URL of a srcset.
+type Candidate struct {
+	URL string
+	// Width is the width of the rendered image in pixels.
+	Width int
+	// DPR is the pixel density of a candidate of a DPR policy, zero otherwise.
+	DPR float64
+}
+
+// Descriptor returns the width or pixel density descriptor of the candidate, e.g. `400w` or `2x`.
+func (c Candidate) Descriptor() string {
+	if c.DPR != 0 {
+		return strconv.FormatFloat(c.DPR, 'f', -1, 64) + "x"
+	}
+	return strconv.Itoa(c.Width) + "w"
+}
+
+// Candidates returns the candidates of the image in the given format, ordered by width.
+func Candidates(c *rokka.Client, img Image, stack, format string, p Policy) ([]Candidate, error) {
+	res := make([]Candidate, 0)
+	if len(p.DPRs) > 0 {
+		if p.Width <= 0 {
+			return nil, fmt.Errorf("responsive: a DPR policy needs a width, got %d", p.Width)
+		}
+		dprs := append([]float64{}, p.DPRs...)
+		sort.Float64s(dprs)
+		for _, dpr := range dprs {
+			w := int(float64(p.Width)*dpr + 0.5)
+			if !p.allows(img, w) {
+				continue
+			}
+			u, err := buildURL(c, img, stack, format, p, p.Width, dpr)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, Candidate{URL: u, Width: w, DPR: dpr})
+		}
+		return res, nil
+	}
+
+	if len(p.Widths) == 0 {
+		return nil, errNoCandidates
+	}
+	widths := append([]int{}, p.Widths...)
+	sort.Ints(widths)
+	capped := false
+	for _, w := range widths {
+		if !p.allows(img, w) {
+			capped = true
+			continue
+		}
+		if len(res) > 0 && res[len(res)-1].Width == w {
+			continue
+		}
+		u, err := buildURL(c, img, stack, format, p, w, 1)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Candidate{URL: u, Width: w})
+	}
+	// offer the full width of the source image instead of the omitted larger widths.
+	if capped && (len(res) == 0 || res[len(res)-1].Width < img.Width) {
+		u, err := buildURL(c, img, stack, format, p, img.Width, 1)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, Candidate{URL: u, Width: img.Width})
+	}
+	return res, nil
+}
+
+// allows returns whether an image of the given width may be rendered.
+func (p Policy) allows(img Image, width int) bool {
+	return p.AllowUpscaling || img.Width <= 0 || width <= img.Width
+}
+
+func buildURL(c *rokka.Client, img Image, stack, format string, p Policy, width int, dpr float64) (string, error) {
+	b := c.NewURLBuilder(img.Organization, stack, img.Hash, format).
+		Operations(p.Operations...).
+		Operations(rokka.ResizeOperation{Width: rokka.IntPtr(width)}).
+		SEOFilename(img.Name)
+	if dpr != 1 {
+		b.Option("dpr", dpr)
+	}
+	return b.Build()
+}
+
+// SrcSet returns the value of the srcset attribute of the image in its own format.
+func SrcSet(c *rokka.Client, img Image, stack string, p Policy) (string, error) {
+	candidates, err := Candidates(c, img, stack, img.Format, p)
+	if err != nil {
+		return "", err
+	}
+	return joinCandidates(candidates), nil
+}
+
+func joinCandidates(candidates []Candidate) string {
+	parts := make([]string, len(candidates))
+	for i, c := range candidates {
+		parts[i] = c.URL + " " + c.Descriptor()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// mimeTypes maps render formats to the type attribute of a <source>.
+var mimeTypes = map[string]string{
+	"avif": "image/avif",
+	"gif":  "image/gif",
+	"heif": "image/heif",
+	"jp2":  "image/jp2",
+	"jpg":  "image/jpeg",
+	"png":  "image/png",
+	"svg":  "image/svg+xml",
+	"webp": "image/webp",
+}
+
+type pictureSource struct {
+	Type   string
+	SrcSet string
+}
+
+type picture struct {
+	Sources []pictureSource
+	Src     string
+	SrcSet  string
+	Sizes   string
+	Width   int
+	Height  int
+	Alt     string
+}
+
+var pictureTemplate = template.Must(template.New("picture").Parse(`<picture>
+{{- range .Sources}}
+  <source type="{{.Type}}" srcset="{{.SrcSet}}"{{if $.Sizes}} sizes="{{$.Sizes}}"{{end}}>
+{{- end}}
+  <img src="{{.Src}}" srcset="{{.SrcSet}}"{{if .Sizes}} sizes="{{.Sizes}}"{{end}}{{if .Width}} width="{{.Width}}"{{end}}{{if .Height}} height="{{.Height}}"{{end}} alt="{{.Alt}}">
+</picture>`))
+
+// Picture returns a <picture> element with a <source> for every alternative format of the policy and an <img> in the
+// format of the image as fallback. The src of the <img> is the smallest candidate, its width and height attributes
+// are set to the dimensions of that candidate to allow the browser reserving space for it.
+func Picture(c *rokka.Client, img Image, stack string, p Policy, alt string) (template.HTML, error) {
+	fallback, err := Candidates(c, img, stack, img.Format, p)
+	if err != nil {
+		return "", err
+	}
+	if len(fallback) == 0 {
+		return "", errNoCandidates
+	}
+
+	data := picture{
+		SrcSet: joinCandidates(fallback),
+		Src:    fallback[0].URL,
+		Alt:    alt,
+	}
+	if len(p.DPRs) == 0 {
+		data.Sizes = p.Sizes
+	}
+	data.Width = fallback[0].Width
+	if fallback[0].DPR != 0 {
+		data.Width = p.Width
+	}
+	if img.Width > 0 && img.Height > 0 {
+		data.Height = int(float64(data.Width)*float64(img.Height)/float64(img.Width) + 0.5)
+	}
+
+	for _, f := range p.Formats {
+		if f == img.Format {
+			continue
+		}
+		typ, ok := mimeTypes[f]
+		if !ok {
+			return "", fmt.Errorf("responsive: unknown format %s", f)
+		}
+		candidates, err := Candidates(c, img, stack, f, p)
+		if err != nil {
+			return "", err
+		}
+		data.Sources = append(data.Sources, pictureSource{Type: typ, SrcSet: joinCandidates(candidates)})
+	}
+
+	var b bytes.Buffer
+	if err := pictureTemplate.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
+
+// FuncMap returns functions to be used in html/template templates:
+//
+//    rokkaBreakpoints "100vw" 400 800     returns a Policy, see Breakpoints
+//    rokkaDPR 300 1.0 2.0                 returns a Policy, see DPR
+//    rokkaFormats policy "avif" "webp"    returns a copy of the policy with alternative formats
+//    rokkaSrcset image stack policy       returns the srcset of the image
+//    rokkaPicture image stack policy alt  returns the <picture> element of the image
+//
+// E.g. `{{rokkaPicture .Image "product" (rokkaFormats (rokkaBreakpoints "100vw" 400 800) "webp") .Title}}`.
+func FuncMap(c *rokka.Client) template.FuncMap {
+	return template.FuncMap{
+		"rokkaBreakpoints": Breakpoints,
+		"rokkaDPR":         DPR,
+		"rokkaFormats": func(p Policy, formats ...string) Policy {
+			return p.WithFormats(formats...)
+		},
+		"rokkaSrcset": func(img Image, stack string, p Policy) (string, error) {
+			return SrcSet(c, img, stack, p)
+		},
+		"rokkaPicture": func(img Image, stack string, p Policy, alt string) (template.HTML, error) {
+			return Picture(c, img, stack, p, alt)
+		},
+	}
+}
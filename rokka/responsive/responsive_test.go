@@ -0,0 +1,121 @@
+package responsive
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/rokka"
+)
+
+const hash = "c1b110"
+
+var testImage = Image{Organization: "test", Hash: hash, Format: "jpg", Width: 1000, Height: 500}
+
+func TestSrcSet(t *testing.T) {
+	c := rokka.NewClient(&rokka.Config{})
+	base := "https://test.rokka.io/product/"
+
+	table := []struct {
+		policy   Policy
+		expected string
+	}{
+		{
+			Breakpoints("100vw", 800, 400),
+			base + "resize-width-400/" + hash + ".jpg 400w, " + base + "resize-width-800/" + hash + ".jpg 800w",
+		},
+		{
+			Breakpoints("100vw", 400, 800, 1600, 3200),
+			base + "resize-width-400/" + hash + ".jpg 400w, " + base + "resize-width-800/" + hash + ".jpg 800w, " + base + "resize-width-1000/" + hash + ".jpg 1000w",
+		},
+		{
+			Breakpoints("100vw", 800, 1600).WithUpscaling(),
+			base + "resize-width-800/" + hash + ".jpg 800w, " + base + "resize-width-1600/" + hash + ".jpg 1600w",
+		},
+		{
+			DPR(400, 1, 2, 3),
+			base + "resize-width-400/" + hash + ".jpg 1x, " + base + "resize-width-400--options-dpr-2/" + hash + ".jpg 2x",
+		},
+		{
+			DPR(400, 1, 1.5).WithUpscaling(),
+			base + "resize-width-400/" + hash + ".jpg 1x, " + base + "resize-width-400--options-dpr-1.5/" + hash + ".jpg 1.5x",
+		},
+	}
+
+	for _, v := range table {
+		res, err := SrcSet(c, testImage, "product", v.policy)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res != v.expected {
+			t.Errorf("Expected '%s', got '%s'", v.expected, res)
+		}
+	}
+
+	if _, err := SrcSet(c, testImage, "product", Policy{}); err == nil {
+		t.Error("Expected an error for an empty policy")
+	}
+	if _, err := SrcSet(c, testImage, "product", DPR(0, 1, 2)); err == nil {
+		t.Error("Expected an error for a DPR policy without width")
+	}
+}
+
+func TestPicture(t *testing.T) {
+	c := rokka.NewClient(&rokka.Config{})
+	img := testImage
+	img.Name = "Red shoes"
+
+	res, err := Picture(c, img, "product", Breakpoints("(min-width: 800px) 50vw, 100vw", 400, 800).WithFormats("avif", "webp", "jpg"), `Red "shoes"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := "https://test.rokka.io/product/resize-width-"
+	expected := `<picture>
+  <source type="image/avif" srcset="` + base + `400/c1b110/Red-shoes.avif 400w, ` + base + `800/c1b110/Red-shoes.avif 800w" sizes="(min-width: 800px) 50vw, 100vw">
+  <source type="image/webp" srcset="` + base + `400/c1b110/Red-shoes.webp 400w, ` + base + `800/c1b110/Red-shoes.webp 800w" sizes="(min-width: 800px) 50vw, 100vw">
+  <img src="` + base + `400/c1b110/Red-shoes.jpg" srcset="` + base + `400/c1b110/Red-shoes.jpg 400w, ` + base + `800/c1b110/Red-shoes.jpg 800w" sizes="(min-width: 800px) 50vw, 100vw" width="400" height="200" alt="Red &#34;shoes&#34;">
+</picture>`
+	if string(res) != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, res)
+	}
+
+	if _, err := Picture(c, img, "product", Breakpoints("100vw", 400).WithFormats("bmp"), ""); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	c := rokka.NewClient(&rokka.Config{})
+	tpl := template.Must(template.New("").Funcs(FuncMap(c)).Parse(
+		`<img srcset="{{rokkaSrcset .Image "product" (rokkaDPR 300 1.0 2.0)}}">{{rokkaPicture .Image "product" (rokkaFormats (rokkaBreakpoints "100vw" 400) "webp") "alt"}}`,
+	))
+
+	var b bytes.Buffer
+	if err := tpl.Execute(&b, map[string]interface{}{"Image": testImage}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	expected := `<img srcset="https://test.rokka.io/product/resize-width-300/c1b110.jpg 1x, https://test.rokka.io/product/resize-width-300--options-dpr-2/c1b110.jpg 2x">`
+	if !strings.HasPrefix(out, expected) {
+		t.Errorf("Expected '%s' to start with '%s'", out, expected)
+	}
+	if !strings.Contains(out, `<source type="image/webp" srcset="https://test.rokka.io/product/resize-width-400/c1b110.webp 400w" sizes="100vw">`) {
+		t.Errorf("Expected '%s' to contain a webp source", out)
+	}
+}
+
+func TestFromSourceImage(t *testing.T) {
+	img := FromSourceImage(rokka.GetSourceImageResponse{Organization: "test", Hash: hash, Format: "png", Width: 10, Height: 20})
+	if img != (Image{Organization: "test", Hash: hash, Format: "png", Width: 10, Height: 20}) {
+		t.Errorf("Unexpected image '%v'", img)
+	}
+
+	img = FromSourceImage(rokka.GetSourceImageResponse{Organization: "test", Hash: hash, Format: "png", Name: "Red Shoes.PNG"})
+	if img.Name != "Red-Shoes" {
+		t.Errorf("Expected name '%s', got '%s'", "Red-Shoes", img.Name)
+	}
+}
@@ -0,0 +1,426 @@
+package rokka
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SortDirection defines the direction a search result is sorted by a field.
+type SortDirection string
+
+// Available sort directions. SortDefault lets rokka decide on the direction.
+const (
+	SortDefault SortDirection = ""
+	SortAsc     SortDirection = "asc"
+	SortDesc    SortDirection = "desc"
+)
+
+type searchValueKind int
+
+const (
+	searchText searchValueKind = iota
+	searchInt
+	searchTime
+)
+
+func (k searchValueKind) String() string {
+	switch k {
+	case searchInt:
+		return "int"
+	case searchTime:
+		return "time"
+	}
+	return "string"
+}
+
+// searchFields contains the fields of a source image which can be searched and sorted by, alongside their type.
+var searchFields = map[string]searchValueKind{
+	"hash":       searchText,
+	"binaryhash": searchText,
+	"format":     searchText,
+	"size":       searchInt,
+	"width":      searchInt,
+	"height":     searchInt,
+	"created":    searchTime,
+}
+
+// userMetadataFieldName matches the name of a user metadata field, optionally prefixed by its type (e.g. `int:likes`).
+var userMetadataFieldName = regexp.MustCompile(`^([a-z]+:)?[A-Za-z0-9_\-.]+$`)
+
+// SearchValue is the value a field is filtered by. It is created by one of the helper funcs like Exact,
+// IntBetween or TimeAfter.
+type SearchValue struct {
+	kind searchValueKind
+	expr string
+	err  error
+}
+
+// String returns the value in the format rokka expects within the query string.
+func (v SearchValue) String() string {
+	return v.expr
+}
+
+// searchEscaper escapes the characters having a special meaning in a search expression.
+var searchEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`)
+
+// Exact matches a field having exactly the given value.
+func Exact(s string) SearchValue {
+	return SearchValue{kind: searchText, expr: searchEscaper.Replace(s)}
+}
+
+// Wildcard matches a field using a pattern in which `*` stands for any number of characters, e.g. `foo*`.
+func Wildcard(pattern string) SearchValue {
+	v := SearchValue{kind: searchText, expr: pattern}
+	if !strings.Contains(pattern, "*") {
+		v.err = fmt.Errorf("wildcard pattern '%s' does not contain a '*'", pattern)
+	}
+	return v
+}
+
+// IntEquals matches a numeric field having exactly the value n.
+func IntEquals(n int) SearchValue {
+	return SearchValue{kind: searchInt, expr: strconv.Itoa(n)}
+}
+
+// IntBetween matches a numeric field having a value between from and to, both inclusive.
+func IntBetween(from, to int) SearchValue {
+	v := SearchValue{kind: searchInt, expr: searchRange(strconv.Itoa(from), strconv.Itoa(to))}
+	if from > to {
+		v.err = fmt.Errorf("range start %d is greater than its end %d", from, to)
+	}
+	return v
+}
+
+// IntAtLeast matches a numeric field having a value greater than or equal to n.
+func IntAtLeast(n int) SearchValue {
+	return SearchValue{kind: searchInt, expr: searchRange(strconv.Itoa(n), "*")}
+}
+
+// IntAtMost matches a numeric field having a value less than or equal to n.
+func IntAtMost(n int) SearchValue {
+	return SearchValue{kind: searchInt, expr: searchRange("*", strconv.Itoa(n))}
+}
+
+// TimeBetween matches a date field having a value between from and to, both inclusive.
+func TimeBetween(from, to time.Time) SearchValue {
+	v := SearchValue{kind: searchTime, expr: searchRange(searchTimeString(from), searchTimeString(to))}
+	if from.After(to) {
+		v.err = fmt.Errorf("range start %s is after its end %s", searchTimeString(from), searchTimeString(to))
+	}
+	return v
+}
+
+// TimeAfter matches a date field having a value equal to or after t.
+func TimeAfter(t time.Time) SearchValue {
+	return SearchValue{kind: searchTime, expr: searchRange(searchTimeString(t), "*")}
+}
+
+// TimeBefore matches a date field having a value equal to or before t.
+func TimeBefore(t time.Time) SearchValue {
+	return SearchValue{kind: searchTime, expr: searchRange("*", searchTimeString(t))}
+}
+
+func searchRange(from, to string) string {
+	return "[" + from + " TO " + to + "]"
+}
+
+func searchTimeString(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// SearchSort defines a field to sort the search result by.
+type SearchSort struct {
+	Field     string
+	Direction SortDirection
+}
+
+func (s SearchSort) String() string {
+	if s.Direction == SortDefault {
+		return s.Field
+	}
+	return s.Field + " " + string(s.Direction)
+}
+
+type searchFilter struct {
+	field string
+	value SearchValue
+}
+
+// SearchQuery builds a search for source images. It is passed to ListSourceImages or IterateSourceImages using
+// ListSourceImagesOptions.Query, e.g.:
+//
+//    q := rokka.NewSearchQuery().
+//        Filter("width", rokka.IntAtLeast(1000)).
+//        Filter("created", rokka.TimeAfter(time.Now().AddDate(0, -1, 0))).
+//        UserMetadata("title", rokka.Wildcard("holiday*")).
+//        Sort("created", rokka.SortDesc)
+//
+// See: https://rokka.io/documentation/references/searching-images.html
+type SearchQuery struct {
+	filters []searchFilter
+	sorts   []SearchSort
+}
+
+// NewSearchQuery creates an empty search query.
+func NewSearchQuery() *SearchQuery {
+	return &SearchQuery{}
+}
+
+// Filter restricts the search to source images whose field matches the value. Besides the fields of a source image
+// (hash, binaryhash, format, size, width, height and created), user metadata fields can be given by prefixing
+// them with `user:`.
+func (q *SearchQuery) Filter(field string, v SearchValue) *SearchQuery {
+	q.filters = append(q.filters, searchFilter{field: field, value: v})
+	return q
+}
+
+// UserMetadata restricts the search to source images whose user metadata field matches the value.
+// Unless the name already contains a type prefix (e.g. `int:likes`), it is derived from the value.
+func (q *SearchQuery) UserMetadata(name string, v SearchValue) *SearchQuery {
+	return q.Filter(userMetadataSearchField(name, v.kind), v)
+}
+
+// Sort adds a field to sort the result by. Calling Sort multiple times sorts by multiple fields in the given order.
+func (q *SearchQuery) Sort(field string, d SortDirection) *SearchQuery {
+	q.sorts = append(q.sorts, SearchSort{Field: field, Direction: d})
+	return q
+}
+
+// Validate checks all filters and sort fields of the query and returns an error listing every problem found.
+func (q *SearchQuery) Validate() error {
+	problems := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, f := range q.filters {
+		if seen[f.field] {
+			problems = append(problems, fmt.Sprintf("%s: filtered more than once", f.field))
+		}
+		seen[f.field] = true
+
+		kind, err := searchFieldKind(f.field)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", f.field, err))
+			continue
+		}
+		if f.value.err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", f.field, f.value.err))
+		} else if kind != f.value.kind {
+			problems = append(problems, fmt.Sprintf("%s: expected a value of type %s, got %s", f.field, kind, f.value.kind))
+		}
+	}
+
+	for _, s := range q.sorts {
+		if _, err := searchFieldKind(s.Field); err != nil {
+			problems = append(problems, fmt.Sprintf("sort %s: %s", s.Field, err))
+		}
+		if s.Direction != SortDefault && s.Direction != SortAsc && s.Direction != SortDesc {
+			problems = append(problems, fmt.Sprintf("sort %s: unknown direction '%s'", s.Field, s.Direction))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("rokka: invalid search query: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Values validates the query and returns it as query string params.
+func (q *SearchQuery) Values() (url.Values, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	v := make(url.Values)
+	for _, f := range q.filters {
+		v.Set(f.field, f.value.String())
+	}
+	if len(q.sorts) > 0 {
+		sorts := make([]string, len(q.sorts))
+		for i, s := range q.sorts {
+			sorts[i] = s.String()
+		}
+		v.Set("sort", strings.Join(sorts, ","))
+	}
+	return v, nil
+}
+
+// String returns the encoded query string of the query, or an empty string if it's invalid.
+func (q *SearchQuery) String() string {
+	v, err := q.Values()
+	if err != nil {
+		return ""
+	}
+	return v.Encode()
+}
+
+// ParseSearchValue parses an expression into a SearchValue matching the type of the field.
+// This allows to accept user input like CLI flags. The following expressions are supported:
+//
+//    foo, foo*                    exact or wildcard match of string fields
+//    100, 100..500, 100.., ..500  exact match or inclusive ranges of numeric fields
+//    2018-01-01..2018-02-01       inclusive ranges of date fields, either as a date or in RFC 3339
+//    [100 TO 500]                 the range syntax of rokka for numeric and date fields, `*` denotes an open end
+func ParseSearchValue(field, expr string) (SearchValue, error) {
+	kind, err := searchFieldKind(field)
+	if err != nil {
+		return SearchValue{}, fmt.Errorf("rokka: %s: %s", field, err)
+	}
+
+	if kind == searchText {
+		if strings.Contains(expr, "*") {
+			return Wildcard(expr), nil
+		}
+		return Exact(expr), nil
+	}
+
+	from, to, isRange := splitSearchRange(expr)
+	if kind == searchInt {
+		if !isRange {
+			n, err := strconv.Atoi(expr)
+			if err != nil {
+				return SearchValue{}, fmt.Errorf("rokka: %s: invalid number '%s'", field, expr)
+			}
+			return IntEquals(n), nil
+		}
+		f, errFrom := parseSearchBound(from, strconv.Atoi)
+		t, errTo := parseSearchBound(to, strconv.Atoi)
+		if errFrom != nil || errTo != nil {
+			return SearchValue{}, fmt.Errorf("rokka: %s: invalid range '%s'", field, expr)
+		}
+		switch {
+		case f == nil && t == nil:
+			return SearchValue{}, fmt.Errorf("rokka: %s: range '%s' is open on both ends", field, expr)
+		case f == nil:
+			return IntAtMost(*t), nil
+		case t == nil:
+			return IntAtLeast(*f), nil
+		}
+		return IntBetween(*f, *t), nil
+	}
+
+	if !isRange {
+		return SearchValue{}, fmt.Errorf("rokka: %s: expected a range of dates, got '%s'", field, expr)
+	}
+	f, errFrom := parseSearchTime(from)
+	t, errTo := parseSearchTime(to)
+	if errFrom != nil || errTo != nil {
+		return SearchValue{}, fmt.Errorf("rokka: %s: invalid range '%s'", field, expr)
+	}
+	switch {
+	case f == nil && t == nil:
+		return SearchValue{}, fmt.Errorf("rokka: %s: range '%s' is open on both ends", field, expr)
+	case f == nil:
+		return TimeBefore(*t), nil
+	case t == nil:
+		return TimeAfter(*f), nil
+	}
+	return TimeBetween(*f, *t), nil
+}
+
+// ParseSearchSort parses a comma separated list of fields each optionally followed by a direction,
+// e.g. `created desc,width`.
+func ParseSearchSort(expr string) ([]SearchSort, error) {
+	sorts := make([]SearchSort, 0)
+	for _, part := range strings.Split(expr, ",") {
+		fields := strings.Fields(part)
+		switch len(fields) {
+		case 1:
+			sorts = append(sorts, SearchSort{Field: fields[0]})
+		case 2:
+			sorts = append(sorts, SearchSort{Field: fields[0], Direction: SortDirection(strings.ToLower(fields[1]))})
+		default:
+			return nil, fmt.Errorf("rokka: invalid sort '%s'", strings.TrimSpace(part))
+		}
+	}
+	return sorts, nil
+}
+
+// searchFieldKind returns the type of values a field can be filtered by.
+func searchFieldKind(field string) (searchValueKind, error) {
+	if kind, ok := searchFields[field]; ok {
+		return kind, nil
+	}
+	if !strings.HasPrefix(field, "user:") {
+		return searchText, errors.New("unknown field")
+	}
+
+	name := strings.TrimPrefix(field, "user:")
+	if !userMetadataFieldName.MatchString(name) {
+		return searchText, errors.New("invalid user metadata field name")
+	}
+	switch {
+	case strings.HasPrefix(name, "int:"):
+		return searchInt, nil
+	case strings.HasPrefix(name, "date:"):
+		return searchTime, nil
+	}
+	return searchText, nil
+}
+
+// userMetadataSearchField returns the search field of a user metadata field, adding the type prefix if missing.
+func userMetadataSearchField(name string, kind searchValueKind) string {
+	if strings.Contains(name, ":") {
+		return "user:" + name
+	}
+	switch kind {
+	case searchInt:
+		return "user:int:" + name
+	case searchTime:
+		return "user:date:" + name
+	}
+	return "user:" + name
+}
+
+// splitSearchRange splits a range expression either in the form `from..to` or `[from TO to]`.
+// An open end is returned as an empty string.
+func splitSearchRange(expr string) (string, string, bool) {
+	if strings.HasPrefix(expr, "[") && strings.HasSuffix(expr, "]") {
+		parts := strings.SplitN(expr[1:len(expr)-1], " TO ", 2)
+		if len(parts) != 2 {
+			return "", "", false
+		}
+		from, to := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if from == "*" {
+			from = ""
+		}
+		if to == "*" {
+			to = ""
+		}
+		return from, to, true
+	}
+	parts := strings.SplitN(expr, "..", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+func parseSearchBound(s string, parse func(string) (int, error)) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	n, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func parseSearchTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
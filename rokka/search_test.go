@@ -0,0 +1,188 @@
+package rokka
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestSearchQuery_Values(t *testing.T) {
+	from := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2018, 2, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	table := []struct {
+		query    *SearchQuery
+		expected map[string]string
+	}{
+		{NewSearchQuery().Filter("width", IntEquals(100)), map[string]string{"width": "100"}},
+		{NewSearchQuery().Filter("width", IntBetween(100, 500)), map[string]string{"width": "[100 TO 500]"}},
+		{NewSearchQuery().Filter("height", IntAtLeast(100)), map[string]string{"height": "[100 TO *]"}},
+		{NewSearchQuery().Filter("size", IntAtMost(1024)), map[string]string{"size": "[* TO 1024]"}},
+		{NewSearchQuery().Filter("created", TimeBetween(from, to)), map[string]string{"created": "[2018-01-01T00:00:00Z TO 2018-02-01T11:30:00Z]"}},
+		{NewSearchQuery().Filter("created", TimeAfter(from)), map[string]string{"created": "[2018-01-01T00:00:00Z TO *]"}},
+		{NewSearchQuery().Filter("format", Exact("png")), map[string]string{"format": "png"}},
+		{NewSearchQuery().UserMetadata("title", Exact("a*b")), map[string]string{"user:title": `a\*b`}},
+		{NewSearchQuery().UserMetadata("title", Wildcard("holiday*")), map[string]string{"user:title": "holiday*"}},
+		{NewSearchQuery().UserMetadata("likes", IntAtLeast(10)), map[string]string{"user:int:likes": "[10 TO *]"}},
+		{NewSearchQuery().UserMetadata("published", TimeBefore(from)), map[string]string{"user:date:published": "[* TO 2018-01-01T00:00:00Z]"}},
+		{NewSearchQuery().Filter("user:int:likes", IntEquals(3)), map[string]string{"user:int:likes": "3"}},
+		{NewSearchQuery().Sort("created", SortDesc).Sort("user:int:likes", SortDefault), map[string]string{"sort": "created desc,user:int:likes"}},
+	}
+
+	for _, v := range table {
+		qs, err := v.query.Values()
+		if err != nil {
+			t.Errorf("Unexpected error '%s'", err)
+			continue
+		}
+		if len(qs) != len(v.expected) {
+			t.Errorf("Expected '%v', got '%v'", v.expected, qs)
+		}
+		for k, e := range v.expected {
+			if qs.Get(k) != e {
+				t.Errorf("Expected '%s' to be '%s', got '%s'", k, e, qs.Get(k))
+			}
+		}
+	}
+}
+
+func TestSearchQuery_Validate(t *testing.T) {
+	table := []struct {
+		query    *SearchQuery
+		expected []string
+	}{
+		{NewSearchQuery().Filter("unknown", IntEquals(1)), []string{"unknown: unknown field"}},
+		{NewSearchQuery().Filter("width", Exact("100")), []string{"width: expected a value of type int, got string"}},
+		{NewSearchQuery().Filter("width", IntBetween(500, 100)), []string{"width: range start 500 is greater than its end 100"}},
+		{NewSearchQuery().Filter("hash", Wildcard("abc")), []string{"hash: wildcard pattern 'abc' does not contain a '*'"}},
+		{NewSearchQuery().Filter("user:in valid", Exact("a")), []string{"user:in valid: invalid user metadata field name"}},
+		{NewSearchQuery().Sort("created", "up"), []string{"sort created: unknown direction 'up'"}},
+		{
+			NewSearchQuery().Filter("width", IntEquals(1)).Filter("width", IntEquals(2)).Sort("foo", SortAsc),
+			[]string{"width: filtered more than once", "sort foo: unknown field"},
+		},
+	}
+
+	for _, v := range table {
+		err := v.query.Validate()
+		if err == nil {
+			t.Errorf("Expected errors '%v', got nil", v.expected)
+			continue
+		}
+		for _, e := range v.expected {
+			if !strings.Contains(err.Error(), e) {
+				t.Errorf("Expected error '%s' to contain '%s'", err, e)
+			}
+		}
+	}
+}
+
+func TestParseSearchValue(t *testing.T) {
+	table := []struct {
+		field    string
+		expr     string
+		expected string
+		err      bool
+	}{
+		{"hash", "abc", "abc", false},
+		{"hash", "abc*", "abc*", false},
+		{"width", "100", "100", false},
+		{"width", "100..500", "[100 TO 500]", false},
+		{"width", "100..", "[100 TO *]", false},
+		{"width", "..500", "[* TO 500]", false},
+		{"width", "[100 TO *]", "[100 TO *]", false},
+		{"created", "2018-01-01..2018-02-01", "[2018-01-01T00:00:00Z TO 2018-02-01T00:00:00Z]", false},
+		{"created", "[2018-01-01T10:00:00+01:00 TO *]", "[2018-01-01T09:00:00Z TO *]", false},
+		{"user:int:likes", "10..", "[10 TO *]", false},
+		{"user:title", "foo", "foo", false},
+		{"width", "abc", "", true},
+		{"width", "..", "", true},
+		{"created", "2018-01-01", "", true},
+		{"created", "yesterday..", "", true},
+		{"unknown", "1", "", true},
+	}
+
+	for _, v := range table {
+		res, err := ParseSearchValue(v.field, v.expr)
+		if v.err {
+			if err == nil {
+				t.Errorf("Expected an error parsing '%s' of %s, got '%s'", v.expr, v.field, res)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error '%s'", err)
+			continue
+		}
+		if res.String() != v.expected {
+			t.Errorf("Expected '%s', got '%s'", v.expected, res)
+		}
+	}
+}
+
+func TestParseSearchSort(t *testing.T) {
+	res, err := ParseSearchSort("created desc, width,user:int:likes ASC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SearchSort{{"created", SortDesc}, {"width", SortDefault}, {"user:int:likes", SortAsc}}
+	if len(res) != len(expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, res)
+	}
+	for i, e := range expected {
+		if res[i] != e {
+			t.Errorf("Expected '%v', got '%v'", e, res[i])
+		}
+	}
+
+	if _, err := ParseSearchSort("created desc asc"); err == nil {
+		t.Error("Expected an error for an invalid sort")
+	}
+}
+
+func TestListSourceImages_SearchQuery(t *testing.T) {
+	org := "test"
+	r := test.NewResponse(http.StatusOK, "./fixtures/ListSourceImages.json")
+	r.Assertion = func(t *testing.T, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("limit") != "10" {
+			t.Errorf("Expected limit to be '10', got '%s'", q.Get("limit"))
+		}
+		if q.Get("width") != "[100 TO *]" {
+			t.Errorf("Expected width to be '[100 TO *]', got '%s'", q.Get("width"))
+		}
+		if q.Get("sort") != "created desc" {
+			t.Errorf("Expected sort to be 'created desc', got '%s'", q.Get("sort"))
+		}
+	}
+	ts := test.NewMockAPI(t, test.Routes{"GET /sourceimages/" + org: r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL})
+
+	_, err := c.ListSourceImages(org, ListSourceImagesOptions{
+		Limit: 10,
+		Query: NewSearchQuery().Filter("width", IntAtLeast(100)).Sort("created", SortDesc),
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = c.ListSourceImages(org, ListSourceImagesOptions{
+		Width: "100",
+		Query: NewSearchQuery().Filter("width", IntAtLeast(100)),
+	})
+	if err == nil {
+		t.Error("Expected an error when setting width in both the options and the search query")
+	}
+
+	_, err = c.ListSourceImages(org, ListSourceImagesOptions{
+		Query: NewSearchQuery().Filter("width", Exact("abc")),
+	})
+	if err == nil {
+		t.Error("Expected an error for an invalid search query")
+	}
+}
@@ -0,0 +1,181 @@
+package rokka
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Errors returned by VerifySignedURL.
+var (
+	ErrInvalidSignature = errors.New("rokka: invalid signature")
+	ErrSignatureExpired = errors.New("rokka: signature expired")
+)
+
+var errorSignatureKeyMissing = errors.New("rokka: signature key must be set to sign URLs")
+
+// signatureLength is the number of hex characters of the HMAC used as signature.
+const signatureLength = 16
+
+// signatureExpiryRounding is the interval the expiry of URLs signed by Client.SignURL is rounded up to. This way
+// URLs generated within the same interval are identical and can be cached.
+const signatureExpiryRounding = 5 * time.Minute
+
+// signatureTimeFormat is the format of the expiry in the `sigopts` parameter.
+const signatureTimeFormat = "2006-01-02T15:04:05-07:00"
+
+// signatureOptions are the options of a signature, passed as JSON in the `sigopts` parameter.
+type signatureOptions struct {
+	Until string `json:"until,omitempty"`
+}
+
+// SignURL signs a render URL with the given key. If until is not zero, the URL is only valid until then.
+// An existing signature of the URL is replaced.
+//
+// The signature is the HMAC-SHA256 of the path and query of the URL, truncated to 16 characters and appended as
+// `sig` parameter. The expiry is part of the signed query as `sigopts` parameter.
+//
+// See: https://rokka.io/documentation/references/signing-urls.html
+func SignURL(rawURL, key string, until time.Time) (string, error) {
+	if key == "" {
+		return "", errorSignatureKeyMissing
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	params := withoutQueryParams(u.RawQuery, "sig", "sigopts")
+	if !until.IsZero() {
+		b, err := json.Marshal(signatureOptions{Until: until.UTC().Format(signatureTimeFormat)})
+		if err != nil {
+			return "", err
+		}
+		params = append(params, "sigopts="+url.QueryEscape(string(b)))
+	}
+
+	u.RawQuery = strings.Join(params, "&")
+	sig := computeSignature(u, key)
+	if u.RawQuery == "" {
+		u.RawQuery = "sig=" + sig
+	} else {
+		u.RawQuery += "&sig=" + sig
+	}
+	return u.String(), nil
+}
+
+// VerifySignedURL checks whether the URL has been signed by one of the keys, which allows to rotate keys.
+// It returns ErrInvalidSignature if the signature is missing or doesn't match, and ErrSignatureExpired if the
+// signature is valid but its expiry has passed.
+func VerifySignedURL(rawURL string, keys []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	sig := q.Get("sig")
+	if len(sig) != signatureLength {
+		return ErrInvalidSignature
+	}
+
+	u.RawQuery = strings.Join(withoutQueryParams(u.RawQuery, "sig"), "&")
+	valid := false
+	for _, k := range keys {
+		if k != "" && hmac.Equal([]byte(computeSignature(u, k)), []byte(sig)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	if sigopts := q.Get("sigopts"); sigopts != "" {
+		opts := signatureOptions{}
+		if err := json.Unmarshal([]byte(sigopts), &opts); err != nil {
+			return ErrInvalidSignature
+		}
+		if opts.Until != "" {
+			until, err := time.Parse(signatureTimeFormat, opts.Until)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			if time.Now().After(until) {
+				return ErrSignatureExpired
+			}
+		}
+	}
+	return nil
+}
+
+// SignURL signs a render URL with the first of the configured signature keys. If a signature validity is configured,
+// the URL expires after it, rounded up to the next five minutes.
+func (c *Client) SignURL(rawURL string) (string, error) {
+	if len(c.config.SignatureKeys) == 0 {
+		return "", errorSignatureKeyMissing
+	}
+
+	var until time.Time
+	if c.config.SignatureValidity > 0 {
+		until = roundUpTime(time.Now().Add(c.config.SignatureValidity), signatureExpiryRounding)
+	}
+	return SignURL(rawURL, c.config.SignatureKeys[0], until)
+}
+
+// VerifySignedURL checks whether the URL has been signed by one of the configured signature keys.
+func (c *Client) VerifySignedURL(rawURL string) error {
+	return VerifySignedURL(rawURL, c.config.SignatureKeys)
+}
+
+// computeSignature returns the signature of the path and query of the URL.
+func computeSignature(u *url.URL, key string) string {
+	s := u.EscapedPath()
+	if u.RawQuery != "" {
+		s += "?" + u.RawQuery
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))[:signatureLength]
+}
+
+// withoutQueryParams removes the given parameters from a raw query. The remaining parameters are kept exactly as they
+// are, as the signature is computed on the query as sent.
+func withoutQueryParams(rawQuery string, names ...string) []string {
+	params := make([]string, 0)
+	for _, p := range strings.Split(rawQuery, "&") {
+		if p == "" {
+			continue
+		}
+		name := p
+		if i := strings.Index(p, "="); i >= 0 {
+			name = p[:i]
+		}
+		keep := true
+		for _, n := range names {
+			if n == name {
+				keep = false
+			}
+		}
+		if keep {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// roundUpTime rounds t up to the next multiple of d.
+func roundUpTime(t time.Time, d time.Duration) time.Time {
+	r := t.Truncate(d)
+	if r.Before(t) {
+		r = r.Add(d)
+	}
+	return r
+}
@@ -0,0 +1,99 @@
+package rokka
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRenderURL = "https://test.rokka.io/dynamic/noop/8bbff49a384a4682fd05144ffe77a84f29f112ff.jpg"
+
+func TestSignURL(t *testing.T) {
+	until := time.Date(2018, 1, 1, 1, 5, 0, 0, time.FixedZone("CET", 3600))
+	table := []struct {
+		url      string
+		until    time.Time
+		expected string
+	}{
+		{testRenderURL, time.Time{}, testRenderURL + "?sig=e27f54497a58d240"},
+		{testRenderURL + "?sig=0000000000000000", time.Time{}, testRenderURL + "?sig=e27f54497a58d240"},
+		{testRenderURL + "?v=%7B%7D", until, testRenderURL + "?v=%7B%7D&sigopts=%7B%22until%22%3A%222018-01-01T00%3A05%3A00%2B00%3A00%22%7D&sig=8980fe9dfaae277f"},
+	}
+
+	for _, v := range table {
+		res, err := SignURL(v.url, "secret", v.until)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res != v.expected {
+			t.Errorf("Expected '%s', got '%s'", v.expected, res)
+		}
+	}
+
+	if _, err := SignURL(testRenderURL, "", time.Time{}); err == nil {
+		t.Error("Expected an error signing without a key")
+	}
+}
+
+func TestVerifySignedURL(t *testing.T) {
+	signed, err := SignURL(testRenderURL, "secret", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := SignURL(testRenderURL, "secret", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		url      string
+		keys     []string
+		expected error
+	}{
+		{signed, []string{"secret"}, nil},
+		{signed, []string{"old", "secret"}, nil},
+		{signed, []string{"other"}, ErrInvalidSignature},
+		{signed, []string{}, ErrInvalidSignature},
+		{strings.Replace(signed, "noop", "grayscale", 1), []string{"secret"}, ErrInvalidSignature},
+		{strings.Replace(signed, "sigopts=", "sigopts=x", 1), []string{"secret"}, ErrInvalidSignature},
+		{testRenderURL, []string{"secret"}, ErrInvalidSignature},
+		{expired, []string{"secret"}, ErrSignatureExpired},
+	}
+
+	for _, v := range table {
+		if err := VerifySignedURL(v.url, v.keys); err != v.expected {
+			t.Errorf("Expected '%v' verifying '%s', got '%v'", v.expected, v.url, err)
+		}
+	}
+}
+
+func TestGetURL_Signed(t *testing.T) {
+	c := NewClient(&Config{SignatureKeys: []string{"secret", "old"}, SignURLs: true, SignatureValidity: time.Hour})
+	u, err := c.GetURL("test", "8bbff49a384a4682fd05144ffe77a84f29f112ff", "jpg", []Operation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(u, testRenderURL+"?sigopts=") || !strings.Contains(u, "&sig=") {
+		t.Errorf("Expected a signed URL with expiry, got '%s'", u)
+	}
+	if err := c.VerifySignedURL(u); err != nil {
+		t.Errorf("Unexpected error verifying '%s': %s", u, err)
+	}
+
+	c = NewClient(&Config{SignURLs: true})
+	if _, err := c.GetURL("test", "8bbff49a384a4682fd05144ffe77a84f29f112ff", "jpg", []Operation{}); err == nil {
+		t.Error("Expected an error signing without a key")
+	}
+}
+
+func TestRoundUpTime(t *testing.T) {
+	from := time.Date(2018, 1, 1, 10, 1, 0, 0, time.UTC)
+	if r := roundUpTime(from, 5*time.Minute); !r.Equal(time.Date(2018, 1, 1, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("Expected 10:05, got '%s'", r)
+	}
+	from = time.Date(2018, 1, 1, 10, 5, 0, 0, time.UTC)
+	if r := roundUpTime(from, 5*time.Minute); !r.Equal(from) {
+		t.Errorf("Expected 10:05, got '%s'", r)
+	}
+}
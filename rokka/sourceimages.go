@@ -2,10 +2,10 @@ package rokka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -34,6 +34,29 @@ type ListSourceImagesOptions struct {
 	Created string `url:"created,omitempty"`
 	// Sort by a specific field
 	Sort string `url:"sort,omitempty"`
+	// Query contains typed filters and sort fields. It's validated before sending and can't be combined
+	// with the string fields filtering or sorting by the same field.
+	Query *SearchQuery `url:"-"`
+}
+
+// values returns the query string params of the options including the ones of the search query.
+func (o ListSourceImagesOptions) values() (url.Values, error) {
+	qs, err := query.Values(o)
+	if err != nil || o.Query == nil {
+		return qs, err
+	}
+
+	sqs, err := o.Query.Values()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range sqs {
+		if _, ok := qs[k]; ok {
+			return nil, fmt.Errorf("rokka: %s is set by both the options and the search query", k)
+		}
+		qs[k] = v
+	}
+	return qs, nil
 }
 
 // ListSourceImagesResponse contains a list of source images alongside a total and pagination links.
@@ -101,14 +124,22 @@ func init() {
 //
 // See: https://rokka.io/documentation/references/searching-images.html
 func (c *Client) ListSourceImages(org string, options ListSourceImagesOptions) (ListSourceImagesResponse, error) {
-	result := ListSourceImagesResponse{}
+	return c.ListSourceImagesWithContext(context.Background(), org, options)
+}
 
-	qs, err := query.Values(options)
+// ListSourceImagesWithContext is the same as ListSourceImages with the addition of passing a context.
+func (c *Client) ListSourceImagesWithContext(ctx context.Context, org string, options ListSourceImagesOptions) (ListSourceImagesResponse, error) {
+	qs, err := options.values()
 	if err != nil {
-		return result, err
+		return ListSourceImagesResponse{}, err
 	}
+	return c.listSourceImages(ctx, org, qs)
+}
+
+func (c *Client) listSourceImages(ctx context.Context, org string, qs url.Values) (ListSourceImagesResponse, error) {
+	result := ListSourceImagesResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/sourceimages/"+org, nil, qs)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/sourceimages/"+org, nil, qs)
 	if err != nil {
 		return result, err
 	}
@@ -122,9 +153,14 @@ func (c *Client) ListSourceImages(org string, options ListSourceImagesOptions) (
 //
 // See: https://rokka.io/documentation/references/source-images.html#retrieve-data-about-a-source-image
 func (c *Client) GetSourceImage(org, hash string) (GetSourceImageResponse, error) {
+	return c.GetSourceImageWithContext(context.Background(), org, hash)
+}
+
+// GetSourceImageWithContext is the same as GetSourceImage with the addition of passing a context.
+func (c *Client) GetSourceImageWithContext(ctx context.Context, org, hash string) (GetSourceImageResponse, error) {
 	result := GetSourceImageResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, fmt.Sprintf("/sourceimages/%s/%s", org, hash), nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("/sourceimages/%s/%s", org, hash), nil, nil)
 	if err != nil {
 		return result, err
 	}
@@ -152,9 +188,14 @@ func downloadResponseHandler(resp *http.Response, v interface{}) error {
 //
 // See: https://rokka.io/documentation/references/source-images.html
 func (c *Client) DownloadSourceImage(org, hash string) (DownloadSourceImageResponse, error) {
+	return c.DownloadSourceImageWithContext(context.Background(), org, hash)
+}
+
+// DownloadSourceImageWithContext is the same as DownloadSourceImage with the addition of passing a context.
+func (c *Client) DownloadSourceImageWithContext(ctx context.Context, org, hash string) (DownloadSourceImageResponse, error) {
 	result := DownloadSourceImageResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, fmt.Sprintf("/sourceimages/%s/%s/download", org, hash), nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("/sourceimages/%s/%s/download", org, hash), nil, nil)
 	if err != nil {
 		return result, err
 	}
@@ -168,7 +209,12 @@ func (c *Client) DownloadSourceImage(org, hash string) (DownloadSourceImageRespo
 //
 // See: https://rokka.io/documentation/references/source-images.html
 func (c *Client) DeleteSourceImage(org, hash string) error {
-	req, err := c.NewRequest(http.MethodDelete, fmt.Sprintf("/sourceimages/%s/%s", org, hash), nil, nil)
+	return c.DeleteSourceImageWithContext(context.Background(), org, hash)
+}
+
+// DeleteSourceImageWithContext is the same as DeleteSourceImage with the addition of passing a context.
+func (c *Client) DeleteSourceImageWithContext(ctx context.Context, org, hash string) error {
+	req, err := c.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("/sourceimages/%s/%s", org, hash), nil, nil)
 	if err != nil {
 		return err
 	}
@@ -179,10 +225,15 @@ func (c *Client) DeleteSourceImage(org, hash string) error {
 //
 // See: https://rokka.io/documentation/references/source-images.html
 func (c *Client) DeleteSourceImageByBinaryHash(org, binaryHash string) error {
+	return c.DeleteSourceImageByBinaryHashWithContext(context.Background(), org, binaryHash)
+}
+
+// DeleteSourceImageByBinaryHashWithContext is the same as DeleteSourceImageByBinaryHash with the addition of passing a context.
+func (c *Client) DeleteSourceImageByBinaryHashWithContext(ctx context.Context, org, binaryHash string) error {
 	qs := url.Values{}
 	qs.Set("binaryHash", binaryHash)
 
-	req, err := c.NewRequest(http.MethodDelete, fmt.Sprintf("/sourceimages/%s", org), nil, qs)
+	req, err := c.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("/sourceimages/%s", org), nil, qs)
 	if err != nil {
 		return err
 	}
@@ -193,7 +244,12 @@ func (c *Client) DeleteSourceImageByBinaryHash(org, binaryHash string) error {
 //
 // See: https://rokka.io/documentation/references/source-images.html
 func (c *Client) RestoreSourceImage(org, hash string) error {
-	req, err := c.NewRequest(http.MethodPost, fmt.Sprintf("/sourceimages/%s/%s/restore", org, hash), nil, nil)
+	return c.RestoreSourceImageWithContext(context.Background(), org, hash)
+}
+
+// RestoreSourceImageWithContext is the same as RestoreSourceImage with the addition of passing a context.
+func (c *Client) RestoreSourceImageWithContext(ctx context.Context, org, hash string) error {
+	req, err := c.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("/sourceimages/%s/%s/restore", org, hash), nil, nil)
 	if err != nil {
 		return err
 	}
@@ -204,11 +260,16 @@ func (c *Client) RestoreSourceImage(org, hash string) error {
 //
 // See: https://rokka.io/documentation/references/source-images.html
 func (c *Client) CopySourceImage(sourceOrg, hash string, destinationOrg string) error {
-	req, err := c.NewRequest("COPY", fmt.Sprintf("/sourceimages/%s/%s", sourceOrg, hash), nil, nil)
-	req.Header.Add("Destination", destinationOrg)
+	return c.CopySourceImageWithContext(context.Background(), sourceOrg, hash, destinationOrg)
+}
+
+// CopySourceImageWithContext is the same as CopySourceImage with the addition of passing a context.
+func (c *Client) CopySourceImageWithContext(ctx context.Context, sourceOrg, hash string, destinationOrg string) error {
+	req, err := c.NewRequestWithContext(ctx, "COPY", fmt.Sprintf("/sourceimages/%s/%s", sourceOrg, hash), nil, nil)
 	if err != nil {
 		return err
 	}
+	req.Header.Add("Destination", destinationOrg)
 	return c.Call(req, nil, nil)
 }
 
@@ -223,16 +284,21 @@ type CreateCopySourceImagesResponse struct {
 //
 // See: https://rokka.io/documentation/references/source-images.html
 func (c *Client) CopySourceImages(sourceOrg string, hashes []string, destinationOrg string) (int, int, error) {
+	return c.CopySourceImagesWithContext(context.Background(), sourceOrg, hashes, destinationOrg)
+}
+
+// CopySourceImagesWithContext is the same as CopySourceImages with the addition of passing a context.
+func (c *Client) CopySourceImagesWithContext(ctx context.Context, sourceOrg string, hashes []string, destinationOrg string) (int, int, error) {
 	b := new(bytes.Buffer)
 	err := json.NewEncoder(b).Encode(hashes)
 	if err != nil {
 		return 0, 0, err
 	}
-	req, err := c.NewRequest("POST", fmt.Sprintf("/sourceimages/%s/copy", sourceOrg), b, nil)
-	req.Header.Add("Destination", destinationOrg)
+	req, err := c.NewRequestWithContext(ctx, "POST", fmt.Sprintf("/sourceimages/%s/copy", sourceOrg), b, nil)
 	if err != nil {
 		return 0, 0, err
 	}
+	req.Header.Add("Destination", destinationOrg)
 	result := CreateCopySourceImagesResponse{}
 
 	err = c.CallJSONResponse(req, &result)
@@ -243,52 +309,42 @@ func (c *Client) CopySourceImages(sourceOrg string, hashes []string, destination
 //
 // See: https://rokka.io/documentation/references/source-images.html#create-a-source-image
 func (c *Client) CreateSourceImage(org, name string, data io.Reader) (CreateSourceImageResponse, error) {
-	return c.CreateSourceImageWithMetadata(org, name, data, nil, nil)
+	return c.CreateSourceImageWithContext(context.Background(), org, name, data)
+}
+
+// CreateSourceImageWithContext is the same as CreateSourceImage with the addition of passing a context.
+func (c *Client) CreateSourceImageWithContext(ctx context.Context, org, name string, data io.Reader) (CreateSourceImageResponse, error) {
+	return c.CreateSourceImageWithMetadataWithContext(ctx, org, name, data, nil, nil)
 }
 
 // CreateSourceImageWithMetadata uploads an image.
+// The image is streamed from data without being buffered in memory. If data is an io.Seeker (e.g. an *os.File),
+// the Content-Length is sent along and the upload can be replayed in case of a retry.
 //
 // See: https://rokka.io/documentation/references/source-images.html#create-a-source-image
 func (c *Client) CreateSourceImageWithMetadata(org, name string, data io.Reader, userMetadata, dynamicMetadata map[string]interface{}) (CreateSourceImageResponse, error) {
+	return c.CreateSourceImageWithMetadataWithContext(context.Background(), org, name, data, userMetadata, dynamicMetadata)
+}
+
+// CreateSourceImageWithMetadataWithContext is the same as CreateSourceImageWithMetadata with the addition of passing a context.
+func (c *Client) CreateSourceImageWithMetadataWithContext(ctx context.Context, org, name string, data io.Reader, userMetadata, dynamicMetadata map[string]interface{}) (CreateSourceImageResponse, error) {
 	result := CreateSourceImageResponse{}
 
-	b := &bytes.Buffer{}
-	w := multipart.NewWriter(b)
-	fw, err := w.CreateFormFile("filename", name)
-	if err != nil {
-		return result, err
-	}
-	if _, err = io.Copy(fw, data); err != nil {
-		return result, err
-	}
-	if userMetadata != nil {
-		ffw, err := w.CreateFormField("meta_user[0]")
-		if err != nil {
-			return result, err
-		}
-		err = json.NewEncoder(ffw).Encode(userMetadata)
-		if err != nil {
-			return result, err
-		}
-	}
-	for k, v := range dynamicMetadata {
-		ffw, err := w.CreateFormField(fmt.Sprintf("meta_dynamic[0][%s]", k))
-		if err != nil {
-			return result, err
-		}
-		err = json.NewEncoder(ffw).Encode(v)
-		if err != nil {
-			return result, err
-		}
-	}
-	w.Close()
+	u := newSourceImageUpload(name, data, userMetadata, dynamicMetadata)
+	// the length needs to be determined before streaming the body as it might seek in data.
+	contentLength := u.ContentLength()
 
-	req, err := c.NewRequest(http.MethodPost, fmt.Sprintf("/sourceimages/%s", org), b, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("/sourceimages/%s", org), nil, nil)
 	if err != nil {
 		return result, err
 	}
+	req.Body = u.Body()
+	req.GetBody = u.GetBody()
+	if contentLength >= 0 {
+		req.ContentLength = contentLength
+	}
 
-	req.Header.Add("Content-Type", w.FormDataContentType())
+	req.Header.Add("Content-Type", u.FormDataContentType())
 	err = c.CallJSONResponse(req, &result)
 
 	return result, err
@@ -311,6 +367,11 @@ func dynamicMetadataResponseHandler(resp *http.Response, v interface{}) error {
 //
 // See: https://rokka.io/documentation/references/dynamic-metadata.html
 func (c *Client) AddDynamicMetadata(org, hash, name string, data io.Reader, options DynamicMetadataOptions) (DynamicMetadataResponse, error) {
+	return c.AddDynamicMetadataWithContext(context.Background(), org, hash, name, data, options)
+}
+
+// AddDynamicMetadataWithContext is the same as AddDynamicMetadata with the addition of passing a context.
+func (c *Client) AddDynamicMetadataWithContext(ctx context.Context, org, hash, name string, data io.Reader, options DynamicMetadataOptions) (DynamicMetadataResponse, error) {
 	result := DynamicMetadataResponse{}
 
 	qs, err := query.Values(options)
@@ -318,7 +379,7 @@ func (c *Client) AddDynamicMetadata(org, hash, name string, data io.Reader, opti
 		return result, err
 	}
 
-	req, err := c.NewRequest(http.MethodPut, fmt.Sprintf("/sourceimages/%s/%s/meta/dynamic/%s", org, hash, name), data, qs)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("/sourceimages/%s/%s/meta/dynamic/%s", org, hash, name), data, qs)
 	if err != nil {
 		return result, err
 	}
@@ -333,6 +394,11 @@ func (c *Client) AddDynamicMetadata(org, hash, name string, data io.Reader, opti
 //
 // See: https://rokka.io/documentation/references/dynamic-metadata.html
 func (c *Client) DeleteDynamicMetadata(org, hash, name string, options DynamicMetadataOptions) (DynamicMetadataResponse, error) {
+	return c.DeleteDynamicMetadataWithContext(context.Background(), org, hash, name, options)
+}
+
+// DeleteDynamicMetadataWithContext is the same as DeleteDynamicMetadata with the addition of passing a context.
+func (c *Client) DeleteDynamicMetadataWithContext(ctx context.Context, org, hash, name string, options DynamicMetadataOptions) (DynamicMetadataResponse, error) {
 	result := DynamicMetadataResponse{}
 
 	qs, err := query.Values(options)
@@ -340,7 +406,7 @@ func (c *Client) DeleteDynamicMetadata(org, hash, name string, options DynamicMe
 		return result, err
 	}
 
-	req, err := c.NewRequest(http.MethodDelete, fmt.Sprintf("/sourceimages/%s/%s/meta/dynamic/%s", org, hash, name), nil, qs)
+	req, err := c.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("/sourceimages/%s/%s/meta/dynamic/%s", org, hash, name), nil, qs)
 	if err != nil {
 		return result, err
 	}
@@ -349,8 +415,8 @@ func (c *Client) DeleteDynamicMetadata(org, hash, name string, options DynamicMe
 	return result, err
 }
 
-func (c *Client) userMetadata(method, org, hash string, data io.Reader) error {
-	req, err := c.NewRequest(method, fmt.Sprintf("/sourceimages/%s/%s/meta/user", org, hash), data, nil)
+func (c *Client) userMetadata(ctx context.Context, method, org, hash string, data io.Reader) error {
+	req, err := c.NewRequestWithContext(ctx, method, fmt.Sprintf("/sourceimages/%s/%s/meta/user", org, hash), data, nil)
 	if err != nil {
 		return err
 	}
@@ -358,8 +424,8 @@ func (c *Client) userMetadata(method, org, hash string, data io.Reader) error {
 	return c.Call(req, nil, nil)
 }
 
-func (c *Client) userMetadataByName(method, org, hash, name string, data io.Reader) error {
-	req, err := c.NewRequest(method, fmt.Sprintf("/sourceimages/%s/%s/meta/user/%s", org, hash, name), data, nil)
+func (c *Client) userMetadataByName(ctx context.Context, method, org, hash, name string, data io.Reader) error {
+	req, err := c.NewRequestWithContext(ctx, method, fmt.Sprintf("/sourceimages/%s/%s/meta/user/%s", org, hash, name), data, nil)
 	if err != nil {
 		return err
 	}
@@ -372,33 +438,58 @@ func (c *Client) userMetadataByName(method, org, hash, name string, data io.Read
 //
 // See: https://rokka.io/documentation/references/image-metadata.html
 func (c *Client) SetUserMetadata(org, hash string, data io.Reader) error {
-	return c.userMetadata(http.MethodPut, org, hash, data)
+	return c.SetUserMetadataWithContext(context.Background(), org, hash, data)
+}
+
+// SetUserMetadataWithContext is the same as SetUserMetadata with the addition of passing a context.
+func (c *Client) SetUserMetadataWithContext(ctx context.Context, org, hash string, data io.Reader) error {
+	return c.userMetadata(ctx, http.MethodPut, org, hash, data)
 }
 
 // UpdateUserMetadata updates a source image by adding arbitrary metadata. Previous values get merged with the new values.
 //
 // See: https://rokka.io/documentation/references/image-metadata.html
 func (c *Client) UpdateUserMetadata(org, hash string, data io.Reader) error {
-	return c.userMetadata(http.MethodPatch, org, hash, data)
+	return c.UpdateUserMetadataWithContext(context.Background(), org, hash, data)
+}
+
+// UpdateUserMetadataWithContext is the same as UpdateUserMetadata with the addition of passing a context.
+func (c *Client) UpdateUserMetadataWithContext(ctx context.Context, org, hash string, data io.Reader) error {
+	return c.userMetadata(ctx, http.MethodPatch, org, hash, data)
 }
 
 // DeleteUserMetadata updates a source image by deleting existing metadata.
 //
 // See: https://rokka.io/documentation/references/image-metadata.html
 func (c *Client) DeleteUserMetadata(org, hash string) error {
-	return c.userMetadata(http.MethodDelete, org, hash, nil)
+	return c.DeleteUserMetadataWithContext(context.Background(), org, hash)
+}
+
+// DeleteUserMetadataWithContext is the same as DeleteUserMetadata with the addition of passing a context.
+func (c *Client) DeleteUserMetadataWithContext(ctx context.Context, org, hash string) error {
+	return c.userMetadata(ctx, http.MethodDelete, org, hash, nil)
 }
 
 // UpdateUserMetadataByName updates a source image by setting user metadata identified by name.
 //
 // See: https://rokka.io/documentation/references/image-metadata.html
 func (c *Client) UpdateUserMetadataByName(org, hash, name string, data io.Reader) error {
-	return c.userMetadataByName(http.MethodPut, org, hash, name, data)
+	return c.UpdateUserMetadataByNameWithContext(context.Background(), org, hash, name, data)
+}
+
+// UpdateUserMetadataByNameWithContext is the same as UpdateUserMetadataByName with the addition of passing a context.
+func (c *Client) UpdateUserMetadataByNameWithContext(ctx context.Context, org, hash, name string, data io.Reader) error {
+	return c.userMetadataByName(ctx, http.MethodPut, org, hash, name, data)
 }
 
 // DeleteUserMetadataByName updates a source image by removing user metadata identified by name.
 //
 // See: https://rokka.io/documentation/references/image-metadata.html
 func (c *Client) DeleteUserMetadataByName(org, hash, name string) error {
-	return c.userMetadataByName(http.MethodDelete, org, hash, name, nil)
+	return c.DeleteUserMetadataByNameWithContext(context.Background(), org, hash, name)
+}
+
+// DeleteUserMetadataByNameWithContext is the same as DeleteUserMetadataByName with the addition of passing a context.
+func (c *Client) DeleteUserMetadataByNameWithContext(ctx context.Context, org, hash, name string) error {
+	return c.userMetadataByName(ctx, http.MethodDelete, org, hash, name, nil)
 }
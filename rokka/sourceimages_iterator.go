@@ -0,0 +1,181 @@
+package rokka
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// SourceImageIterator iterates over all source images matching the given ListSourceImagesOptions. Pages are requested
+// as needed by following the next link, or the cursor if no next link is available.
+//
+// The iterator is used similar to a bufio.Scanner:
+//
+//    it := client.IterateSourceImages("example", rokka.ListSourceImagesOptions{Limit: 100})
+//    defer it.Close()
+//    for it.Next() {
+//        image := it.SourceImage()
+//        // ...
+//    }
+//    if err := it.Err(); err != nil {
+//        // handle error
+//    }
+//
+// A SourceImageIterator is not safe for concurrent use.
+type SourceImageIterator struct {
+	// Prefetch enables requesting the next page concurrently while the items of the current page are consumed.
+	// It needs to be set before the first call to Next.
+	Prefetch bool
+
+	client *Client
+	org    string
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// next is the query string of the page to request next, it is nil once the last page has been requested.
+	next    url.Values
+	pending chan sourceImagesPage
+
+	items   []GetSourceImageResponse
+	current GetSourceImageResponse
+	total   int
+	err     error
+}
+
+// sourceImagesPage is the result of requesting a single page.
+type sourceImagesPage struct {
+	query    url.Values
+	response ListSourceImagesResponse
+	err      error
+}
+
+// IterateSourceImages returns an iterator over all source images of an organization matching the options.
+// No request is sent before the first call to Next.
+//
+// See: https://rokka.io/documentation/references/searching-images.html
+func (c *Client) IterateSourceImages(org string, options ListSourceImagesOptions) *SourceImageIterator {
+	return c.IterateSourceImagesWithContext(context.Background(), org, options)
+}
+
+// IterateSourceImagesWithContext is the same as IterateSourceImages with the addition of passing a context.
+// Canceling the context stops the iteration, Err returns the error of the context in that case.
+func (c *Client) IterateSourceImagesWithContext(ctx context.Context, org string, options ListSourceImagesOptions) *SourceImageIterator {
+	it := &SourceImageIterator{
+		client: c,
+		org:    org,
+	}
+	if ctx == nil {
+		it.err = errors.New("rokka: nil context")
+		return it
+	}
+	it.ctx, it.cancel = context.WithCancel(ctx)
+	it.next, it.err = options.values()
+	return it
+}
+
+// Next advances the iterator to the next source image, requesting the next page if needed.
+// It returns false when there are no more source images or an error occurred.
+func (it *SourceImageIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	for len(it.items) == 0 {
+		if it.pending == nil {
+			if it.next == nil {
+				return false
+			}
+			it.request()
+		}
+
+		var page sourceImagesPage
+		select {
+		case page = <-it.pending:
+		case <-it.ctx.Done():
+			page.err = it.ctx.Err()
+		}
+		it.pending = nil
+
+		if page.err != nil {
+			it.err = page.err
+			it.Close()
+			return false
+		}
+
+		it.total = page.response.Total
+		it.items = page.response.Items
+		it.next = nextSourceImagesQuery(page.query, page.response)
+		if it.Prefetch && it.next != nil {
+			it.request()
+		}
+	}
+
+	it.current, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// SourceImage returns the source image the iterator currently points to.
+func (it *SourceImageIterator) SourceImage() GetSourceImageResponse {
+	return it.current
+}
+
+// Total returns the total amount of source images as reported by the last requested page.
+func (it *SourceImageIterator) Total() int {
+	return it.total
+}
+
+// Err returns the first error which occurred during iteration.
+func (it *SourceImageIterator) Err() error {
+	return it.err
+}
+
+// Close stops the iteration and cancels a pending request of the next page. It is safe to call Close multiple times.
+func (it *SourceImageIterator) Close() {
+	if it.cancel != nil {
+		it.cancel()
+	}
+	it.items = nil
+	it.next = nil
+}
+
+// request starts requesting the page identified by it.next in a separate goroutine.
+func (it *SourceImageIterator) request() {
+	qs := it.next
+	it.next = nil
+
+	pending := make(chan sourceImagesPage, 1)
+	go func() {
+		res, err := it.client.listSourceImages(it.ctx, it.org, qs)
+		pending <- sourceImagesPage{query: qs, response: res, err: err}
+	}()
+	it.pending = pending
+}
+
+// nextSourceImagesQuery returns the query string of the page following the given response, or nil if it is
+// the last page. The next link is preferred over the cursor as it contains all parameters of the search.
+func nextSourceImagesQuery(current url.Values, res ListSourceImagesResponse) url.Values {
+	if len(res.Items) == 0 {
+		return nil
+	}
+	if res.Links.Next != nil && res.Links.Next.Href != "" {
+		if u, err := url.Parse(res.Links.Next.Href); err == nil {
+			if next := u.Query(); next.Encode() != current.Encode() {
+				return next
+			}
+		}
+	}
+	if res.Cursor == "" || res.Cursor == current.Get("offset") {
+		return nil
+	}
+
+	next := make(url.Values, len(current))
+	for k, v := range current {
+		next[k] = v
+	}
+	next.Set("offset", res.Cursor)
+	return next
+}
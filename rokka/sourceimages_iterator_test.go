@@ -0,0 +1,102 @@
+package rokka
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func sourceImagesPagesRoutes(org string) test.Routes {
+	return test.Routes{
+		"GET /sourceimages/" + org + "?format=png&limit=2":                test.NewResponse(http.StatusOK, "./fixtures/ListSourceImagesPage1.json"),
+		"GET /sourceimages/" + org + "?format=png&limit=2&offset=cursor1": test.NewResponse(http.StatusOK, "./fixtures/ListSourceImagesPage2.json"),
+		"GET /sourceimages/" + org + "?format=png&limit=2&offset=cursor2": test.NewResponse(http.StatusOK, "./fixtures/ListSourceImagesPage3.json"),
+	}
+}
+
+func TestIterateSourceImages(t *testing.T) {
+	org := "test"
+	expected := []string{"aaaaaa", "bbbbbb", "cccccc", "dddddd", "eeeeee"}
+
+	for _, prefetch := range []bool{false, true} {
+		ts := test.NewMockAPI(t, sourceImagesPagesRoutes(org))
+		c := NewClient(&Config{APIAddress: ts.URL})
+
+		it := c.IterateSourceImages(org, ListSourceImagesOptions{Limit: 2, Format: "png"})
+		it.Prefetch = prefetch
+
+		hashes := make([]string, 0)
+		for it.Next() {
+			hashes = append(hashes, it.SourceImage().ShortHash)
+		}
+		it.Close()
+		ts.Close()
+
+		if err := it.Err(); err != nil {
+			t.Errorf("Prefetch %t: unexpected error '%s'", prefetch, err)
+		}
+		if it.Total() != 5 {
+			t.Errorf("Prefetch %t: expected total to be 5, got %d", prefetch, it.Total())
+		}
+		if len(hashes) != len(expected) {
+			t.Fatalf("Prefetch %t: expected %v, got %v", prefetch, expected, hashes)
+		}
+		for i, h := range expected {
+			if hashes[i] != h {
+				t.Errorf("Prefetch %t: expected hash %d to be '%s', got '%s'", prefetch, i, h, hashes[i])
+			}
+		}
+	}
+}
+
+func TestIterateSourceImages_Error(t *testing.T) {
+	org := "test"
+	routes := sourceImagesPagesRoutes(org)
+	routes["GET /sourceimages/"+org+"?format=png&limit=2&offset=cursor1"] = test.NewResponse(http.StatusInternalServerError, "")
+	ts := test.NewMockAPI(t, routes)
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL})
+	it := c.IterateSourceImages(org, ListSourceImagesOptions{Limit: 2, Format: "png"})
+	it.Prefetch = true
+	defer it.Close()
+
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 source images before the error, got %d", n)
+	}
+	if !errors.Is(it.Err(), ErrServer) {
+		t.Errorf("Expected error '%v', got '%v'", ErrServer, it.Err())
+	}
+	if it.Next() {
+		t.Error("Expected Next to return false after an error")
+	}
+}
+
+func TestIterateSourceImages_ContextCanceled(t *testing.T) {
+	org := "test"
+	ts := test.NewMockAPI(t, sourceImagesPagesRoutes(org))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewClient(&Config{APIAddress: ts.URL})
+	it := c.IterateSourceImagesWithContext(ctx, org, ListSourceImagesOptions{Limit: 2, Format: "png"})
+	defer it.Close()
+
+	if !it.Next() {
+		t.Fatalf("Expected a first source image, got error '%v'", it.Err())
+	}
+	cancel()
+	if it.Next() {
+		t.Error("Expected Next to return false after canceling the context")
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("Expected error '%v', got '%v'", context.Canceled, it.Err())
+	}
+}
@@ -3,6 +3,7 @@ package rokka
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -175,6 +176,62 @@ func TestCreateSourceImageWithMetadata(t *testing.T) {
 	t.Log(res)
 }
 
+func TestCreateSourceImage_Streaming(t *testing.T) {
+	org := "test"
+	image, err := ioutil.ReadFile("./fixtures/image.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := []struct {
+		name          string
+		data          io.Reader
+		contentLength bool
+	}{
+		{"seekable reader", bytes.NewReader(image), true},
+		{"reader with length", bytes.NewBuffer(image), true},
+		{"plain reader", ioutil.NopCloser(bytes.NewReader(image)), false},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			r := test.NewResponse(http.StatusOK, "./fixtures/CreateSourceImage.json")
+			r.Assertion = func(t *testing.T, r *http.Request) {
+				if v.contentLength && r.ContentLength <= int64(len(image)) {
+					t.Errorf("Expected Content-Length to be set, got '%d'", r.ContentLength)
+				}
+				if !v.contentLength && r.ContentLength != -1 {
+					t.Errorf("Expected unknown Content-Length, got '%d'", r.ContentLength)
+				}
+				f, _, err := r.FormFile("filename")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				d, err := ioutil.ReadAll(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(d, image) {
+					t.Errorf("Expected uploaded file to have %d bytes, got %d", len(image), len(d))
+				}
+				if m := r.FormValue("meta_user[0]"); m != "{\"key1\":\"value1\"}\n" {
+					t.Errorf("Unexpected user metadata '%s'", m)
+				}
+			}
+			ts := test.NewMockAPI(t, test.Routes{"POST /sourceimages/" + org: r})
+			defer ts.Close()
+
+			c := NewClient(&Config{APIAddress: ts.URL})
+
+			_, err := c.CreateSourceImageWithMetadata(org, "image.png", v.data, map[string]interface{}{"key1": "value1"}, nil)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
 func TestAddDynamicMetadata(t *testing.T) {
 	loc := "https://api.example.org/test/1234-2"
 	org := "test"
@@ -1,6 +1,12 @@
 package rokka
 
-import "net/http"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
 
 // StackOptionsResponse contains the available stack options.
 type StackOptionsResponse struct {
@@ -16,9 +22,14 @@ type StackOptionsResponse struct {
 
 // GetStackOptions returns the available stack options definition.
 func (c *Client) GetStackOptions() (StackOptionsResponse, error) {
+	return c.GetStackOptionsWithContext(context.Background())
+}
+
+// GetStackOptionsWithContext is the same as GetStackOptions with the addition of passing a context.
+func (c *Client) GetStackOptionsWithContext(ctx context.Context) (StackOptionsResponse, error) {
 	result := StackOptionsResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/stackoptions", nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/stackoptions", nil, nil)
 	if err != nil {
 		return result, err
 	}
@@ -26,3 +37,120 @@ func (c *Client) GetStackOptions() (StackOptionsResponse, error) {
 	err = c.CallJSONResponse(req, &result)
 	return result, err
 }
+
+// Validate checks the stack options against the definitions and reports all problems at once.
+func (r StackOptionsResponse) Validate(options StackOptions) error {
+	if problems := r.problems(options); len(problems) > 0 {
+		return fmt.Errorf("rokka: invalid stack options: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (r StackOptionsResponse) problems(options StackOptions) []string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	problems := make([]string, 0)
+	for _, k := range keys {
+		def, ok := r.Properties[k]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("option %s: unknown option", k))
+			continue
+		}
+		if err := validateStackOption(def.Type, def.Values, def.Minimum, def.Maximum, def.MinLength, options[k]); err != nil {
+			problems = append(problems, fmt.Sprintf("option %s: %s", k, err))
+		}
+	}
+	return problems
+}
+
+func validateStackOption(typ interface{}, values []string, min, max, minLength *int, v interface{}) error {
+	types := make([]string, 0)
+	switch t := typ.(type) {
+	case string:
+		types = append(types, t)
+	case []interface{}:
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	actual := jsonSchemaType(v)
+	matches := len(types) == 0
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			matches = true
+		}
+	}
+	if !matches {
+		return fmt.Errorf("expected a value of type %s, got %s", strings.Join(types, " or "), actual)
+	}
+
+	switch v := v.(type) {
+	case string:
+		if len(values) > 0 {
+			found := false
+			for _, e := range values {
+				found = found || e == v
+			}
+			if !found {
+				return fmt.Errorf("'%s' is not one of %s", v, strings.Join(values, ", "))
+			}
+		}
+		if minLength != nil && len(v) < *minLength {
+			return fmt.Errorf("'%s' is shorter than %d characters", v, *minLength)
+		}
+	default:
+		if n, ok := toFloat64(v); ok {
+			if min != nil && n < float64(*min) {
+				return fmt.Errorf("%v is less than the minimum of %d", v, *min)
+			}
+			if max != nil && n > float64(*max) {
+				return fmt.Errorf("%v is greater than the maximum of %d", v, *max)
+			}
+		}
+	}
+	return nil
+}
+
+// jsonSchemaType returns the JSON schema type of a value.
+func jsonSchemaType(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		if n, ok := toFloat64(v); ok {
+			if n == float64(int64(n)) {
+				return "integer"
+			}
+			return "number"
+		}
+	}
+	return "object"
+}
+
+// toFloat64 converts any numeric value to float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
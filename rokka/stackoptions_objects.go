@@ -0,0 +1,256 @@
+package rokka
+
+// Code generated by go generate; DO NOT EDIT.
+// This file was generated from the stack options schema version reconstructed (sha256 07498554cc905b20c7135ecea35ebc17af8a10ceadde62f688d55468661a9f9f).
+
+// Names of the stack options known by this version of the client.
+const (
+	StackOptionAutoformat                = "autoformat"
+	StackOptionBasestack                 = "basestack"
+	StackOptionContentDisposition        = "content_disposition"
+	StackOptionDpr                       = "dpr"
+	StackOptionHeifQuality               = "heif.quality"
+	StackOptionInterlacingMode           = "interlacing.mode"
+	StackOptionJpgQuality                = "jpg.quality"
+	StackOptionJpgTransparencyAutoformat = "jpg.transparency.autoformat"
+	StackOptionJpgTransparencyColor      = "jpg.transparency.color"
+	StackOptionJpgTransparencyConvert    = "jpg.transparency.convert"
+	StackOptionOptimDisableAll           = "optim.disable_all"
+	StackOptionOptimImmediate            = "optim.immediate"
+	StackOptionOptimQuality              = "optim.quality"
+	StackOptionPngCompressionLevel       = "png.compression_level"
+	StackOptionRemoteBasepath            = "remote_basepath"
+	StackOptionRemoteFullurlAllow        = "remote_fullurl_allow"
+	StackOptionSourceFile                = "source_file"
+	StackOptionWebpLossless              = "webp.lossless"
+	StackOptionWebpQuality               = "webp.quality"
+)
+
+// Values of the stack option content_disposition.
+const (
+	StackOptionContentDispositionInline     = "inline"
+	StackOptionContentDispositionAttachment = "attachment"
+)
+
+// StackOptionContentDispositionValues lists the allowed values of the stack option content_disposition.
+var StackOptionContentDispositionValues = []string{"inline", "attachment"}
+
+// Values of the stack option interlacing.mode.
+const (
+	StackOptionInterlacingModeNone      = "none"
+	StackOptionInterlacingModeLine      = "line"
+	StackOptionInterlacingModePlane     = "plane"
+	StackOptionInterlacingModePartition = "partition"
+)
+
+// StackOptionInterlacingModeValues lists the allowed values of the stack option interlacing.mode.
+var StackOptionInterlacingModeValues = []string{"none", "line", "plane", "partition"}
+
+// StackOptionsSpec contains the stack options known by this version of the client as typed fields.
+// Options which aren't set are nil, rokka uses the default value for them.
+//
+// See: https://rokka.io/documentation/references/stacks.html#stack-options
+type StackOptionsSpec struct {
+	// Autoformat is the stack option autoformat. Defaults to false.
+	Autoformat *bool `json:"autoformat,omitempty"`
+	// Basestack is the stack option basestack.
+	Basestack *string `json:"basestack,omitempty"`
+	// ContentDisposition is the stack option content_disposition. Defaults to "inline".
+	ContentDisposition *string `json:"content_disposition,omitempty"`
+	// Dpr is the stack option dpr. Defaults to 1.0.
+	Dpr *float64 `json:"dpr,omitempty"`
+	// HeifQuality is the stack option heif.quality. Defaults to 40.
+	HeifQuality *int `json:"heif.quality,omitempty"`
+	// InterlacingMode is the stack option interlacing.mode. Defaults to "plane".
+	InterlacingMode *string `json:"interlacing.mode,omitempty"`
+	// JpgQuality is the stack option jpg.quality. Defaults to 76.
+	JpgQuality *int `json:"jpg.quality,omitempty"`
+	// JpgTransparencyAutoformat is the stack option jpg.transparency.autoformat. Defaults to false.
+	JpgTransparencyAutoformat *bool `json:"jpg.transparency.autoformat,omitempty"`
+	// JpgTransparencyColor is the stack option jpg.transparency.color. Defaults to "FFFFFF".
+	JpgTransparencyColor *string `json:"jpg.transparency.color,omitempty"`
+	// JpgTransparencyConvert is the stack option jpg.transparency.convert. Defaults to false.
+	JpgTransparencyConvert *bool `json:"jpg.transparency.convert,omitempty"`
+	// OptimDisableAll is the stack option optim.disable_all. Defaults to false.
+	OptimDisableAll *bool `json:"optim.disable_all,omitempty"`
+	// OptimImmediate is the stack option optim.immediate. Defaults to false.
+	OptimImmediate *bool `json:"optim.immediate,omitempty"`
+	// OptimQuality is the stack option optim.quality. Defaults to 0.
+	OptimQuality *int `json:"optim.quality,omitempty"`
+	// PngCompressionLevel is the stack option png.compression_level. Defaults to 7.
+	PngCompressionLevel *int `json:"png.compression_level,omitempty"`
+	// RemoteBasepath is the stack option remote_basepath.
+	RemoteBasepath *string `json:"remote_basepath,omitempty"`
+	// RemoteFullurlAllow is the stack option remote_fullurl_allow. Defaults to false.
+	RemoteFullurlAllow *bool `json:"remote_fullurl_allow,omitempty"`
+	// SourceFile is the stack option source_file. Defaults to false.
+	SourceFile *bool `json:"source_file,omitempty"`
+	// WebpLossless is the stack option webp.lossless. Defaults to false.
+	WebpLossless *bool `json:"webp.lossless,omitempty"`
+	// WebpQuality is the stack option webp.quality. Defaults to 80.
+	WebpQuality *int `json:"webp.quality,omitempty"`
+
+	// Other contains the options unknown to this version of the client and the ones with values which couldn't be
+	// converted to the type of their field.
+	Other StackOptions `json:"-"`
+}
+
+// NewStackOptionsSpec returns the typed form of the stack options. Numbers and booleans passed as string are
+// converted to the type of their field. Options which can't be converted are reported in the error and kept in
+// Other, just like unknown options. Converting the result back using StackOptions therefore doesn't lose anything.
+func NewStackOptionsSpec(options StackOptions) (StackOptionsSpec, error) {
+	s := StackOptionsSpec{}
+	c := newStackOptionsConverter()
+	for k, v := range options {
+		switch k {
+		case StackOptionAutoformat:
+			s.Autoformat = c.toBool(k, v)
+		case StackOptionBasestack:
+			s.Basestack = c.toString(k, v)
+		case StackOptionContentDisposition:
+			s.ContentDisposition = c.toString(k, v)
+		case StackOptionDpr:
+			s.Dpr = c.toFloat64(k, v)
+		case StackOptionHeifQuality:
+			s.HeifQuality = c.toInt(k, v)
+		case StackOptionInterlacingMode:
+			s.InterlacingMode = c.toString(k, v)
+		case StackOptionJpgQuality:
+			s.JpgQuality = c.toInt(k, v)
+		case StackOptionJpgTransparencyAutoformat:
+			s.JpgTransparencyAutoformat = c.toBool(k, v)
+		case StackOptionJpgTransparencyColor:
+			s.JpgTransparencyColor = c.toString(k, v)
+		case StackOptionJpgTransparencyConvert:
+			s.JpgTransparencyConvert = c.toBool(k, v)
+		case StackOptionOptimDisableAll:
+			s.OptimDisableAll = c.toBool(k, v)
+		case StackOptionOptimImmediate:
+			s.OptimImmediate = c.toBool(k, v)
+		case StackOptionOptimQuality:
+			s.OptimQuality = c.toInt(k, v)
+		case StackOptionPngCompressionLevel:
+			s.PngCompressionLevel = c.toInt(k, v)
+		case StackOptionRemoteBasepath:
+			s.RemoteBasepath = c.toString(k, v)
+		case StackOptionRemoteFullurlAllow:
+			s.RemoteFullurlAllow = c.toBool(k, v)
+		case StackOptionSourceFile:
+			s.SourceFile = c.toBool(k, v)
+		case StackOptionWebpLossless:
+			s.WebpLossless = c.toBool(k, v)
+		case StackOptionWebpQuality:
+			s.WebpQuality = c.toInt(k, v)
+		default:
+			c.keep(k, v)
+		}
+	}
+	s.Other = c.other
+	return s, c.err()
+}
+
+// StackOptions returns the options which are set in the map form used by stacks, including Other.
+func (s StackOptionsSpec) StackOptions() StackOptions {
+	o := make(StackOptions, len(s.Other))
+	for k, v := range s.Other {
+		o[k] = v
+	}
+	if s.Autoformat != nil {
+		o[StackOptionAutoformat] = *s.Autoformat
+	}
+	if s.Basestack != nil {
+		o[StackOptionBasestack] = *s.Basestack
+	}
+	if s.ContentDisposition != nil {
+		o[StackOptionContentDisposition] = *s.ContentDisposition
+	}
+	if s.Dpr != nil {
+		o[StackOptionDpr] = *s.Dpr
+	}
+	if s.HeifQuality != nil {
+		o[StackOptionHeifQuality] = *s.HeifQuality
+	}
+	if s.InterlacingMode != nil {
+		o[StackOptionInterlacingMode] = *s.InterlacingMode
+	}
+	if s.JpgQuality != nil {
+		o[StackOptionJpgQuality] = *s.JpgQuality
+	}
+	if s.JpgTransparencyAutoformat != nil {
+		o[StackOptionJpgTransparencyAutoformat] = *s.JpgTransparencyAutoformat
+	}
+	if s.JpgTransparencyColor != nil {
+		o[StackOptionJpgTransparencyColor] = *s.JpgTransparencyColor
+	}
+	if s.JpgTransparencyConvert != nil {
+		o[StackOptionJpgTransparencyConvert] = *s.JpgTransparencyConvert
+	}
+	if s.OptimDisableAll != nil {
+		o[StackOptionOptimDisableAll] = *s.OptimDisableAll
+	}
+	if s.OptimImmediate != nil {
+		o[StackOptionOptimImmediate] = *s.OptimImmediate
+	}
+	if s.OptimQuality != nil {
+		o[StackOptionOptimQuality] = *s.OptimQuality
+	}
+	if s.PngCompressionLevel != nil {
+		o[StackOptionPngCompressionLevel] = *s.PngCompressionLevel
+	}
+	if s.RemoteBasepath != nil {
+		o[StackOptionRemoteBasepath] = *s.RemoteBasepath
+	}
+	if s.RemoteFullurlAllow != nil {
+		o[StackOptionRemoteFullurlAllow] = *s.RemoteFullurlAllow
+	}
+	if s.SourceFile != nil {
+		o[StackOptionSourceFile] = *s.SourceFile
+	}
+	if s.WebpLossless != nil {
+		o[StackOptionWebpLossless] = *s.WebpLossless
+	}
+	if s.WebpQuality != nil {
+		o[StackOptionWebpQuality] = *s.WebpQuality
+	}
+	return o
+}
+
+// Validate checks the values of the options against the allowed values and limits of the schema and returns a
+// *StackOptionsValidationError listing every invalid option. Other isn't checked.
+func (s StackOptionsSpec) Validate() error {
+	v := newOperationValidator("")
+	if s.Basestack != nil {
+		v.minLength(StackOptionBasestack, *s.Basestack, 1)
+	}
+	if s.ContentDisposition != nil {
+		v.enum(StackOptionContentDisposition, *s.ContentDisposition, StackOptionContentDispositionValues)
+	}
+	if s.Dpr != nil {
+		v.minimum(StackOptionDpr, *s.Dpr, 1)
+		v.maximum(StackOptionDpr, *s.Dpr, 10)
+	}
+	if s.HeifQuality != nil {
+		v.minimum(StackOptionHeifQuality, float64(*s.HeifQuality), 1)
+		v.maximum(StackOptionHeifQuality, float64(*s.HeifQuality), 100)
+	}
+	if s.InterlacingMode != nil {
+		v.enum(StackOptionInterlacingMode, *s.InterlacingMode, StackOptionInterlacingModeValues)
+	}
+	if s.JpgQuality != nil {
+		v.minimum(StackOptionJpgQuality, float64(*s.JpgQuality), 1)
+		v.maximum(StackOptionJpgQuality, float64(*s.JpgQuality), 100)
+	}
+	if s.OptimQuality != nil {
+		v.minimum(StackOptionOptimQuality, float64(*s.OptimQuality), 0)
+		v.maximum(StackOptionOptimQuality, float64(*s.OptimQuality), 10)
+	}
+	if s.PngCompressionLevel != nil {
+		v.minimum(StackOptionPngCompressionLevel, float64(*s.PngCompressionLevel), 0)
+		v.maximum(StackOptionPngCompressionLevel, float64(*s.PngCompressionLevel), 9)
+	}
+	if s.WebpQuality != nil {
+		v.minimum(StackOptionWebpQuality, float64(*s.WebpQuality), 1)
+		v.maximum(StackOptionWebpQuality, float64(*s.WebpQuality), 100)
+	}
+	return stackOptionsValidationResult(v)
+}
@@ -0,0 +1,105 @@
+package rokka
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StackOptionsValidationError is returned by StackOptionsSpec.Validate and NewStackOptionsSpec and lists every
+// invalid option.
+type StackOptionsValidationError struct {
+	Errors []OptionError
+}
+
+func (e *StackOptionsValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("rokka: invalid stack options: %s", strings.Join(msgs, "; "))
+}
+
+// stackOptionsValidationResult returns the errors collected by the validator as *StackOptionsValidationError.
+func stackOptionsValidationResult(v *operationValidator) error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errors, func(i, j int) bool { return v.errors[i].Field < v.errors[j].Field })
+	return &StackOptionsValidationError{Errors: v.errors}
+}
+
+// stackOptionsConverter converts the values of the map form of stack options to the types of the fields of
+// StackOptionsSpec. It's used by the generated NewStackOptionsSpec.
+type stackOptionsConverter struct {
+	v     *operationValidator
+	other StackOptions
+}
+
+func newStackOptionsConverter() *stackOptionsConverter {
+	return &stackOptionsConverter{v: newOperationValidator("")}
+}
+
+// keep adds an option to the ones which aren't part of StackOptionsSpec.
+func (c *stackOptionsConverter) keep(name string, value interface{}) {
+	if c.other == nil {
+		c.other = make(StackOptions)
+	}
+	c.other[name] = value
+}
+
+// invalid keeps an option which couldn't be converted and reports it.
+func (c *stackOptionsConverter) invalid(name string, value interface{}, typ string) {
+	c.keep(name, value)
+	c.v.add(name, "can't convert %#v to %s", value, typ)
+}
+
+func (c *stackOptionsConverter) number(value interface{}) (float64, bool) {
+	if n, ok := toFloat64(value); ok {
+		return n, true
+	}
+	return coerceFloat(value)
+}
+
+func (c *stackOptionsConverter) toInt(name string, value interface{}) *int {
+	n, ok := c.number(value)
+	if !ok || n != float64(int(n)) {
+		c.invalid(name, value, "int")
+		return nil
+	}
+	return IntPtr(int(n))
+}
+
+func (c *stackOptionsConverter) toFloat64(name string, value interface{}) *float64 {
+	n, ok := c.number(value)
+	if !ok {
+		c.invalid(name, value, "float64")
+		return nil
+	}
+	return Float64Ptr(n)
+}
+
+func (c *stackOptionsConverter) toBool(name string, value interface{}) *bool {
+	switch v := value.(type) {
+	case bool:
+		return BoolPtr(v)
+	case string:
+		if v == "true" || v == "false" {
+			return BoolPtr(v == "true")
+		}
+	}
+	c.invalid(name, value, "bool")
+	return nil
+}
+
+func (c *stackOptionsConverter) toString(name string, value interface{}) *string {
+	if v, ok := value.(string); ok {
+		return StrPtr(v)
+	}
+	c.invalid(name, value, "string")
+	return nil
+}
+
+func (c *stackOptionsConverter) err() error {
+	return stackOptionsValidationResult(c.v)
+}
@@ -0,0 +1,87 @@
+package rokka
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewStackOptionsSpec(t *testing.T) {
+	options := StackOptions{
+		"jpg.quality":      float64(80),
+		"dpr":              "2",
+		"autoformat":       "true",
+		"interlacing.mode": "line",
+		"some.new.option":  "x",
+	}
+	s, err := NewStackOptionsSpec(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *s.JpgQuality != 80 || *s.Dpr != 2 || !*s.Autoformat || *s.InterlacingMode != StackOptionInterlacingModeLine {
+		t.Errorf("Expected options to be converted, got %+v", s)
+	}
+	if !reflect.DeepEqual(s.Other, StackOptions{"some.new.option": "x"}) {
+		t.Errorf("Expected unknown option to be kept, got %v", s.Other)
+	}
+
+	expected := StackOptions{
+		"jpg.quality":      80,
+		"dpr":              float64(2),
+		"autoformat":       true,
+		"interlacing.mode": "line",
+		"some.new.option":  "x",
+	}
+	if res := s.StackOptions(); !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+}
+
+func TestNewStackOptionsSpec_Invalid(t *testing.T) {
+	s, err := NewStackOptionsSpec(StackOptions{"jpg.quality": "high", "autoformat": 1, "webp.quality": 80})
+	if err == nil {
+		t.Fatal("Expected options which can't be converted to return an error")
+	}
+	expected := `rokka: invalid stack options: option "autoformat" can't convert 1 to bool; option "jpg.quality" can't convert "high" to int`
+	if err.Error() != expected {
+		t.Errorf("Expected error '%s', got '%s'", expected, err)
+	}
+	if *s.WebpQuality != 80 {
+		t.Errorf("Expected valid options to be converted, got %+v", s)
+	}
+	if !reflect.DeepEqual(s.Other, StackOptions{"jpg.quality": "high", "autoformat": 1}) {
+		t.Errorf("Expected invalid options to be kept, got %v", s.Other)
+	}
+}
+
+func TestStackOptionsSpec_Validate(t *testing.T) {
+	s := StackOptionsSpec{
+		JpgQuality:      IntPtr(101),
+		Dpr:             Float64Ptr(0.5),
+		InterlacingMode: StrPtr("diagonal"),
+		Basestack:       StrPtr(""),
+		WebpQuality:     IntPtr(80),
+	}
+	err := s.Validate()
+	verr, ok := err.(*StackOptionsValidationError)
+	if !ok {
+		t.Fatalf("Expected error of type '%T', got '%T'", verr, err)
+	}
+	expected := []string{
+		`option "basestack" must be at least 1 characters long, got ""`,
+		`option "dpr" must be at least 1, got 0.5`,
+		`option "interlacing.mode" must be one of none, line, plane, partition, got "diagonal"`,
+		`option "jpg.quality" must be at most 100, got 101`,
+	}
+	if len(verr.Errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), verr.Errors)
+	}
+	for i, e := range expected {
+		if verr.Errors[i].Error() != e {
+			t.Errorf("Expected error '%s', got '%s'", e, verr.Errors[i])
+		}
+	}
+
+	if err := (StackOptionsSpec{JpgQuality: IntPtr(80)}).Validate(); err != nil {
+		t.Errorf("Expected valid options, got %s", err)
+	}
+}
@@ -2,6 +2,7 @@ package rokka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,6 +13,9 @@ import (
 // StackOptions allows to specify certain settings for a stack. Examples are the compression levels depending on the image format.
 type StackOptions map[string]interface{}
 
+// StackVariables contains the values of variables used by the operations or expressions of a stack.
+type StackVariables map[string]interface{}
+
 // Expression allows to override certain behaviour of a stack based on e.g. DPR size of the requesting client.
 type Expression struct {
 	Expression string                 `json:"expression"`
@@ -26,11 +30,60 @@ type Stack struct {
 	StackOptions     StackOptions `json:"stack_options"`
 	StackOperations  Operations   `json:"stack_operations"`
 	StackExpressions []Expression `json:"stack_expressions"`
+	// Warnings lists the options which couldn't be decoded. The affected operations are kept as *RawOperation.
+	Warnings []DecodeWarning `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler in order to keep the warnings of decoding the operations.
+func (s *Stack) UnmarshalJSON(data []byte) error {
+	type stack Stack
+	raw := struct {
+		*stack
+		StackOperations json.RawMessage `json:"stack_operations"`
+	}{stack: (*stack)(s)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	s.Warnings = nil
+	if len(raw.StackOperations) == 0 {
+		return nil
+	}
+	ops, warnings, err := decodeOperations(raw.StackOperations)
+	if err != nil {
+		return err
+	}
+	s.StackOperations = append(s.StackOperations, ops...)
+	for _, w := range warnings {
+		w.Stack = s.Name
+		s.Warnings = append(s.Warnings, w)
+	}
+	return nil
 }
 
 // ListStacksResponse contains a list of stacks each containing a list of operations.
 type ListStacksResponse struct {
 	Items []Stack `json:"items"`
+	// Warnings lists the warnings of all stacks, see Stack.Warnings.
+	Warnings []DecodeWarning `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler in order to collect the warnings of decoding the operations.
+func (r *ListStacksResponse) UnmarshalJSON(data []byte) error {
+	raw := struct {
+		Items []Stack `json:"items"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.Items = make([]Stack, 0, len(raw.Items))
+	r.Warnings = nil
+	for _, s := range raw.Items {
+		r.Items = append(r.Items, s)
+		r.Warnings = append(r.Warnings, s.Warnings...)
+	}
+	return nil
 }
 
 // CreateStackRequest specifies the stack to create.
@@ -44,22 +97,55 @@ type CreateStackRequest struct {
 //
 // See: https://rokka.io/documentation/references/stacks.html#retrieve-a-stack
 func (c *Client) ListStacks(org string) (ListStacksResponse, error) {
+	return c.ListStacksWithContext(context.Background(), org)
+}
+
+// ListStacksWithContext is the same as ListStacks with the addition of passing a context.
+func (c *Client) ListStacksWithContext(ctx context.Context, org string) (ListStacksResponse, error) {
 	result := ListStacksResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/stacks/"+org, nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/stacks/"+org, nil, nil)
 	if err != nil {
 		return result, err
 	}
 
-	err = c.CallJSONResponse(req, &result)
+	if err = c.CallJSONResponse(req, &result); err != nil {
+		return result, err
+	}
+	return result, c.checkStrictOperations(result.Items...)
+}
+
+// GetStack returns a single stack of the organization.
+//
+// See: https://rokka.io/documentation/references/stacks.html#retrieve-a-stack
+func (c *Client) GetStack(org, name string) (Stack, error) {
+	return c.GetStackWithContext(context.Background(), org, name)
+}
+
+// GetStackWithContext is the same as GetStack with the addition of passing a context.
+func (c *Client) GetStackWithContext(ctx context.Context, org, name string) (Stack, error) {
+	result := Stack{}
+
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("/stacks/%s/%s", org, name), nil, nil)
+	if err != nil {
+		return result, err
+	}
 
-	return result, err
+	if err = c.CallJSONResponse(req, &result); err != nil {
+		return result, err
+	}
+	return result, c.checkStrictOperations(result)
 }
 
 // CreateStack allows to create a new stack for the organization.
 //
 // See: https://rokka.io/documentation/references/stacks.html
 func (c *Client) CreateStack(org, name string, stack CreateStackRequest, overwrite bool) (Stack, error) {
+	return c.CreateStackWithContext(context.Background(), org, name, stack, overwrite)
+}
+
+// CreateStackWithContext is the same as CreateStack with the addition of passing a context.
+func (c *Client) CreateStackWithContext(ctx context.Context, org, name string, stack CreateStackRequest, overwrite bool) (Stack, error) {
 	qs := url.Values{}
 	if overwrite {
 		qs.Set("overwrite", "true")
@@ -73,20 +159,27 @@ func (c *Client) CreateStack(org, name string, stack CreateStackRequest, overwri
 		return result, err
 	}
 
-	req, err := c.NewRequest(http.MethodPut, fmt.Sprintf("/stacks/%s/%s", org, name), b, qs)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("/stacks/%s/%s", org, name), b, qs)
 	if err != nil {
 		return result, err
 	}
 
-	err = c.CallJSONResponse(req, &result)
-	return result, err
+	if err = c.CallJSONResponse(req, &result); err != nil {
+		return result, err
+	}
+	return result, c.checkStrictOperations(result)
 }
 
 // DeleteStack allows to delete an existing stack.
 //
 // See: https://rokka.io/documentation/references/stacks.html
 func (c *Client) DeleteStack(org, name string) error {
-	req, err := c.NewRequest(http.MethodDelete, fmt.Sprintf("/stacks/%s/%s", org, name), nil, nil)
+	return c.DeleteStackWithContext(context.Background(), org, name)
+}
+
+// DeleteStackWithContext is the same as DeleteStack with the addition of passing a context.
+func (c *Client) DeleteStackWithContext(ctx context.Context, org, name string) error {
+	req, err := c.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("/stacks/%s/%s", org, name), nil, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,134 @@
+package rokka
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Fingerprint returns a hash of the stack definition. It's independent of the order of keys, the representation of
+// numbers and empty vs. missing options or expressions, the same as DiffStack.
+func (r CreateStackRequest) Fingerprint() (string, error) {
+	v, err := normalizeJSONValue(r)
+	if err != nil {
+		return "", err
+	}
+	// maps are encoded with sorted keys, which makes the encoding canonical.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Fingerprint returns the fingerprint of the definition of the stack, see CreateStackRequest.Fingerprint.
+func (s Stack) Fingerprint() (string, error) {
+	return s.CreateStackRequest().Fingerprint()
+}
+
+// StackPrecondition describes the expected current state of a stack for UpdateStackIfMatch. Every field which is
+// set needs to match. The zero value expects the stack not to exist.
+type StackPrecondition struct {
+	// Fingerprint is the expected fingerprint of the current definition, see Stack.Fingerprint.
+	Fingerprint string
+	// Created is the expected creation date of the current stack. As it changes whenever a stack is overwritten, it
+	// can be used as version of the stack.
+	Created time.Time
+}
+
+// IsZero returns true if neither the fingerprint nor the creation date is set.
+func (p StackPrecondition) IsZero() bool {
+	return p.Fingerprint == "" && p.Created.IsZero()
+}
+
+// PreconditionOf returns the precondition matching the current state of the stack.
+func PreconditionOf(s Stack) (StackPrecondition, error) {
+	fingerprint, err := s.Fingerprint()
+	if err != nil {
+		return StackPrecondition{}, err
+	}
+	return StackPrecondition{Fingerprint: fingerprint, Created: s.Created}, nil
+}
+
+// StackConflictError is returned by UpdateStackIfMatch if the current stack doesn't match the precondition. It can
+// be checked using errors.Is(err, ErrConflict) as well.
+type StackConflictError struct {
+	Organization string
+	Name         string
+	Expected     StackPrecondition
+	// Current is the stack found when checking the precondition, nil if the stack doesn't exist.
+	Current *Stack
+	// CurrentFingerprint is the fingerprint of the current stack, empty if the stack doesn't exist.
+	CurrentFingerprint string
+}
+
+func (e *StackConflictError) Error() string {
+	var msg string
+	switch {
+	case e.Expected.IsZero():
+		msg = "already exists"
+	case e.Current == nil:
+		msg = "doesn't exist anymore"
+	case e.Expected.Fingerprint != "" && e.Expected.Fingerprint != e.CurrentFingerprint:
+		msg = fmt.Sprintf("has been changed, expected fingerprint %s, got %s", e.Expected.Fingerprint, e.CurrentFingerprint)
+	default:
+		msg = fmt.Sprintf("has been changed, expected it to be created at %s, got %s", e.Expected.Created.Format(time.RFC3339), e.Current.Created.Format(time.RFC3339))
+	}
+	return kindErrorString(ErrConflict, fmt.Sprintf("stack %s of organization %s %s", e.Name, e.Organization, msg))
+}
+
+// Unwrap returns ErrConflict.
+func (e *StackConflictError) Unwrap() error { return ErrConflict }
+
+// UpdateStackIfMatch creates or overwrites a stack only if the current stack matches the precondition, otherwise it
+// returns a *StackConflictError. With the zero precondition, the stack is only created if it doesn't exist yet.
+//
+// rokka doesn't support conditional requests, therefore the stack is fetched and compared right before it's updated.
+// This prevents overwriting changes made since the stack has been read, but two concurrent updates within the short
+// time between checking and updating can't be detected.
+//
+//    current, err := c.GetStack(org, name)
+//    precondition, err := rokka.PreconditionOf(current)
+//    // ... change the definition
+//    _, err = c.UpdateStackIfMatch(org, name, req, precondition)
+//    if errors.Is(err, rokka.ErrConflict) {
+//        // the stack has been changed by someone else in the meantime
+//    }
+func (c *Client) UpdateStackIfMatch(org, name string, stack CreateStackRequest, precondition StackPrecondition) (Stack, error) {
+	return c.UpdateStackIfMatchWithContext(context.Background(), org, name, stack, precondition)
+}
+
+// UpdateStackIfMatchWithContext is the same as UpdateStackIfMatch with the addition of passing a context.
+func (c *Client) UpdateStackIfMatchWithContext(ctx context.Context, org, name string, stack CreateStackRequest, precondition StackPrecondition) (Stack, error) {
+	conflict := &StackConflictError{Organization: org, Name: name, Expected: precondition}
+
+	current, err := c.GetStackWithContext(ctx, org, name)
+	exists := err == nil
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Stack{}, err
+	}
+	if exists {
+		conflict.Current = &current
+		if conflict.CurrentFingerprint, err = current.Fingerprint(); err != nil {
+			return Stack{}, err
+		}
+	}
+
+	if exists != !precondition.IsZero() ||
+		(precondition.Fingerprint != "" && precondition.Fingerprint != conflict.CurrentFingerprint) ||
+		(!precondition.Created.IsZero() && !precondition.Created.Equal(current.Created)) {
+		return Stack{}, conflict
+	}
+
+	res, err := c.CreateStackWithContext(ctx, org, name, stack, exists)
+	// rokka rejects creating a stack which has been created in the meantime.
+	if !exists && errors.Is(err, ErrConflict) {
+		return res, conflict
+	}
+	return res, err
+}
@@ -0,0 +1,157 @@
+package rokka
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestGetStack(t *testing.T) {
+	org := "test-org"
+	name := "test-stack"
+	r := test.NewResponse(http.StatusOK, "./fixtures/GetStack.json")
+	ts := test.NewMockAPI(t, test.Routes{"GET /stacks/" + org + "/" + name: r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL})
+
+	res, err := c.GetStack(org, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Name != name || len(res.StackOperations) != 2 || res.StackOperations[1].Name() != "resize" {
+		t.Errorf("Unexpected stack %+v", res)
+	}
+
+	if _, err := c.GetStack(org, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := CreateStackRequest{
+		Operations: Operations{ResizeOperation{Width: IntPtr(100), Height: IntPtr(50)}},
+		Options:    StackOptions{"jpg.quality": 80, "autoformat": true},
+	}.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CreateStackRequest{
+		Operations:  Operations{&ResizeOperation{Height: IntPtr(50), Width: IntPtr(100)}},
+		Options:     StackOptions{"autoformat": true, "jpg.quality": float64(80)},
+		Expressions: []Expression{},
+	}.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Errorf("Expected equal fingerprints, got %s and %s", a, b)
+	}
+
+	c, err := CreateStackRequest{Operations: Operations{ResizeOperation{Width: IntPtr(101), Height: IntPtr(50)}}}.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == c {
+		t.Error("Expected different fingerprints for different stacks")
+	}
+}
+
+func TestUpdateStackIfMatch(t *testing.T) {
+	org := "test-org"
+	name := "test-stack"
+	route := "/stacks/" + org + "/" + name
+
+	existing := test.NewResponse(http.StatusOK, "./fixtures/GetStack.json")
+	fixture, err := ioutil.ReadFile("./fixtures/GetStack.json")
+	if err != nil {
+		panic(err)
+	}
+	current := Stack{}
+	if err := json.Unmarshal(fixture, &current); err != nil {
+		t.Fatal(err)
+	}
+	precondition, err := PreconditionOf(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := CreateStackRequest{Operations: Operations{ResizeOperation{Width: IntPtr(200)}}}
+
+	table := []struct {
+		name         string
+		get          test.Response
+		put          test.Response
+		overwrite    string
+		precondition StackPrecondition
+		conflict     string
+	}{
+		{"matching", existing, test.NewResponse(http.StatusOK, "./fixtures/CreateStack.json"), "true", precondition, ""},
+		{"matching fingerprint", existing, test.NewResponse(http.StatusOK, "./fixtures/CreateStack.json"), "true", StackPrecondition{Fingerprint: precondition.Fingerprint}, ""},
+		{"create", test.NewResponse(http.StatusNotFound, ""), test.NewResponse(http.StatusOK, "./fixtures/CreateStack.json"), "", StackPrecondition{}, ""},
+		{
+			"changed fingerprint", existing, test.Response{}, "", StackPrecondition{Fingerprint: "abc"},
+			"rokka: conflict (stack test-stack of organization test-org has been changed, expected fingerprint abc, got " + precondition.Fingerprint + ")",
+		},
+		{
+			"changed version", existing, test.Response{}, "", StackPrecondition{Created: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+			"rokka: conflict (stack test-stack of organization test-org has been changed, expected it to be created at 2018-01-01T00:00:00Z, got 2018-02-05T15:45:00Z)",
+		},
+		{
+			"deleted", test.NewResponse(http.StatusNotFound, ""), test.Response{}, "", precondition,
+			"rokka: conflict (stack test-stack of organization test-org doesn't exist anymore)",
+		},
+		{
+			"exists", existing, test.Response{}, "", StackPrecondition{},
+			"rokka: conflict (stack test-stack of organization test-org already exists)",
+		},
+		{
+			"created concurrently", test.NewResponse(http.StatusNotFound, ""), test.NewResponse(http.StatusConflict, ""), "", StackPrecondition{},
+			"rokka: conflict (stack test-stack of organization test-org already exists)",
+		},
+	}
+
+	for _, v := range table {
+		routes := test.Routes{"GET " + route: v.get}
+		put := false
+		if v.put.StatusCode != 0 {
+			overwrite := v.overwrite
+			v.put.Assertion = func(t *testing.T, r *http.Request) {
+				put = true
+				if o := r.URL.Query().Get("overwrite"); o != overwrite {
+					t.Errorf("Expected overwrite '%s', got '%s'", overwrite, o)
+				}
+			}
+			routes["PUT "+route] = v.put
+		}
+		ts := test.NewMockAPI(t, routes)
+		c := NewClient(&Config{APIAddress: ts.URL})
+
+		_, err := c.UpdateStackIfMatch(org, name, req, v.precondition)
+		ts.Close()
+
+		if v.conflict == "" {
+			if err != nil {
+				t.Errorf("%s: %s", v.name, err)
+			}
+			if !put {
+				t.Errorf("%s: expected the stack to be updated", v.name)
+			}
+			continue
+		}
+		if _, ok := err.(*StackConflictError); !ok || !errors.Is(err, ErrConflict) {
+			t.Errorf("%s: expected *StackConflictError, got %v", v.name, err)
+			continue
+		}
+		if err.Error() != v.conflict {
+			t.Errorf("%s: expected '%s', got '%s'", v.name, v.conflict, err)
+		}
+		if put && v.name != "created concurrently" {
+			t.Errorf("%s: expected the stack not to be updated", v.name)
+		}
+	}
+}
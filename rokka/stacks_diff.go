@@ -0,0 +1,177 @@
+package rokka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// StackChange describes a single difference between two stack definitions.
+// Old is nil if the value has been added, New is nil if it has been removed.
+type StackChange struct {
+	// Path identifies the changed value in the JSON representation of a CreateStackRequest,
+	// e.g. `operations[0].options.width` or `options.jpg.quality`.
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func (c StackChange) String() string {
+	switch {
+	case c.Old == nil:
+		return fmt.Sprintf("+ %s: %s", c.Path, compactJSON(c.New))
+	case c.New == nil:
+		return fmt.Sprintf("- %s: %s", c.Path, compactJSON(c.Old))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", c.Path, compactJSON(c.Old), compactJSON(c.New))
+}
+
+// CreateStackRequest returns the definition of the stack, which can be used to create the same stack again.
+func (s Stack) CreateStackRequest() CreateStackRequest {
+	return CreateStackRequest{
+		Operations:  s.StackOperations,
+		Options:     s.StackOptions,
+		Expressions: s.StackExpressions,
+	}
+}
+
+// DiffStack compares two stack definitions and returns the changes needed to get from current to desired.
+// The comparison is semantic: the order of keys, the representation of numbers and empty vs. missing options or
+// expressions don't matter. An operation which has been replaced by another one is reported as a single change.
+func DiffStack(current, desired CreateStackRequest) ([]StackChange, error) {
+	a, err := normalizeJSONValue(current)
+	if err != nil {
+		return nil, err
+	}
+	b, err := normalizeJSONValue(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]StackChange, 0)
+	diffJSONValues("", a, b, &changes)
+	return changes, nil
+}
+
+// normalizeJSONValue converts v into its generic JSON representation and removes null values as well as empty
+// objects and arrays.
+func normalizeJSONValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	return pruneJSONValue(res), nil
+}
+
+func pruneJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if e = pruneJSONValue(e); e == nil {
+				delete(v, k)
+			} else {
+				v[k] = e
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = pruneJSONValue(e)
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+func diffJSONValues(path string, a, b interface{}, changes *[]StackChange) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	if !strings.HasSuffix(path, "]") {
+		a, b = emptyLike(a, b), emptyLike(b, a)
+	}
+
+	ma, okA := a.(map[string]interface{})
+	mb, okB := b.(map[string]interface{})
+	if okA && okB && ma["name"] == mb["name"] {
+		keys := make(map[string]bool)
+		for k := range ma {
+			keys[k] = true
+		}
+		for k := range mb {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffJSONValues(p, ma[k], mb[k], changes)
+		}
+		return
+	}
+
+	sa, okA := a.([]interface{})
+	sb, okB := b.([]interface{})
+	if okA && okB {
+		n := len(sa)
+		if len(sb) > n {
+			n = len(sb)
+		}
+		for i := 0; i < n; i++ {
+			var ea, eb interface{}
+			if i < len(sa) {
+				ea = sa[i]
+			}
+			if i < len(sb) {
+				eb = sb[i]
+			}
+			diffJSONValues(fmt.Sprintf("%s[%d]", path, i), ea, eb, changes)
+		}
+		return
+	}
+
+	*changes = append(*changes, StackChange{Path: path, Old: a, New: b})
+}
+
+// emptyLike returns an empty object or array if v is missing and other is an object or array. This allows to report
+// the single entries of an added or removed object or array. Entries of arrays, e.g. operations, are kept as a whole.
+func emptyLike(v, other interface{}) interface{} {
+	if v != nil {
+		return v
+	}
+	switch other.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}{}
+	case []interface{}:
+		return []interface{}{}
+	}
+	return v
+}
+
+func compactJSON(v interface{}) string {
+	b := new(bytes.Buffer)
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
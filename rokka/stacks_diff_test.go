@@ -0,0 +1,61 @@
+package rokka
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiffStack(t *testing.T) {
+	current := CreateStackRequest{
+		Operations: Operations{
+			ResizeOperation{Width: IntPtr(100), Height: IntPtr(100)},
+			GrayscaleOperation{},
+		},
+		Options: StackOptions{"autoformat": true, "jpg.quality": 80},
+	}
+
+	var same CreateStackRequest
+	if err := json.Unmarshal([]byte(`{"operations":[{"name":"resize","options":{"height":100,"width":100}},{"name":"grayscale","options":{}}],"options":{"jpg.quality":80,"autoformat":true},"expressions":[]}`), &same); err != nil {
+		panic(err)
+	}
+	changes, err := DiffStack(current, same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+
+	desired := CreateStackRequest{
+		Operations: Operations{
+			ResizeOperation{Width: IntPtr(200), Height: IntPtr(100), Mode: StrPtr("fill")},
+			RotateOperation{Angle: Float64Ptr(90)},
+			GrayscaleOperation{},
+		},
+		Options: StackOptions{"jpg.quality": 80},
+		Expressions: []Expression{
+			{Expression: "options.dpr >= 2", Overrides: map[string]interface{}{"options": map[string]interface{}{"jpg.quality": 60}}},
+		},
+	}
+	changes, err = DiffStack(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`+ expressions[0]: {"expression":"options.dpr >= 2","overrides":{"options":{"jpg.quality":60}}}`,
+		`+ operations[0].options.mode: "fill"`,
+		`~ operations[0].options.width: 100 -> 200`,
+		`~ operations[1]: {"name":"grayscale"} -> {"name":"rotate","options":{"angle":90}}`,
+		`+ operations[2]: {"name":"grayscale"}`,
+		`- options.autoformat: true`,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+	}
+	for i, e := range expected {
+		if changes[i].String() != e {
+			t.Errorf("Expected '%s', got '%s'", e, changes[i])
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package rokka
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -31,6 +32,11 @@ type StatsResponse struct {
 //
 // See: https://rokka.io/documentation/references/stats.html
 func (c *Client) GetStats(org string, options GetStatsOptions) (StatsResponse, error) {
+	return c.GetStatsWithContext(context.Background(), org, options)
+}
+
+// GetStatsWithContext is the same as GetStats with the addition of passing a context.
+func (c *Client) GetStatsWithContext(ctx context.Context, org string, options GetStatsOptions) (StatsResponse, error) {
 	result := StatsResponse{}
 
 	qs, err := query.Values(options)
@@ -38,7 +44,7 @@ func (c *Client) GetStats(org string, options GetStatsOptions) (StatsResponse, e
 		return result, err
 	}
 
-	req, err := c.NewRequest(http.MethodGet, "/stats/"+org, nil, qs)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/stats/"+org, nil, qs)
 	if err != nil {
 		return result, err
 	}
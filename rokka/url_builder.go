@@ -0,0 +1,124 @@
+package rokka
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableNamePattern matches valid names of stack variables.
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// URLBuilder builds render URLs with operations, stack options, variables and an SEO filename, e.g.:
+//
+//    u, err := c.NewURLBuilder("example", "product", hash, "jpg").
+//        Operations(rokka.ResizeOperation{Width: rokka.IntPtr(300)}).
+//        Option("jpg.quality", 80).
+//        Variable("text", "Hello").
+//        SEOFilename("Red shoes").
+//        Build()
+//
+// The URL is generated in the canonical form described in RenderURL.String, and signed if enabled in the config.
+type URLBuilder struct {
+	client      *Client
+	url         RenderURL
+	definitions *StackOptionsResponse
+}
+
+// NewURLBuilder returns a URLBuilder for the image with the given hash rendered by the stack in the given format.
+func (c *Client) NewURLBuilder(organization, stack, hash, format string) *URLBuilder {
+	return &URLBuilder{
+		client: c,
+		url: RenderURL{
+			Host:         strings.Replace(c.config.ImageHost, "{{organization}}", organization, -1),
+			Organization: organization,
+			Stack:        stack,
+			Hash:         hash,
+			Format:       format,
+		},
+	}
+}
+
+// Operations adds operations on top of the stack.
+func (b *URLBuilder) Operations(ops ...Operation) *URLBuilder {
+	b.url.Operations = append(b.url.Operations, ops...)
+	return b
+}
+
+// Option overrides a stack option, e.g. `autoformat`, `jpg.quality` or `dpr`.
+func (b *URLBuilder) Option(name string, value interface{}) *URLBuilder {
+	if b.url.Options == nil {
+		b.url.Options = make(StackOptions)
+	}
+	b.url.Options[name] = value
+	return b
+}
+
+// Options overrides multiple stack options.
+func (b *URLBuilder) Options(options StackOptions) *URLBuilder {
+	for k, v := range options {
+		b.Option(k, v)
+	}
+	return b
+}
+
+// Variable sets a variable of the stack.
+func (b *URLBuilder) Variable(name string, value interface{}) *URLBuilder {
+	if b.url.Variables == nil {
+		b.url.Variables = make(StackVariables)
+	}
+	b.url.Variables[name] = value
+	return b
+}
+
+// SEOFilename adds a filename to the URL, which is converted using SEOFilename. An empty name removes the filename.
+func (b *URLBuilder) SEOFilename(name string) *URLBuilder {
+	b.url.Filename = SEOFilename(name)
+	return b
+}
+
+// ValidateOptions enables validating the stack options against the definitions returned by GetStackOptions when
+// building the URL.
+func (b *URLBuilder) ValidateOptions(definitions StackOptionsResponse) *URLBuilder {
+	b.definitions = &definitions
+	return b
+}
+
+// RenderURL returns the URL built so far without validating it.
+func (b *URLBuilder) RenderURL() RenderURL {
+	return b.url
+}
+
+// Build validates the operations, variables and, if enabled, the stack options and returns the URL.
+// All problems found are reported at once.
+func (b *URLBuilder) Build() (string, error) {
+	problems := make([]string, 0)
+	for _, o := range b.url.Operations {
+		if o == nil {
+			problems = append(problems, "operation is nil")
+			continue
+		}
+		if _, err := o.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("operation %s: %s", o.Name(), err))
+		}
+	}
+	for k := range b.url.Variables {
+		if !variableNamePattern.MatchString(k) {
+			problems = append(problems, fmt.Sprintf("variable %s: invalid name", k))
+		}
+	}
+	if b.definitions != nil {
+		problems = append(problems, b.definitions.problems(b.url.Options)...)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return "", fmt.Errorf("rokka: invalid render URL: %s", strings.Join(problems, "; "))
+	}
+
+	u := b.url.String()
+	if b.client.config.SignURLs {
+		return b.client.SignURL(u)
+	}
+	return u, nil
+}
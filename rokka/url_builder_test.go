@@ -0,0 +1,131 @@
+package rokka
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rokka-io/rokka-go/test"
+)
+
+func TestURLBuilder_Build(t *testing.T) {
+	hash := "8bbff49a384a4682fd05144ffe77a84f29f112ff"
+	c := NewClient(&Config{})
+
+	table := []struct {
+		builder  *URLBuilder
+		expected string
+	}{
+		{
+			c.NewURLBuilder("test", "product", hash, "jpg"),
+			"https://test.rokka.io/product/" + hash + ".jpg",
+		},
+		{
+			c.NewURLBuilder("test", "product", hash, "jpg").
+				Operations(ResizeOperation{Width: IntPtr(300)}, GrayscaleOperation{}).
+				Option("jpg.quality", 80).
+				Option("autoformat", true).
+				Variable("w", 200).
+				Variable("color", "ff0000"),
+			"https://test.rokka.io/product/resize-width-300--grayscale--options-autoformat-true-jpg.quality-80--v-color-ff0000-w-200/" + hash + ".jpg",
+		},
+		{
+			c.NewURLBuilder("test", "product", hash, "webp").
+				Variable("text", "Hello World").
+				SEOFilename("Red Shoes (2018)!"),
+			"https://test.rokka.io/product/" + hash + "/Red-Shoes-2018.webp?v=%7B%22text%22%3A%22Hello+World%22%7D",
+		},
+	}
+
+	for _, v := range table {
+		u, err := v.builder.Build()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if u != v.expected {
+			t.Errorf("Expected '%s', got '%s'", v.expected, u)
+		}
+
+		parsed, err := ParseURL(u)
+		if err != nil {
+			t.Errorf("Unexpected error parsing '%s': %s", u, err)
+			continue
+		}
+		if parsed.String() != u {
+			t.Errorf("Expected parsing and formatting '%s' to be identical, got '%s'", u, parsed)
+		}
+	}
+}
+
+func TestURLBuilder_Validate(t *testing.T) {
+	r := test.NewResponse(http.StatusOK, "./fixtures/GetStackOptions.json")
+	ts := test.NewMockAPI(t, test.Routes{"GET /stackoptions": r})
+	defer ts.Close()
+
+	c := NewClient(&Config{APIAddress: ts.URL})
+	definitions, err := c.GetStackOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.NewURLBuilder("test", "product", "c1b110", "jpg").
+		Operations(ResizeOperation{}).
+		Options(StackOptions{"jpg.quality": 120, "interlacing.mode": "zigzag", "png.compression_level": "high", "unknown": 1, "webp.quality": 50}).
+		Variable("in valid", 1).
+		ValidateOptions(definitions).
+		Build()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	expected := []string{
+		"operation resize:",
+		"option interlacing.mode: 'zigzag' is not one of none, line, plane, partition",
+		"option jpg.quality: 120 is greater than the maximum of 100",
+		"option png.compression_level: expected a value of type integer, got string",
+		"option unknown: unknown option",
+		"variable in valid: invalid name",
+	}
+	for _, e := range expected {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("Expected error '%s' to contain '%s'", err, e)
+		}
+	}
+	if strings.Contains(err.Error(), "webp.quality") {
+		t.Errorf("Expected error '%s' not to contain the valid option webp.quality", err)
+	}
+
+	if err := definitions.Validate(StackOptions{"jpg.quality": 80.0, "basestack": "base"}); err != nil {
+		t.Errorf("Unexpected error '%s'", err)
+	}
+}
+
+func TestURLBuilder_Signed(t *testing.T) {
+	c := NewClient(&Config{SignatureKeys: []string{"secret"}, SignURLs: true})
+	u, err := c.NewURLBuilder("test", "protected", "c1b110", "jpg").Variable("text", "a b").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VerifySignedURL(u); err != nil {
+		t.Errorf("Unexpected error verifying '%s': %s", u, err)
+	}
+}
+
+func TestSEOFilename(t *testing.T) {
+	table := map[string]string{
+		"image":             "image",
+		"Red Shoes (2018)!": "Red-Shoes-2018",
+		"--a__b--":          "a__b",
+		"Über uns":          "Ueber-uns",
+		"Crème brûlée":      "Creme-brulee",
+		"Größe ÄÖÜ":         "Groesse-AeOeUe",
+		"日本":                "",
+		"":                  "",
+	}
+	for in, expected := range table {
+		if res := SEOFilename(in); res != expected {
+			t.Errorf("Expected '%s' for '%s', got '%s'", expected, in, res)
+		}
+	}
+}
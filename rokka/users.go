@@ -2,6 +2,7 @@ package rokka
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 )
@@ -31,6 +32,11 @@ type createUser struct {
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#create-a-user
 func (c *Client) CreateUser(org, email string) (CreateUserResponse, error) {
+	return c.CreateUserWithContext(context.Background(), org, email)
+}
+
+// CreateUserWithContext is the same as CreateUser with the addition of passing a context.
+func (c *Client) CreateUserWithContext(ctx context.Context, org, email string) (CreateUserResponse, error) {
 	result := CreateUserResponse{}
 
 	b := new(bytes.Buffer)
@@ -41,7 +47,7 @@ func (c *Client) CreateUser(org, email string) (CreateUserResponse, error) {
 	if err != nil {
 		return result, err
 	}
-	req, err := c.NewRequest(http.MethodPost, "/users", b, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPost, "/users", b, nil)
 	if err != nil {
 		return result, err
 	}
@@ -54,9 +60,14 @@ func (c *Client) CreateUser(org, email string) (CreateUserResponse, error) {
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#get-the-current-user_id
 func (c *Client) GetUserID() (CreateUserIDResponse, error) {
+	return c.GetUserIDWithContext(context.Background())
+}
+
+// GetUserIDWithContext is the same as GetUserID with the addition of passing a context.
+func (c *Client) GetUserIDWithContext(ctx context.Context) (CreateUserIDResponse, error) {
 	result := CreateUserIDResponse{}
 
-	req, err := c.NewRequest(http.MethodGet, "/user", nil, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodGet, "/user", nil, nil)
 	if err != nil {
 		return result, err
 	}
@@ -69,6 +80,11 @@ func (c *Client) GetUserID() (CreateUserIDResponse, error) {
 //
 // See: https://rokka.io/documentation/references/users-and-memberships.html#create-a-user
 func (c *Client) CreateUserWithoutOrg(email string) (CreateUserResponse, error) {
+	return c.CreateUserWithoutOrgWithContext(context.Background(), email)
+}
+
+// CreateUserWithoutOrgWithContext is the same as CreateUserWithoutOrg with the addition of passing a context.
+func (c *Client) CreateUserWithoutOrgWithContext(ctx context.Context, email string) (CreateUserResponse, error) {
 	result := CreateUserResponse{}
 
 	b := new(bytes.Buffer)
@@ -78,7 +94,7 @@ func (c *Client) CreateUserWithoutOrg(email string) (CreateUserResponse, error)
 	if err != nil {
 		return result, err
 	}
-	req, err := c.NewRequest(http.MethodPost, "/users", b, nil)
+	req, err := c.NewRequestWithContext(ctx, http.MethodPost, "/users", b, nil)
 	if err != nil {
 		return result, err
 	}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 )
 
@@ -18,6 +19,9 @@ type Response struct {
 	FileName   string
 	Headers    map[string]string
 	Assertion  AssertionFunc
+	// Preceding responses are returned one after another for the first requests to the route.
+	// Once all of them have been returned, the response itself is returned for every following request.
+	Preceding []Response
 }
 
 // NewResponse creates a response.
@@ -35,12 +39,24 @@ type Routes map[string]Response
 
 // NewMockAPI starts a httptest server which responds to any request with the given response.
 func NewMockAPI(t *testing.T, responses Routes) *httptest.Server {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response, ok := responses[r.Method+" "+r.URL.String()]
+		route := r.Method + " " + r.URL.String()
+		response, ok := responses[route]
 		if !ok {
-			response, ok = responses[r.Method+" "+r.URL.Path]
+			route = r.Method + " " + r.URL.Path
+			response, ok = responses[route]
 		}
 		if ok {
+			mu.Lock()
+			if i := calls[route]; i < len(response.Preceding) {
+				response = response.Preceding[i]
+			}
+			calls[route]++
+			mu.Unlock()
+
 			if response.Assertion != nil {
 				response.Assertion(t, r)
 			}